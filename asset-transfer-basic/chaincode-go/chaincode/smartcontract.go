@@ -1,9 +1,6 @@
 package chaincode
 
 import (
-	"encoding/json"
-	"fmt"
-
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
@@ -13,7 +10,7 @@ type SmartContract struct {
 }
 
 // Asset describes basic details of what makes up a simple asset
-//Insert struct field in alphabetic order => to achieve determinism accross languages
+// Insert struct field in alphabetic order => to achieve determinism accross languages
 // golang keeps the order when marshal to json but doesn't order automatically
 type Asset struct {
 	AppraisedValue int    `json:"AppraisedValue"`
@@ -23,6 +20,11 @@ type Asset struct {
 	Size           int    `json:"Size"`
 }
 
+// assetRepository reads & writes assets on the world state under their ID
+var assetRepository = &Repository[Asset]{
+	Describe: func(id string) string { return "the asset " + id },
+}
+
 // InitLedger adds a base set of assets to the ledger
 func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
 	assets := []Asset{
@@ -34,16 +36,11 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 		{ID: "asset6", Color: "white", Size: 15, Owner: "Michel", AppraisedValue: 800},
 	}
 
-	for _, asset := range assets {
-		assetJSON, err := json.Marshal(asset)
+	for i := range assets {
+		err := assetRepository.Put(ctx, assets[i].ID, &assets[i])
 		if err != nil {
 			return err
 		}
-
-		err = ctx.GetStub().PutState(asset.ID, assetJSON)
-		if err != nil {
-			return fmt.Errorf("failed to put to world state. %v", err)
-		}
 	}
 
 	return nil
@@ -51,14 +48,6 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 
 // CreateAsset issues a new asset to the world state with given details.
 func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, id string, color string, size int, owner string, appraisedValue int) error {
-	exists, err := s.AssetExists(ctx, id)
-	if err != nil {
-		return err
-	}
-	if exists {
-		return fmt.Errorf("the asset %s already exists", id)
-	}
-
 	asset := Asset{
 		ID:             id,
 		Color:          color,
@@ -66,98 +55,48 @@ func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface,
 		Owner:          owner,
 		AppraisedValue: appraisedValue,
 	}
-	assetJSON, err := json.Marshal(asset)
-	if err != nil {
-		return err
-	}
 
-	return ctx.GetStub().PutState(id, assetJSON)
+	return assetRepository.Create(ctx, id, &asset)
 }
 
 // ReadAsset returns the asset stored in the world state with given id.
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*Asset, error) {
-	assetJSON, err := ctx.GetStub().GetState(id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read from world state: %v", err)
-	}
-	if assetJSON == nil {
-		return nil, fmt.Errorf("the asset %s does not exist", id)
-	}
-
-	var asset Asset
-	err = json.Unmarshal(assetJSON, &asset)
-	if err != nil {
-		return nil, err
-	}
-
-	return &asset, nil
+	return assetRepository.Get(ctx, id)
 }
 
 // UpdateAsset updates an existing asset in the world state with provided parameters.
 func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, id string, color string, size int, owner string, appraisedValue int) error {
-	exists, err := s.AssetExists(ctx, id)
-	if err != nil {
-		return err
-	}
-	if !exists {
-		return fmt.Errorf("the asset %s does not exist", id)
-	}
-
-	// overwriting original asset with new asset
-	asset := Asset{
-		ID:             id,
-		Color:          color,
-		Size:           size,
-		Owner:          owner,
-		AppraisedValue: appraisedValue,
-	}
-	assetJSON, err := json.Marshal(asset)
-	if err != nil {
-		return err
-	}
-
-	return ctx.GetStub().PutState(id, assetJSON)
+	return assetRepository.Update(ctx, id, func(asset *Asset) error {
+		// overwriting original asset with new asset
+		*asset = Asset{
+			ID:             id,
+			Color:          color,
+			Size:           size,
+			Owner:          owner,
+			AppraisedValue: appraisedValue,
+		}
+		return nil
+	})
 }
 
 // DeleteAsset deletes an given asset from the world state.
 func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, id string) error {
-	exists, err := s.AssetExists(ctx, id)
-	if err != nil {
-		return err
-	}
-	if !exists {
-		return fmt.Errorf("the asset %s does not exist", id)
-	}
-
-	return ctx.GetStub().DelState(id)
+	return assetRepository.Delete(ctx, id)
 }
 
 // AssetExists returns true when asset with given ID exists in world state
 func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
-	assetJSON, err := ctx.GetStub().GetState(id)
-	if err != nil {
-		return false, fmt.Errorf("failed to read from world state: %v", err)
-	}
-
-	return assetJSON != nil, nil
+	return assetRepository.Exists(ctx, id)
 }
 
 // TransferAsset updates the owner field of asset with given id in world state, and returns the old owner.
 func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, id string, newOwner string) (string, error) {
-	asset, err := s.ReadAsset(ctx, id)
-	if err != nil {
-		return "", err
-	}
-
-	oldOwner := asset.Owner
-	asset.Owner = newOwner
-
-	assetJSON, err := json.Marshal(asset)
-	if err != nil {
-		return "", err
-	}
-
-	err = ctx.GetStub().PutState(id, assetJSON)
+	var oldOwner string
+	err := assetRepository.Update(ctx, id, func(asset *Asset) error {
+		oldOwner = asset.Owner
+		asset.Owner = newOwner
+		return nil
+	})
 	if err != nil {
 		return "", err
 	}
@@ -169,26 +108,19 @@ func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterfac
 func (s *SmartContract) GetAllAssets(ctx contractapi.TransactionContextInterface) ([]*Asset, error) {
 	// range query with empty string for startKey and endKey does an
 	// open-ended query of all assets in the chaincode namespace.
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	entries, err := assetRepository.Range(ctx, "", "")
 	if err != nil {
 		return nil, err
 	}
-	defer resultsIterator.Close()
 
-	var assets []*Asset
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
-		if err != nil {
-			return nil, err
-		}
+	return values(entries), nil
+}
 
-		var asset Asset
-		err = json.Unmarshal(queryResponse.Value, &asset)
-		if err != nil {
-			return nil, err
-		}
-		assets = append(assets, &asset)
+// values returns the values of entries
+func values[T any](entries []Entry[T]) []*T {
+	values := make([]*T, 0, len(entries))
+	for _, entry := range entries {
+		values = append(values, entry.Value)
 	}
-
-	return assets, nil
+	return values
 }
@@ -39,7 +39,7 @@ func TestInitLedger(t *testing.T) {
 
 	chaincodeStub.PutStateReturns(fmt.Errorf("failed inserting key"))
 	err = assetTransfer.InitLedger(transactionContext)
-	require.EqualError(t, err, "failed to put to world state. failed inserting key")
+	require.EqualError(t, err, "failed to put to world state: failed inserting key")
 }
 
 func TestCreateAsset(t *testing.T) {
@@ -174,11 +174,11 @@ func TestGetAllAssets(t *testing.T) {
 	iterator.HasNextReturns(true)
 	iterator.NextReturns(nil, fmt.Errorf("failed retrieving next item"))
 	assets, err = assetTransfer.GetAllAssets(transactionContext)
-	require.EqualError(t, err, "failed retrieving next item")
+	require.EqualError(t, err, "failed to read from result iterator: failed retrieving next item")
 	require.Nil(t, assets)
 
 	chaincodeStub.GetStateByRangeReturns(nil, fmt.Errorf("failed retrieving all assets"))
 	assets, err = assetTransfer.GetAllAssets(transactionContext)
-	require.EqualError(t, err, "failed retrieving all assets")
+	require.EqualError(t, err, "failed to get state by range: failed retrieving all assets")
 	require.Nil(t, assets)
 }
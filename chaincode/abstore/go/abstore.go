@@ -62,7 +62,10 @@ func (t *ABstore) Invoke(ctx contractapi.TransactionContextInterface, A, B strin
 	if Avalbytes == nil {
 		return fmt.Errorf("Entity not found")
 	}
-	Aval, _ = strconv.Atoi(string(Avalbytes))
+	Aval, err = strconv.Atoi(string(Avalbytes))
+	if err != nil {
+		return fmt.Errorf("Value of %s is not an integer: %s", A, string(Avalbytes))
+	}
 
 	Bvalbytes, err := ctx.GetStub().GetState(B)
 	if err != nil {
@@ -71,11 +74,20 @@ func (t *ABstore) Invoke(ctx contractapi.TransactionContextInterface, A, B strin
 	if Bvalbytes == nil {
 		return fmt.Errorf("Entity not found")
 	}
-	Bval, _ = strconv.Atoi(string(Bvalbytes))
+	Bval, err = strconv.Atoi(string(Bvalbytes))
+	if err != nil {
+		return fmt.Errorf("Value of %s is not an integer: %s", B, string(Bvalbytes))
+	}
 
-	// Perform the execution
-	Aval = Aval - X
-	Bval = Bval + X
+	// Perform the execution, refusing values that do not fit an int
+	Aval, ok := subInt(Aval, X)
+	if !ok {
+		return fmt.Errorf("Payment of %d from %s overflows its value %s", X, A, string(Avalbytes))
+	}
+	Bval, ok = addInt(Bval, X)
+	if !ok {
+		return fmt.Errorf("Payment of %d to %s overflows its value %s", X, B, string(Bvalbytes))
+	}
 	fmt.Printf("Aval = %d, Bval = %d\n", Aval, Bval)
 
 	// Write the state back to the ledger
@@ -124,6 +136,28 @@ func (t *ABstore) Query(ctx contractapi.TransactionContextInterface, A string) (
 	return string(Avalbytes), nil
 }
 
+// bounds of int, math.MaxInt & math.MinInt need go 1.17
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
+// addInt returns a + b, false if the sum does not fit an int
+func addInt(a, b int) (int, bool) {
+	if (b > 0 && a > maxInt-b) || (b < 0 && a < minInt-b) {
+		return 0, false
+	}
+	return a + b, true
+}
+
+// subInt returns a - b, false if the difference does not fit an int
+func subInt(a, b int) (int, bool) {
+	if (b > 0 && a < minInt+b) || (b < 0 && a > maxInt+b) {
+		return 0, false
+	}
+	return a - b, true
+}
+
 func main() {
 	cc, err := contractapi.NewChaincode(new(ABstore))
 	if err != nil {
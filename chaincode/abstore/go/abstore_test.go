@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestAddSubInt(t *testing.T) {
+	tests := []struct {
+		a, b          int
+		sum, diff     int
+		sumOK, diffOK bool
+	}{
+		{100, 10, 110, 90, true, true},
+		{-5, -10, -15, 5, true, true},
+		{maxInt, 1, 0, maxInt - 1, false, true},
+		{maxInt, -1, maxInt - 1, 0, true, false},
+		{minInt, -1, 0, minInt + 1, false, true},
+		{minInt, 1, minInt + 1, 0, true, false},
+		{0, minInt, minInt, 0, true, false},
+	}
+	for _, tt := range tests {
+		if sum, ok := addInt(tt.a, tt.b); ok != tt.sumOK || sum != tt.sum {
+			t.Errorf("addInt(%d, %d) = %d, %t, want %d, %t", tt.a, tt.b, sum, ok, tt.sum, tt.sumOK)
+		}
+		if diff, ok := subInt(tt.a, tt.b); ok != tt.diffOK || diff != tt.diff {
+			t.Errorf("subInt(%d, %d) = %d, %t, want %d, %t", tt.a, tt.b, diff, ok, tt.diff, tt.diffOK)
+		}
+	}
+}
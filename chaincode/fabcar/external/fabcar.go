@@ -5,7 +5,6 @@ SPDX-License-Identifier: Apache-2.0
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
@@ -32,6 +31,9 @@ type Car struct {
 	Owner  string `json:"owner"`
 }
 
+// carRepository reads & writes cars on the world state under their car number
+var carRepository = &Repository[Car]{}
+
 // QueryResult structure used for handling result of query
 type QueryResult struct {
 	Key    string `json:"Key"`
@@ -53,12 +55,11 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 		Car{Make: "Holden", Model: "Barina", Colour: "brown", Owner: "Shotaro"},
 	}
 
-	for i, car := range cars {
-		carAsBytes, _ := json.Marshal(car)
-		err := ctx.GetStub().PutState("CAR"+strconv.Itoa(i), carAsBytes)
+	for i := range cars {
+		err := carRepository.Put(ctx, "CAR"+strconv.Itoa(i), &cars[i])
 
 		if err != nil {
-			return fmt.Errorf("Failed to put to world state. %s", err.Error())
+			return err
 		}
 	}
 
@@ -74,27 +75,12 @@ func (s *SmartContract) CreateCar(ctx contractapi.TransactionContextInterface, c
 		Owner:  owner,
 	}
 
-	carAsBytes, _ := json.Marshal(car)
-
-	return ctx.GetStub().PutState(carNumber, carAsBytes)
+	return carRepository.Put(ctx, carNumber, &car)
 }
 
 // QueryCar returns the car stored in the world state with given id
 func (s *SmartContract) QueryCar(ctx contractapi.TransactionContextInterface, carNumber string) (*Car, error) {
-	carAsBytes, err := ctx.GetStub().GetState(carNumber)
-
-	if err != nil {
-		return nil, fmt.Errorf("Failed to read from world state. %s", err.Error())
-	}
-
-	if carAsBytes == nil {
-		return nil, fmt.Errorf("%s does not exist", carNumber)
-	}
-
-	car := new(Car)
-	_ = json.Unmarshal(carAsBytes, car)
-
-	return car, nil
+	return carRepository.Get(ctx, carNumber)
 }
 
 // QueryAllCars returns all cars found in world state
@@ -102,26 +88,16 @@ func (s *SmartContract) QueryAllCars(ctx contractapi.TransactionContextInterface
 	startKey := ""
 	endKey := ""
 
-	resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	entries, err := carRepository.Range(ctx, startKey, endKey)
 
 	if err != nil {
 		return nil, err
 	}
-	defer resultsIterator.Close()
 
 	results := []QueryResult{}
 
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
-
-		if err != nil {
-			return nil, err
-		}
-
-		car := new(Car)
-		_ = json.Unmarshal(queryResponse.Value, car)
-
-		queryResult := QueryResult{Key: queryResponse.Key, Record: car}
+	for _, entry := range entries {
+		queryResult := QueryResult{Key: entry.Key, Record: entry.Value}
 		results = append(results, queryResult)
 	}
 
@@ -130,17 +106,10 @@ func (s *SmartContract) QueryAllCars(ctx contractapi.TransactionContextInterface
 
 // ChangeCarOwner updates the owner field of car with given id in world state
 func (s *SmartContract) ChangeCarOwner(ctx contractapi.TransactionContextInterface, carNumber string, newOwner string) error {
-	car, err := s.QueryCar(ctx, carNumber)
-
-	if err != nil {
-		return err
-	}
-
-	car.Owner = newOwner
-
-	carAsBytes, _ := json.Marshal(car)
-
-	return ctx.GetStub().PutState(carNumber, carAsBytes)
+	return carRepository.Update(ctx, carNumber, func(car *Car) error {
+		car.Owner = newOwner
+		return nil
+	})
 }
 
 func main() {
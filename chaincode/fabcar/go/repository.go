@@ -0,0 +1,158 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Repository reads & writes values of type T, put on the world state as Json under their key.
+// Errors of the stub are wrapped with what failed.
+//
+// It is a minimal port of the Repository of the LoC chaincode (chaincode/loc/go/chaincode/repository.go),
+// without its key prefix, events, rich queries & history, which the Go samples have no use for.
+// asset-transfer-basic & fabcar keep the same copy of it, change them together.
+type Repository[T any] struct {
+	// Describe names the value under key in errors, eg. "the asset asset1", the key if nil
+	Describe func(key string) string
+}
+
+// Entry is a value read by a query of a Repository, with the key it is stored under
+type Entry[T any] struct {
+	Key   string
+	Value *T
+}
+
+// NotFoundError is returned for a key without value
+type NotFoundError struct {
+	Name string // of the value, eg. "the asset asset1"
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s does not exist", e.Name)
+}
+
+// ExistsError is returned when creating a value under a key that already has one
+type ExistsError struct {
+	Name string // of the value, eg. "the asset asset1"
+}
+
+func (e *ExistsError) Error() string {
+	return fmt.Sprintf("%s already exists", e.Name)
+}
+
+// describe names the value under key in errors
+func (r *Repository[T]) describe(key string) string {
+	if r.Describe == nil {
+		return key
+	}
+	return r.Describe(key)
+}
+
+// Get returns the value under key, a NotFoundError if there is none
+func (r *Repository[T]) Get(ctx contractapi.TransactionContextInterface, key string) (*T, error) {
+	valueJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if valueJSON == nil {
+		return nil, &NotFoundError{Name: r.describe(key)}
+	}
+	var value T
+	err = json.Unmarshal(valueJSON, &value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	return &value, nil
+}
+
+// Exists reports whether there is a value under key
+func (r *Repository[T]) Exists(ctx contractapi.TransactionContextInterface, key string) (bool, error) {
+	valueJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	return valueJSON != nil, nil
+}
+
+// Create puts value under key, an ExistsError if key already has a value
+func (r *Repository[T]) Create(ctx contractapi.TransactionContextInterface, key string, value *T) error {
+	exists, err := r.Exists(ctx, key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return &ExistsError{Name: r.describe(key)}
+	}
+	return r.Put(ctx, key, value)
+}
+
+// Put puts value under key, whether key has a value or not
+func (r *Repository[T]) Put(ctx contractapi.TransactionContextInterface, key string, value *T) error {
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal into Json: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, valueJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return nil
+}
+
+// Update reads the value under key, changes it with mutate & puts it back. Nothing is put if mutate fails.
+func (r *Repository[T]) Update(ctx contractapi.TransactionContextInterface, key string, mutate func(value *T) error) error {
+	value, err := r.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	err = mutate(value)
+	if err != nil {
+		return err
+	}
+	return r.Put(ctx, key, value)
+}
+
+// Delete deletes the value under key, a NotFoundError if key has no value
+func (r *Repository[T]) Delete(ctx contractapi.TransactionContextInterface, key string) error {
+	exists, err := r.Exists(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return &NotFoundError{Name: r.describe(key)}
+	}
+	err = ctx.GetStub().DelState(key)
+	if err != nil {
+		return fmt.Errorf("failed to delete from world state: %v", err)
+	}
+	return nil
+}
+
+// Range returns the values with keys from startKey to endKey, excluded, "" & "" for all of them
+func (r *Repository[T]) Range(ctx contractapi.TransactionContextInterface, startKey string, endKey string) ([]Entry[T], error) {
+	resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state by range: %v", err)
+	}
+	defer resultsIterator.Close()
+	entries := make([]Entry[T], 0)
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from result iterator: %v", err)
+		}
+		var value T
+		err = json.Unmarshal(queryResult.Value, &value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal query result: %v", err)
+		}
+		entries = append(entries, Entry[T]{Key: queryResult.Key, Value: &value})
+	}
+	return entries, nil
+}
@@ -6,13 +6,29 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"sample.com/lc/chaincode"
 )
 
 func main() {
-	locChaincode, err := contractapi.NewChaincode(&chaincode.LocContract{})
+	// timezone of status log timestamps, must be the same on every endorsing peer
+	if timeZone := os.Getenv("LOC_TIMEZONE"); timeZone != "" {
+		if err := chaincode.SetTimeZone(timeZone); err != nil {
+			log.Panicf("Error reading LOC_TIMEZONE: %v", err)
+		}
+	}
+	locContract := &chaincode.LocContract{}
+	// optional X.509 attribute checks per action, eg. LOC_REQUIRED_ATTRIBUTES="IssueLoC:loc.role=maker"
+	requiredAttributes, err := chaincode.ParseAttributeRequirements(os.Getenv("LOC_REQUIRED_ATTRIBUTES"))
+	if err != nil {
+		log.Panicf("Error reading LOC_REQUIRED_ATTRIBUTES: %v", err)
+	}
+	locContract.RequiredAttributes = requiredAttributes
+	// MSPs allowed to read every LoC, eg. LOC_REGULATOR_MSPS="RegulatorMSP"
+	locContract.RegulatorMSPs = chaincode.ParseRegulatorMSPs(os.Getenv("LOC_REGULATOR_MSPS"))
+	locChaincode, err := contractapi.NewChaincode(locContract)
 	if err != nil {
 		log.Panicf("Error creating loc chaincode: %v", err)
 	}
@@ -0,0 +1,542 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/money"
+)
+
+// amendmentIndex is the composite key object type amendments are stored under, attributes are LoC id & sequence
+const amendmentIndex = "loc~amendment~seq"
+
+// AmendmentStatus is the lifecycle state of an amendment
+type AmendmentStatus string
+
+const (
+	AmendmentProposed AmendmentStatus = "PROPOSED"
+	AmendmentAccepted AmendmentStatus = "ACCEPTED"
+	AmendmentRejected AmendmentStatus = "REJECTED"
+)
+
+// FieldChange is one LoC field an amendment changes, values are rendered as strings
+type FieldChange struct {
+	Field    string `json:"field"` // json name of the LoC field, eg. date_of_expiry
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+// Amendment is a proposed change to the terms of an LoC. As per UCP 600 Article 10 it only takes effect
+// once accepted by the advising bank on behalf of the beneficiary, until then the LoC keeps its original terms.
+type Amendment struct {
+	DocType      string          `json:"doc_type"` // always "Amendment"
+	LoCID        string          `json:"loc_id"`
+	Seq          int             `json:"seq"` // 1 for the first amendment of an LoC
+	Changes      []FieldChange   `json:"changes"`
+	Status       AmendmentStatus `json:"status"`
+	ProposedBy   string          `json:"proposed_by"`
+	ProposedAt   string          `json:"proposed_at"`
+	ProposedTxID string          `json:"proposed_tx_id"`
+	DecidedBy    string          `json:"decided_by"`
+	DecidedAt    string          `json:"decided_at"`
+	DecidedTxID  string          `json:"decided_tx_id"`
+	Reason       string          `json:"reason"` // why it was rejected
+}
+
+// amendableField reads & writes one amendable LoC field as a string
+type amendableField struct {
+	get func(loc *LoC) string
+	set func(loc *LoC, value string) error
+}
+
+func stringField(field func(loc *LoC) *string) amendableField {
+	return amendableField{
+		get: func(loc *LoC) string { return *field(loc) },
+		set: func(loc *LoC, value string) error { *field(loc) = value; return nil },
+	}
+}
+
+func intField(field func(loc *LoC) *int) amendableField {
+	return amendableField{
+		get: func(loc *LoC) string { return strconv.Itoa(*field(loc)) },
+		set: func(loc *LoC, value string) error {
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%q is not a whole number", value)
+			}
+			*field(loc) = number
+			return nil
+		},
+	}
+}
+
+// amendableFields are the LoC terms an amendment may change, keyed by json name
+var amendableFields = map[string]amendableField{
+	"amount": {
+		get: func(loc *LoC) string { return loc.Amount.Amount },
+		set: func(loc *LoC, value string) error {
+			amount, err := money.Parse(value, loc.CurrencyCode)
+			if err != nil {
+				return err
+			}
+			loc.Amount = amount
+			return nil
+		},
+	},
+	"tolerance_plus_percent":            intField(func(loc *LoC) *int { return &loc.TolerancePlusPercent }),
+	"tolerance_minus_percent":           intField(func(loc *LoC) *int { return &loc.ToleranceMinusPercent }),
+	"date_of_expiry":                    stringField(func(loc *LoC) *string { return &loc.DateOfExpiry }),
+	"place_of_expiry":                   stringField(func(loc *LoC) *string { return &loc.PlaceOfExpiry }),
+	"latest_date_of_shipment":           stringField(func(loc *LoC) *string { return &loc.LatestDateOfShipment }),
+	"shipment_period":                   stringField(func(loc *LoC) *string { return &loc.ShipmentPeriod }),
+	"partial_shipments":                 stringField(func(loc *LoC) *string { return &loc.PartialShipments }),
+	"loading_from":                      stringField(func(loc *LoC) *string { return &loc.LoadingFrom }),
+	"transportation_to":                 stringField(func(loc *LoC) *string { return &loc.TransportationTo }),
+	"description_of_goods_and_services": stringField(func(loc *LoC) *string { return &loc.DescriptionOfGoodsAndServices }),
+	"documents_required":                stringField(func(loc *LoC) *string { return &loc.DocumentsRequired }),
+	"period_for_presentation":           stringField(func(loc *LoC) *string { return &loc.PeriodForPresentation }),
+}
+
+// diffAmendment turns proposed field values into the changes they make to loc, in field order.
+// The changes are applied to a copy of loc to make sure the amended LoC would still be valid.
+func diffAmendment(loc *LoC, proposed map[string]string) ([]FieldChange, error) {
+	verr := &ValidationError{}
+	if len(proposed) == 0 {
+		verr.add("an amendment must change at least one field")
+	}
+	fields := make([]string, 0, len(proposed))
+	for field := range proposed {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	amended := *loc
+	changes := make([]FieldChange, 0, len(fields))
+	for _, field := range fields {
+		amendable, ok := amendableFields[field]
+		if !ok {
+			verr.add("%s can not be amended", field)
+			continue
+		}
+		oldValue := amendable.get(loc)
+		if err := amendable.set(&amended, strings.TrimSpace(proposed[field])); err != nil {
+			verr.add("%s %v", field, err)
+			continue
+		}
+		// as the field renders it, eg. 1000 is 1000.00 for an amount in INR
+		newValue := amendable.get(&amended)
+		if newValue == oldValue {
+			verr.add("%s is already %q", field, oldValue)
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field, OldValue: oldValue, NewValue: newValue})
+	}
+	if len(verr.Violations) == 0 {
+		if err := validateLoC(&amended); err != nil {
+			return nil, err
+		}
+	}
+	return changes, verr.orNil()
+}
+
+// applyAmendment makes the changes of amendment to loc, the fields must still hold the values it was proposed against
+func applyAmendment(loc *LoC, amendment *Amendment) error {
+	for _, change := range amendment.Changes {
+		amendable, ok := amendableFields[change.Field]
+		if !ok {
+			return fmt.Errorf("%s can not be amended", change.Field)
+		}
+		if current := amendable.get(loc); current != change.OldValue {
+			return fmt.Errorf("%s changed from %q to %q since amendment #%d was proposed", change.Field, change.OldValue, current, amendment.Seq)
+		}
+		if err := amendable.set(loc, change.NewValue); err != nil {
+			return fmt.Errorf("%s %v", change.Field, err)
+		}
+	}
+	err := validateLoC(loc)
+	if err != nil {
+		return err
+	}
+	normalizeLoCDates(loc)
+	return nil
+}
+
+// amendmentKey returns the composite key of amendment {seq} of LoC {id}, zero padded so keys sort by sequence
+func amendmentKey(ctx contractapi.TransactionContextInterface, id string, seq int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(amendmentIndex, []string{id, fmt.Sprintf("%06d", seq)})
+}
+
+// readAmendment returns amendment {seq} of loc, amendments are kept in the private data collection of loc
+func readAmendment(ctx contractapi.TransactionContextInterface, loc *LoC, seq int) (*Amendment, error) {
+	key, err := amendmentKey(ctx, loc.ID, seq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite key: %v", err)
+	}
+	amendmentJSON, err := ctx.GetStub().GetPrivateData(privateCollection(loc), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private data: %v", err)
+	}
+	if amendmentJSON == nil {
+		return nil, fmt.Errorf("amendment #%d of LoC with Id@%s does not exist", seq, loc.ID)
+	}
+	var amendment Amendment
+	err = json.Unmarshal(amendmentJSON, &amendment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	return &amendment, nil
+}
+
+// putAmendment puts amendment of loc in its private data collection under its composite key, & its public part, see
+// publicAmendment, on the world state under the same key for the other parties to the LoC
+func putAmendment(ctx contractapi.TransactionContextInterface, loc *LoC, amendment *Amendment) error {
+	key, err := amendmentKey(ctx, amendment.LoCID, amendment.Seq)
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	amendmentJSON, err := json.Marshal(amendment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal into Json: %v", err)
+	}
+	err = ctx.GetStub().PutPrivateData(privateCollection(loc), key, amendmentJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put private data: %v", err)
+	}
+	publicJSON, err := json.Marshal(publicAmendment(amendment))
+	if err != nil {
+		return fmt.Errorf("failed to marshal into Json: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, publicJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return nil
+}
+
+// decideAmendment marks amendment as accepted/rejected by the submitting client in the current transaction
+func decideAmendment(ctx contractapi.TransactionContextInterface, amendment *Amendment, status AmendmentStatus, reason string) error {
+	org, err := getOrgName(ctx)
+	if err != nil {
+		return err
+	}
+	txTime, err := GetTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	amendment.Status = status
+	amendment.DecidedBy = org
+	amendment.DecidedAt = txTime.Format(time.RFC3339)
+	amendment.DecidedTxID = ctx.GetStub().GetTxID()
+	amendment.Reason = reason
+	return nil
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// ProposeLoCAmendment proposes changes to the terms of LoC with given {id}, eg. {"date_of_expiry":"20220331"}.
+// Changes of private fields, eg. {"amount":"12000000"}, are passed in transient field loc_amendment instead.
+// The LoC keeps its current terms until the amendment is acknowledged by the advising bank.
+func (c *LocContract) ProposeLoCAmendment(ctx contractapi.TransactionContextInterface, id string, jsonChanges string) (*LoC, error) {
+	// Un-Marshal jsonChanges to field -> new value
+	proposed := map[string]string{}
+	err := decodeStrict(jsonChanges, &proposed)
+	if err != nil {
+		log.Println("error -> decodeStrict -> ProposeLoCAmendment\n", err)
+		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	// jsonChanges ends up in the block for every org to see
+	for field := range proposed {
+		if privateFields[field] {
+			return nil, fmt.Errorf("%s is private, pass its change in transient field %s", field, amendmentTransientKey)
+		}
+	}
+	privateChanges, err := transientAmendment(ctx)
+	if err != nil {
+		log.Println("error -> transientAmendment -> ProposeLoCAmendment\n", err)
+		return nil, err
+	}
+	for field, value := range privateChanges {
+		proposed[field] = value
+	}
+	return c.proposeAmendment(ctx, id, ActionProposeLoCAmendment, proposed)
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// AmendLoCAmount proposes an amendment of the LoC amount for LoC with given {id}. The amount is private, it is passed in
+// transient field loc_amendment, eg. {"amount":"12000000.50"}, a decimal in the currency of the LoC.
+func (c *LocContract) AmendLoCAmount(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+	proposed, err := transientAmendment(ctx)
+	if err != nil {
+		log.Println("error -> transientAmendment -> AmendLoCAmount\n", err)
+		return nil, err
+	}
+	amount, ok := proposed["amount"]
+	if !ok || len(proposed) != 1 {
+		return nil, fmt.Errorf("%s must only hold the amount, eg. {\"amount\":\"12000000.50\"}, ProposeLoCAmendment amends other fields", amendmentTransientKey)
+	}
+	return c.proposeAmendment(ctx, id, ActionAmendLoCAmount, map[string]string{"amount": amount})
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// proposeAmendment stores a new amendment of LoC with given {id} & moves the LoC to AMENDED_BY_APPLICANT_BANK
+func (c *LocContract) proposeAmendment(ctx contractapi.TransactionContextInterface, id string, action Action, proposed map[string]string) (*LoC, error) {
+	// Get LoC if exists, update it & put it back
+	loc, err := c.updateLoC(ctx, id, func(loc *LoC) (*Event, error) {
+		// only applicant bank can do it
+		err := c.authorize(ctx, loc, action)
+		if err != nil {
+			return nil, err
+		}
+		// check the LoC can move to the next status
+		transition, err := checkTransition(ctx, loc, action)
+		if err != nil {
+			return nil, err
+		}
+		// diff proposed values against current terms
+		changes, err := diffAmendment(loc, proposed)
+		if err != nil {
+			log.Printf("error -> diffAmendment -> %s\n%v", action, err)
+			return nil, err
+		}
+		// the amended LoC must still cover its drawings
+		amended := *loc
+		err = applyAmendment(&amended, &Amendment{Seq: loc.AmendmentCount + 1, Changes: changes})
+		if err != nil {
+			log.Printf("error -> applyAmendment -> %s\n%v", action, err)
+			return nil, err
+		}
+		err = checkDrawn(ctx, &amended)
+		if err != nil {
+			log.Printf("error -> checkDrawn -> %s\n%v", action, err)
+			return nil, err
+		}
+		txTime, err := GetTxTime(ctx)
+		if err != nil {
+			log.Printf("error -> GetTxTime -> %s\n%v", action, err)
+			return nil, err
+		}
+		loc.AmendmentCount++
+		amendment := &Amendment{
+			DocType:      "Amendment",
+			LoCID:        loc.ID,
+			Seq:          loc.AmendmentCount,
+			Changes:      changes,
+			Status:       AmendmentProposed,
+			ProposedBy:   loc.ApplicantBank,
+			ProposedAt:   txTime.Format(time.RFC3339),
+			ProposedTxID: ctx.GetStub().GetTxID(),
+		}
+		// current status & status log
+		fields := make([]string, 0, len(changes))
+		for _, change := range changes {
+			fields = append(fields, change.Field)
+		}
+		err = recordStatus(ctx, loc, transition.To, fmt.Sprintf("LoC amendment #%d of %s proposed by %s", amendment.Seq, strings.Join(fields, ", "), loc.ApplicantBank))
+		if err != nil {
+			log.Printf("error -> recordStatus -> %s\n%v", action, err)
+			return nil, err
+		}
+		// Put amendment on ledger
+		err = putAmendment(ctx, loc, amendment)
+		if err != nil {
+			log.Printf("error -> putAmendment -> %s\n%v", action, err)
+			return nil, err
+		}
+		return &Event{Name: "LoCAmendmentProposed", Payload: publicAmendment(amendment)}, nil
+	})
+	if err != nil {
+		log.Printf("error -> c.updateLoC -> %s\n%v", action, err)
+		return nil, err
+	}
+	return loc, nil
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// AcknowledgeLoCAmendment accepts the pending amendment of LoC with given {id}, applies its changes and updates status
+func (c *LocContract) AcknowledgeLoCAmendment(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+	// Get LoC if exists, update it & put it back
+	loc, err := c.updateLoC(ctx, id, func(loc *LoC) (*Event, error) {
+		// only advising bank can do it
+		err := c.authorize(ctx, loc, ActionAcknowledgeLoCAmendment)
+		if err != nil {
+			return nil, err
+		}
+		// check the LoC can move to the next status
+		transition, err := checkTransition(ctx, loc, ActionAcknowledgeLoCAmendment)
+		if err != nil {
+			return nil, err
+		}
+		// Get pending amendment & apply it
+		amendment, err := readAmendment(ctx, loc, loc.AmendmentCount)
+		if err != nil {
+			log.Println("error -> readAmendment -> AcknowledgeLoCAmendment\n", err)
+			return nil, err
+		}
+		if amendment.Status != AmendmentProposed {
+			return nil, fmt.Errorf("amendment #%d of LoC with Id@%s is %s, not %s", amendment.Seq, loc.ID, amendment.Status, AmendmentProposed)
+		}
+		err = applyAmendment(loc, amendment)
+		if err != nil {
+			log.Println("error -> applyAmendment -> AcknowledgeLoCAmendment\n", err)
+			return nil, err
+		}
+		err = checkDrawn(ctx, loc)
+		if err != nil {
+			log.Println("error -> checkDrawn -> AcknowledgeLoCAmendment\n", err)
+			return nil, err
+		}
+		err = decideAmendment(ctx, amendment, AmendmentAccepted, "")
+		if err != nil {
+			log.Println("error -> decideAmendment -> AcknowledgeLoCAmendment\n", err)
+			return nil, err
+		}
+		// current status & status log
+		err = recordStatus(ctx, loc, transition.To, fmt.Sprintf("LoC amendment #%d acknowledged by %s", amendment.Seq, loc.AdviseThroughBank))
+		if err != nil {
+			log.Println("error -> recordStatus -> AcknowledgeLoCAmendment\n", err)
+			return nil, err
+		}
+		// current status & status log
+		err = recordStatus(ctx, loc, transition.Then, followUpComment(loc, transition.Then))
+		if err != nil {
+			log.Println("error -> recordStatus -> AcknowledgeLoCAmendment\n", err)
+			return nil, err
+		}
+		// Put amendment on ledger
+		err = putAmendment(ctx, loc, amendment)
+		if err != nil {
+			log.Println("error -> putAmendment -> AcknowledgeLoCAmendment\n", err)
+			return nil, err
+		}
+		return &Event{Name: "LoCAmendmentAcknowledged", Payload: publicAmendment(amendment)}, nil
+	})
+	if err != nil {
+		log.Println("error -> c.updateLoC -> AcknowledgeLoCAmendment\n", err)
+		return nil, err
+	}
+	return loc, nil
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// RejectLoCAmendment rejects the pending amendment of LoC with given {id} for given {reason}, the LoC keeps its terms
+func (c *LocContract) RejectLoCAmendment(ctx contractapi.TransactionContextInterface, id string, reason string) (*LoC, error) {
+	// Get LoC if exists, update it & put it back
+	loc, err := c.updateLoC(ctx, id, func(loc *LoC) (*Event, error) {
+		// only advising bank can do it
+		err := c.authorize(ctx, loc, ActionRejectLoCAmendment)
+		if err != nil {
+			return nil, err
+		}
+		// check the LoC can move to the next status
+		transition, err := checkTransition(ctx, loc, ActionRejectLoCAmendment)
+		if err != nil {
+			return nil, err
+		}
+		// Get pending amendment & reject it
+		amendment, err := readAmendment(ctx, loc, loc.AmendmentCount)
+		if err != nil {
+			log.Println("error -> readAmendment -> RejectLoCAmendment\n", err)
+			return nil, err
+		}
+		if amendment.Status != AmendmentProposed {
+			return nil, fmt.Errorf("amendment #%d of LoC with Id@%s is %s, not %s", amendment.Seq, loc.ID, amendment.Status, AmendmentProposed)
+		}
+		err = decideAmendment(ctx, amendment, AmendmentRejected, reason)
+		if err != nil {
+			log.Println("error -> decideAmendment -> RejectLoCAmendment\n", err)
+			return nil, err
+		}
+		// current status & status log
+		err = recordStatus(ctx, loc, transition.To, fmt.Sprintf("LoC amendment #%d rejected by %s: %s", amendment.Seq, loc.AdviseThroughBank, reason))
+		if err != nil {
+			log.Println("error -> recordStatus -> RejectLoCAmendment\n", err)
+			return nil, err
+		}
+		// current status & status log
+		err = recordStatus(ctx, loc, transition.Then, followUpComment(loc, transition.Then))
+		if err != nil {
+			log.Println("error -> recordStatus -> RejectLoCAmendment\n", err)
+			return nil, err
+		}
+		// Put amendment on ledger
+		err = putAmendment(ctx, loc, amendment)
+		if err != nil {
+			log.Println("error -> putAmendment -> RejectLoCAmendment\n", err)
+			return nil, err
+		}
+		return &Event{Name: "LoCAmendmentRejected", Payload: publicAmendment(amendment)}, nil
+	})
+	if err != nil {
+		log.Println("error -> c.updateLoC -> RejectLoCAmendment\n", err)
+		return nil, err
+	}
+	return loc, nil
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// GetLoCAmendments returns every amendment of LoC with given {id}, oldest first. Values of private fields, eg. the amount,
+// are only returned to the applicant & advising banks, other parties get them blank.
+func (c *LocContract) GetLoCAmendments(ctx contractapi.TransactionContextInterface, id string) ([]*Amendment, error) {
+	// only parties to the LoC can read its amendments
+	loc, err := c.GetLoCById(ctx, id)
+	if err != nil {
+		log.Println("error -> c.GetLoCById -> GetLoCAmendments\n", err)
+		return nil, err
+	}
+	// amendments with private values are kept in the collection of the applicant & advising banks, like the terms they change
+	member, err := isPairMember(ctx, loc)
+	if err != nil {
+		log.Println("error -> isPairMember -> GetLoCAmendments\n", err)
+		return nil, err
+	}
+	// Get result iterator
+	var resultsIterator shim.StateQueryIteratorInterface
+	if member {
+		resultsIterator, err = ctx.GetStub().GetPrivateDataByPartialCompositeKey(privateCollection(loc), amendmentIndex, []string{id})
+	} else {
+		resultsIterator, err = ctx.GetStub().GetStateByPartialCompositeKey(amendmentIndex, []string{id})
+	}
+	if err != nil {
+		log.Println("error -> ctx.GetStub.GetPrivateDataByPartialCompositeKey -> GetLoCAmendments\n", err)
+		return nil, fmt.Errorf("failed to get amendments: %v", err)
+	}
+	defer resultsIterator.Close()
+	amendments := make([]*Amendment, 0)
+	// Iterate the results, unmarshal & append to amendments & return
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			log.Println("error -> resultsIterator.Next -> GetLoCAmendments\n", err)
+			return nil, fmt.Errorf("failed to read from result iterator: %v", err)
+		}
+		var amendment Amendment
+		err = json.Unmarshal(queryResult.Value, &amendment)
+		if err != nil {
+			log.Println("error -> json.Unmarshal -> GetLoCAmendments\n", err)
+			return nil, fmt.Errorf("failed to unmarshal query result: %v", err)
+		}
+		amendments = append(amendments, &amendment)
+	}
+	return amendments, nil
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// GetPendingLoCAmendments returns the amendments of LoC with given {id} awaiting acknowledgement
+func (c *LocContract) GetPendingLoCAmendments(ctx contractapi.TransactionContextInterface, id string) ([]*Amendment, error) {
+	amendments, err := c.GetLoCAmendments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]*Amendment, 0)
+	for _, amendment := range amendments {
+		if amendment.Status == AmendmentProposed {
+			pending = append(pending, amendment)
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,149 @@
+package chaincode
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"sample.com/lc/events"
+	"sample.com/lc/money"
+)
+
+// amendableLoC returns a valid LoC to amend
+func amendableLoC() *LoC {
+	return &LoC{
+		ID:                      "LC1",
+		DocumentaryCreditNumber: "LC1",
+		ApplicantBank:           "Org1",
+		Beneficiary:             "POSCO INDIA PROCESSING CENTER PVT",
+		CurrencyCode:            "INR",
+		Amount:                  money.Money{Amount: "1000.00", Currency: "INR"},
+		DateOfIssue:             "20220105",
+		DateOfExpiry:            "20220221",
+		LatestDateOfShipment:    "20220131",
+	}
+}
+
+func TestDiffAmendment(t *testing.T) {
+	tests := []struct {
+		name     string
+		proposed map[string]string
+		want     []FieldChange
+		err      string
+	}{
+		{
+			name:     "fields in order",
+			proposed: map[string]string{"date_of_expiry": "2022-03-01", "amount": " 1500.5 ", "tolerance_plus_percent": "5"},
+			want: []FieldChange{
+				{Field: "amount", OldValue: "1000.00", NewValue: "1500.50"},
+				{Field: "date_of_expiry", OldValue: "20220221", NewValue: "2022-03-01"},
+				{Field: "tolerance_plus_percent", OldValue: "0", NewValue: "5"},
+			},
+		},
+		{name: "nothing", proposed: map[string]string{}, err: "an amendment must change at least one field"},
+		{name: "same value", proposed: map[string]string{"amount": "1000"}, err: `amount is already "1000.00"`},
+		{name: "not amendable", proposed: map[string]string{"applicant_bank": "Org3"}, err: "applicant_bank can not be amended"},
+		{name: "not a number", proposed: map[string]string{"tolerance_minus_percent": "five"}, err: `tolerance_minus_percent "five" is not a whole number`},
+		{name: "too many decimals", proposed: map[string]string{"amount": "1000.001"}, err: `amount "1000.001" has more than 2 decimals`},
+		{name: "invalid LoC", proposed: map[string]string{"date_of_expiry": "20220125"}, err: "latest_date_of_shipment 20220131 must not be after date_of_expiry 20220125"},
+		{name: "all violations", proposed: map[string]string{"amount": "1000", "beneficiary": "X"}, err: `amount is already "1000.00"; beneficiary can not be amended`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := amendableLoC()
+			changes, err := diffAmendment(loc, tt.proposed)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("diffAmendment error = %v, want it to contain %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(changes, tt.want) {
+				t.Errorf("diffAmendment = %+v, %v, want %+v", changes, err, tt.want)
+			}
+			if !reflect.DeepEqual(loc, amendableLoC()) {
+				t.Errorf("diffAmendment changed the LoC")
+			}
+		})
+	}
+}
+
+func TestApplyAmendment(t *testing.T) {
+	loc := amendableLoC()
+	amendment := &Amendment{Changes: []FieldChange{
+		{Field: "amount", OldValue: "1000.00", NewValue: "1500.50"},
+		{Field: "place_of_expiry", OldValue: "", NewValue: "MUMBAI"},
+	}}
+	if err := applyAmendment(loc, amendment); err != nil {
+		t.Fatalf("applyAmendment: %v", err)
+	}
+	if loc.Amount.String() != "INR 1500.50" || loc.PlaceOfExpiry != "MUMBAI" {
+		t.Errorf("applyAmendment amended to %s, %q", loc.Amount, loc.PlaceOfExpiry)
+	}
+	// the LoC moved on since the amendment was proposed
+	if err := applyAmendment(loc, amendment); err == nil {
+		t.Errorf("applyAmendment applied an amendment proposed against other terms")
+	}
+}
+
+func TestGetLoCAmendments(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", map[string]interface{}{"negotiating_bank": "Org3"})
+	l.run("LC1", []step{
+		{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+		{org: "Org1", invoke: withArg((*LocContract).ProposeLoCAmendment, `{"place_of_expiry":"MUMBAI"}`), want: StatusAmended, event: "LoCAmendmentProposed"},
+		{org: "Org2", invoke: withArg((*LocContract).RejectLoCAmendment, "not agreed"), want: StatusAwaitingDocuments, event: "LoCAmendmentRejected"},
+		{org: "Org1", invoke: amendAmount, transient: amountAmendment("2000"), want: StatusAmended, event: "LoCAmendmentProposed"},
+	})
+	amendments, err := l.contract.GetLoCAmendments(l.orgs["Org2"], "LC1")
+	if err != nil || len(amendments) != 2 {
+		t.Fatalf("GetLoCAmendments = %v, %v", amendments, err)
+	}
+	first, second := amendments[0], amendments[1]
+	if first.Seq != 1 || first.Status != AmendmentRejected || first.Reason != "not agreed" || first.DecidedBy != "Org2" {
+		t.Errorf("amendment #1 = %+v", first)
+	}
+	if second.Seq != 2 || second.Status != AmendmentProposed || second.Changes[0] != (FieldChange{Field: "amount", OldValue: "1000.00", NewValue: "2000.00"}) {
+		t.Errorf("amendment #2 = %+v", second)
+	}
+	// amendments only take effect once acknowledged
+	if loc, _ := l.contract.GetLoCById(l.orgs["Org1"], "LC1"); loc.Amount.Amount != "1000.00" || loc.PlaceOfExpiry != "" {
+		t.Errorf("LoC amended to %s, %q before acknowledgement", loc.Amount, loc.PlaceOfExpiry)
+	}
+	pending, err := l.contract.GetPendingLoCAmendments(l.orgs["Org1"], "LC1")
+	if err != nil || len(pending) != 1 || pending[0].Seq != 2 {
+		t.Errorf("GetPendingLoCAmendments = %v, %v", pending, err)
+	}
+	// other parties get amendments without private values
+	public, err := l.contract.GetLoCAmendments(l.orgs["Org3"], "LC1")
+	if err != nil || len(public) != 2 || public[0].Changes[0] != first.Changes[0] || public[1].Changes[0] != (FieldChange{Field: "amount"}) {
+		t.Errorf("GetLoCAmendments as the negotiating bank = %+v, %v", public, err)
+	}
+	l.run("LC1", []step{{org: "Org2", invoke: acknowledgeAmendment, want: StatusAwaitingDocuments, event: "LoCAmendmentAcknowledged"}})
+	if loc, _ := l.contract.GetLoCById(l.orgs["Org2"], "LC1"); loc.Amount.Amount != "2000.00" || loc.AmendmentCount != 2 {
+		t.Errorf("acknowledged LoC amount %s, amendment count %d", loc.Amount, loc.AmendmentCount)
+	}
+	event, _ := l.stub.LastEvent()
+	if strings.Contains(string(event.Payload), "2000") {
+		t.Errorf("event %s carries the amended amount\n%s", event.Name, event.Payload)
+	}
+	// the acknowledgement, then the LoC settles awaiting documents
+	batch, err := events.DecodeBatch(event.Payload)
+	if err != nil || len(batch.Events) != 2 {
+		t.Fatalf("event %s = %s, %v", event.Name, event.Payload, err)
+	}
+	envelope, followUp := batch.Events[0], batch.Events[1]
+	if envelope.Type != "LoCAmendmentAcknowledged" || envelope.FromStatus != string(StatusAmended) || envelope.ToStatus != string(StatusAmendmentAcknowledged) {
+		t.Errorf("envelope %+v", envelope)
+	}
+	if followUp.Type != "DocumentsAwaited" || followUp.FromStatus != string(StatusAmendmentAcknowledged) || followUp.ToStatus != string(StatusAwaitingDocuments) || len(followUp.Changes) != 0 || string(followUp.Details) != string(envelope.Details) {
+		t.Errorf("follow-up envelope %+v", followUp)
+	}
+	var acknowledged Amendment
+	if ok, err := envelope.DecodeDetails(&acknowledged); !ok || err != nil || acknowledged.Seq != 2 || acknowledged.Status != AmendmentAccepted {
+		t.Errorf("details of %s = %s, %v", envelope.Type, envelope.Details, err)
+	}
+	if change := envelope.Change("amendment_count"); change != nil {
+		t.Errorf("envelope changes amendment_count, counted when the amendment was proposed: %s -> %s", change.From, change.To)
+	}
+}
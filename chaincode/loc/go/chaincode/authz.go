@@ -0,0 +1,175 @@
+package chaincode
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Role is the part a bank/org plays on an LoC, each action can only be taken by one role
+type Role string
+
+const (
+	RoleApplicantBank   Role = "APPLICANT_BANK"
+	RoleAdvisingBank    Role = "ADVISING_BANK"
+	RoleNegotiatingBank Role = "NEGOTIATING_BANK"
+)
+
+// AttributeRequirement is an X.509 attribute the submitting client certificate must carry, eg. loc.role=maker
+type AttributeRequirement struct {
+	Name  string
+	Value string
+}
+
+func (r AttributeRequirement) String() string {
+	return r.Name + "=" + r.Value
+}
+
+// partyOrg returns the org playing role on loc
+func partyOrg(loc *LoC, role Role) string {
+	switch role {
+	case RoleApplicantBank:
+		return loc.ApplicantBank
+	case RoleAdvisingBank:
+		return loc.AdviseThroughBank
+	case RoleNegotiatingBank:
+		return loc.NegotiatingBank
+	}
+	return ""
+}
+
+// permits reports whether the submitting client may take action on loc, without logging refusals
+func (c *LocContract) permits(ctx contractapi.TransactionContextInterface, loc *LoC, action Action) (bool, error) {
+	transition, ok := transitionsOf(loc)[action]
+	if !ok && isKnownAction(action) {
+		return false, &InstrumentError{ID: loc.ID, Action: action, InstrumentType: instrumentOf(loc)}
+	}
+	if !ok {
+		return false, fmt.Errorf("unknown action %s", action)
+	}
+	org, err := getOrgName(ctx)
+	if err != nil {
+		return false, err
+	}
+	if org == "" || org != partyOrg(loc, transition.Role) {
+		return false, nil
+	}
+	return c.hasRequiredAttributes(ctx, action)
+}
+
+// hasRequiredAttributes reports whether the submitting client carries every attribute configured for action
+func (c *LocContract) hasRequiredAttributes(ctx contractapi.TransactionContextInterface, action Action) (bool, error) {
+	for _, required := range c.RequiredAttributes[action] {
+		value, found, err := ctx.GetClientIdentity().GetAttributeValue(required.Name)
+		if err != nil {
+			return false, fmt.Errorf("failed to get client attribute %s: %v", required.Name, err)
+		}
+		if !found || value != required.Value {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// authorize returns an error unless the submitting client belongs to the org playing the role
+// required by action on loc and carries every attribute configured for action
+func (c *LocContract) authorize(ctx contractapi.TransactionContextInterface, loc *LoC, action Action) error {
+	ok, err := c.permits(ctx, loc, action)
+	if err != nil {
+		log.Println("error -> c.permits -> authorize\n", err)
+		return err
+	}
+	if !ok {
+		org, _ := getOrgName(ctx)
+		role := transitionsOf(loc)[action].Role
+		err = fmt.Errorf("client of org %s is not authorized to %s LoC with Id@%s: requires %s %s", org, action, loc.ID, role, partyOrg(loc, role))
+		if required := c.RequiredAttributes[action]; len(required) > 0 {
+			err = fmt.Errorf("%v with attributes %v", err, required)
+		}
+		log.Println("error -> authorize\n", err)
+		return err
+	}
+	return nil
+}
+
+// ParseAttributeRequirements parses per-action attribute requirements of the form
+// "IssueLoC:loc.role=maker;CloseLoC:loc.role=checker", used to configure LocContract.RequiredAttributes
+func ParseAttributeRequirements(spec string) (map[Action][]AttributeRequirement, error) {
+	requirements := make(map[Action][]AttributeRequirement)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		actionName, attribute, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid attribute requirement %q: expected Action:name=value", entry)
+		}
+		action := Action(strings.TrimSpace(actionName))
+		if !isKnownAction(action) {
+			return nil, fmt.Errorf("invalid attribute requirement %q: unknown action %s", entry, action)
+		}
+		name, value, ok := strings.Cut(attribute, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid attribute requirement %q: expected Action:name=value", entry)
+		}
+		requirements[action] = append(requirements[action], AttributeRequirement{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+	}
+	return requirements, nil
+}
+
+// isRegulator reports whether the submitting client belongs to one of the regulator MSPs exempt from read restrictions
+func (c *LocContract) isRegulator(ctx contractapi.TransactionContextInterface) (bool, error) {
+	if len(c.RegulatorMSPs) == 0 {
+		return false, nil
+	}
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return false, fmt.Errorf("failed to get verified MSPID: %v", err)
+	}
+	for _, mspID := range c.RegulatorMSPs {
+		if mspID == clientMSPID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// canRead reports whether the submitting client may read loc, only the banks party to it and regulators can
+func (c *LocContract) canRead(ctx contractapi.TransactionContextInterface, loc *LoC) (bool, error) {
+	regulator, err := c.isRegulator(ctx)
+	if err != nil || regulator {
+		return regulator, err
+	}
+	return isParty(ctx, loc)
+}
+
+// isParty reports whether the submitting client belongs to one of the banks party to loc
+func isParty(ctx contractapi.TransactionContextInterface, loc *LoC) (bool, error) {
+	org, err := getOrgName(ctx)
+	if err != nil {
+		return false, err
+	}
+	if org == "" {
+		return false, nil
+	}
+	for _, party := range []string{loc.ApplicantBank, loc.AdviseThroughBank, loc.NegotiatingBank, loc.ReimbursingBank} {
+		if party == org {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ParseRegulatorMSPs parses a comma separated list of regulator MSP IDs, used to configure LocContract.RegulatorMSPs
+func ParseRegulatorMSPs(spec string) []string {
+	mspIDs := make([]string, 0)
+	for _, mspID := range strings.Split(spec, ",") {
+		if mspID = strings.TrimSpace(mspID); mspID != "" {
+			mspIDs = append(mspIDs, mspID)
+		}
+	}
+	return mspIDs
+}
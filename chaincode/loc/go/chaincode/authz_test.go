@@ -0,0 +1,180 @@
+package chaincode
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/fabrictest"
+)
+
+func TestParseAttributeRequirements(t *testing.T) {
+	tests := []struct {
+		spec string
+		want map[Action][]AttributeRequirement
+		err  string
+	}{
+		{"", map[Action][]AttributeRequirement{}, ""},
+		{
+			" IssueLoC : loc.role = maker ; IssueLoC:loc.level=2;HonourClaim:loc.role=checker;",
+			map[Action][]AttributeRequirement{
+				ActionIssueLoC:    {{"loc.role", "maker"}, {"loc.level", "2"}},
+				ActionHonourClaim: {{"loc.role", "checker"}},
+			},
+			"",
+		},
+		{"IssueLoC", nil, `invalid attribute requirement "IssueLoC": expected Action:name=value`},
+		{"IssueLoC:loc.role", nil, "expected Action:name=value"},
+		{"IssueLoC:=maker", nil, "expected Action:name=value"},
+		{"DeleteLoC:loc.role=maker", nil, "unknown action DeleteLoC"},
+	}
+	for _, tt := range tests {
+		got, err := ParseAttributeRequirements(tt.spec)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseAttributeRequirements(%q) error = %v, want it to contain %q", tt.spec, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseAttributeRequirements(%q) = %v, %v, want %v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseRegulatorMSPs(t *testing.T) {
+	if got := ParseRegulatorMSPs(" RegMSP, ,AuditMSP,"); !reflect.DeepEqual(got, []string{"RegMSP", "AuditMSP"}) {
+		t.Errorf("ParseRegulatorMSPs = %v", got)
+	}
+	if got := ParseRegulatorMSPs(""); len(got) != 0 {
+		t.Errorf("ParseRegulatorMSPs(\"\") = %v", got)
+	}
+}
+
+func TestPermits(t *testing.T) {
+	c := &LocContract{RegulatorMSPs: []string{"RegMSP"}}
+	loc := &LoC{ID: "LC1", ApplicantBank: "Org1", AdviseThroughBank: "Org2", NegotiatingBank: "Org3", ReimbursingBank: "Org1", InstrumentType: InstrumentDocumentaryCredit}
+	// every action is taken by the org playing its role only, regulators read but never act
+	for action, transition := range locTransitions {
+		if transition.Role == "" {
+			continue
+		}
+		for _, org := range []string{"Org1", "Org2", "Org3", "Reg"} {
+			ctx := fabrictest.NewTransactionContext(fabrictest.NewStub(), fabrictest.NewClientIdentity(org+"MSP", "user1", nil))
+			ok, err := c.permits(ctx, loc, action)
+			if want := org == partyOrg(loc, transition.Role); err != nil || ok != want {
+				t.Errorf("permits(%s) as %s = %t, %v, want %t", action, org, ok, err, want)
+			}
+		}
+	}
+	ctx := fabrictest.NewTransactionContext(fabrictest.NewStub(), fabrictest.NewClientIdentity("Org3MSP", "user1", nil))
+	var instrumentErr *InstrumentError
+	if _, err := c.permits(ctx, loc, ActionSubmitClaim); !errors.As(err, &instrumentErr) {
+		t.Errorf("permits(SubmitClaim) on a documentary credit error = %v, want an InstrumentError", err)
+	}
+	if _, err := c.permits(ctx, loc, "DeleteLoC"); err == nil || !strings.Contains(err.Error(), "unknown action DeleteLoC") {
+		t.Errorf("permits(DeleteLoC) error = %v", err)
+	}
+	err := c.authorize(ctx, loc, ActionAcceptDocuments)
+	if want := "client of org Org3 is not authorized to AcceptDocuments LoC with Id@LC1: requires APPLICANT_BANK Org1"; err == nil || err.Error() != want {
+		t.Errorf("authorize error = %v, want %s", err, want)
+	}
+}
+
+func TestRequiredAttributes(t *testing.T) {
+	l := newLedger(t)
+	l.contract.RequiredAttributes = map[Action][]AttributeRequirement{ActionAcknowledgeLoCIssuance: {{"loc.role", "checker"}}}
+	l.issue("LC1", nil)
+	tests := []struct {
+		attributes map[string]string
+		err        string
+	}{
+		{nil, "requires ADVISING_BANK Org2 with attributes [loc.role=checker]"},
+		{map[string]string{"loc.role": "maker"}, "with attributes [loc.role=checker]"},
+		{map[string]string{"loc.role": "checker"}, ""},
+	}
+	for _, tt := range tests {
+		l.begin(time.Time{}, nil)
+		ctx := &TransactionContext{TransactionContext: *fabrictest.NewTransactionContext(l.stub, fabrictest.NewClientIdentity("Org2MSP", "user2", tt.attributes))}
+		actions, err := l.contract.GetAllowedActions(ctx, "LC1")
+		if err != nil || (len(actions) == 1) != (tt.err == "") {
+			t.Errorf("GetAllowedActions with %v = %v, %v", tt.attributes, actions, err)
+		}
+		_, err = l.contract.AcknowledgeLoCIssuance(ctx, "LC1")
+		if tt.err == "" && err != nil {
+			t.Errorf("AcknowledgeLoCIssuance with %v: %v", tt.attributes, err)
+		} else if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("AcknowledgeLoCIssuance with %v error = %v, want it to contain %q", tt.attributes, err, tt.err)
+		}
+	}
+}
+
+func TestCanRead(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", map[string]interface{}{"negotiating_bank": "Org3", "reimbursing_bank": "Org4"})
+	tests := []struct {
+		mspID string
+		want  bool
+	}{
+		{"Org1MSP", true},
+		{"Org2MSP", true},
+		{"Org3MSP", true},
+		{"Org4MSP", true},
+		{"Org5MSP", false},
+		{"RegMSP", true},
+		{"OrdererMSP", false},
+	}
+	for _, tt := range tests {
+		ctx := fabrictest.NewTransactionContext(l.stub, fabrictest.NewClientIdentity(tt.mspID, "user1", nil))
+		if _, err := l.contract.GetLoCById(ctx, "LC1"); (err == nil) != tt.want {
+			t.Errorf("GetLoCById as %s error = %v, want readable %t", tt.mspID, err, tt.want)
+		}
+	}
+}
+
+func TestReadsOfOtherOrgs(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", nil)
+	// reads of one LoC, non parties can not tell it apart from a missing LoC
+	reads := map[string]func(c *LocContract, ctx contractapi.TransactionContextInterface) error{
+		"GetLoCById": func(c *LocContract, ctx contractapi.TransactionContextInterface) error {
+			_, err := c.GetLoCById(ctx, "LC1")
+			return err
+		},
+		"GetLoCTimeline": func(c *LocContract, ctx contractapi.TransactionContextInterface) error {
+			_, err := c.GetLoCTimeline(ctx, "LC1")
+			return err
+		},
+		"GetAllowedActions": func(c *LocContract, ctx contractapi.TransactionContextInterface) error {
+			_, err := c.GetAllowedActions(ctx, "LC1")
+			return err
+		},
+		"GetLoCHistory": func(c *LocContract, ctx contractapi.TransactionContextInterface) error {
+			_, err := c.GetLoCHistory(ctx, "LC1")
+			return err
+		},
+		"GetLoCAmendments": func(c *LocContract, ctx contractapi.TransactionContextInterface) error {
+			_, err := c.GetLoCAmendments(ctx, "LC1")
+			return err
+		},
+		"GetLoCPresentations": func(c *LocContract, ctx contractapi.TransactionContextInterface) error {
+			_, err := c.GetLoCPresentations(ctx, "LC1")
+			return err
+		},
+		"GetLoCDrawings": func(c *LocContract, ctx contractapi.TransactionContextInterface) error {
+			_, err := c.GetLoCDrawings(ctx, "LC1")
+			return err
+		},
+	}
+	for name, read := range reads {
+		if err := read(l.contract, l.orgs["Org3"]); err == nil || !strings.Contains(err.Error(), "the LoC with Id@LC1 does not exist or access is forbidden") {
+			t.Errorf("%s as Org3 error = %v", name, err)
+		}
+		if err := read(l.contract, l.orgs["Reg"]); err != nil {
+			t.Errorf("%s as a regulator: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package chaincode
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// demandDocumentType is the type of the document a claim has to carry, the demand for payment of the beneficiary
+const demandDocumentType = "DEMAND"
+
+// ********************** CLAIM FLOW START **********************
+// standbys & guarantees, see claimTransitions in status.go
+// ----------------------------------------------------------------
+// vvv ISSUED_BY_APPLICANT_BANK
+// vvv ISSUANCE_ACKNOWLEDGED_BY_ADVISING_BANK
+// (amendments settle in AWAITING_CLAIM, see amendment.go)
+// ----------------------------------------------------------------
+// vvv CLAIM_SUBMITTED_BY_NEGOTIATING_BANK
+// vvv CLAIM_HONOURED_BY_APPLICANT_BANK
+// (or CLAIM_REJECTED_BY_APPLICANT_BANK & then AWAITING_CLAIM)
+// ----------------------------------------------------------------
+// vvv PAYMENT_DONE_FROM_APPLICANT_BANK_TO_NEGOTIATING_BANK
+// vvv PAYMENT_ACKNOWLEDGED_FROM_APPLICANT_BANK_TO_NEGOTIATING_BANK
+// (the next claim, if partial drawings are allowed)
+// ----------------------------------------------------------------
+// vvv CLOSED_BY_APPLICANT_BANK
+// ********************** CLAIM FLOW END **********************
+
+// checkDemand returns an error unless documents carry the demand of the beneficiary
+func checkDemand(documents []Document) error {
+	for _, document := range documents {
+		if document.Type == demandDocumentType {
+			return nil
+		}
+	}
+	return &ValidationError{Violations: []string{fmt.Sprintf("a claim must carry a document of type %s", demandDocumentType)}}
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// SubmitClaim presents a claim under standby or guarantee {id}, the demand of the beneficiary & its supporting documents,
+// eg. [{"url":"https://...","sha256":"9f86d0...","type":"DEMAND"},{"url":"https://...","sha256":"5e884...","type":"STATEMENT_OF_DEFAULT"}].
+// The sha256 of every document is required. The amount claimed is passed in transient field loc_presentation as for
+// SubmitDocuments, it is paid by ConfirmPayment.
+func (c *LocContract) SubmitClaim(ctx contractapi.TransactionContextInterface, id string, jsonDocuments string) (*LoC, error) {
+	documents, err := decodeDocuments(jsonDocuments)
+	if err != nil {
+		log.Println("error -> decodeDocuments -> SubmitClaim\n", err)
+		return nil, err
+	}
+	err = checkDemand(documents)
+	if err != nil {
+		log.Println("error -> checkDemand -> SubmitClaim\n", err)
+		return nil, err
+	}
+	return c.movePresentation(ctx, id, ActionSubmitClaim, func(loc *LoC, presentation *Presentation) (string, error) {
+		// a paid claim can only be followed by another one for partial drawings
+		if loc.DrawingCount > 0 && !partialDrawingsAllowed(loc) {
+			return "", fmt.Errorf("partial drawings are not allowed, LoC with Id@%s is already drawn", loc.ID)
+		}
+		presentation.Round = 1
+		presentation.Documents = documents
+		// anchor documents on the LoC, docs Urls array keeps the same order
+		loc.Documents = append(loc.Documents, documents...)
+		loc.DocsUrls = append(loc.DocsUrls, documentUrls(documents)...)
+		return fmt.Sprintf("Claim with %d document(s) submitted by %s to %s", len(documents), loc.NegotiatingBank, loc.ApplicantBank), nil
+	})
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// HonourClaim honours the current claim under standby or guarantee {id}, it is then paid by ConfirmPayment
+func (c *LocContract) HonourClaim(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+	return c.movePresentation(ctx, id, ActionHonourClaim, func(loc *LoC, presentation *Presentation) (string, error) {
+		return fmt.Sprintf("Claim honoured by %s", loc.ApplicantBank), nil
+	})
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// RejectClaim rejects the current claim under standby or guarantee {id} for given {reason}, eg. a non complying demand.
+// The LoC awaits a new claim.
+func (c *LocContract) RejectClaim(ctx contractapi.TransactionContextInterface, id string, reason string) (*LoC, error) {
+	reason, err := checkReason(ActionRejectClaim, reason)
+	if err != nil {
+		log.Println("error -> checkReason -> RejectClaim\n", err)
+		return nil, err
+	}
+	return c.movePresentation(ctx, id, ActionRejectClaim, func(loc *LoC, presentation *Presentation) (string, error) {
+		presentation.RefusalReason = reason
+		return fmt.Sprintf("Claim rejected by %s: %s", loc.ApplicantBank, reason), nil
+	})
+}
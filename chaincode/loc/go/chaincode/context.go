@@ -0,0 +1,75 @@
+package chaincode
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/events"
+)
+
+// TransactionContext is the transaction context of LocContract, it collects the events of the transaction.
+// Fabric emits one event per transaction, the events collected are emitted together as one LoCEvents batch
+// once the transaction succeeds, see events.Batch.
+type TransactionContext struct {
+	contractapi.TransactionContext
+	events []events.Envelope
+}
+
+// AddEvent collects event, it is emitted with the other events of the transaction
+func (ctx *TransactionContext) AddEvent(event events.Envelope) {
+	ctx.events = append(ctx.events, event)
+}
+
+// Events returns the events collected so far, in the order they were added
+func (ctx *TransactionContext) Events() []events.Envelope {
+	return ctx.events
+}
+
+// eventCollector is a transaction context that collects events, see TransactionContext
+type eventCollector interface {
+	AddEvent(event events.Envelope)
+}
+
+// GetTransactionContextHandler makes transactions of LocContract run with a TransactionContext, whatever
+// TransactionContextHandler is set
+func (c *LocContract) GetTransactionContextHandler() contractapi.SettableTransactionContextInterface {
+	return new(TransactionContext)
+}
+
+// GetAfterTransaction makes transactions of LocContract emit their events once they succeed, whatever
+// AfterTransaction is set
+func (c *LocContract) GetAfterTransaction() interface{} {
+	return flushEvents
+}
+
+// addEvents adds envelopes to the events of the transaction, an error for contexts other than TransactionContext,
+// eg. of other handlers, they do not collect events & Fabric only keeps the last event of a transaction
+func addEvents(ctx contractapi.TransactionContextInterface, envelopes ...events.Envelope) error {
+	if len(envelopes) == 0 {
+		return nil
+	}
+	collector, ok := ctx.(eventCollector)
+	if !ok {
+		return fmt.Errorf("transaction context %T collects no events, LocContract transactions need a TransactionContext", ctx)
+	}
+	for _, envelope := range envelopes {
+		collector.AddEvent(envelope)
+	}
+	return nil
+}
+
+// flushEvents emits the events collected during the transaction as one LoCEvents batch, nothing if there are none
+func flushEvents(ctx *TransactionContext) error {
+	if len(ctx.events) == 0 {
+		return nil
+	}
+	batch := events.Batch{SchemaVersion: events.SchemaVersion, TxID: ctx.GetStub().GetTxID(), Events: ctx.events}
+	err := setJSONEvent(ctx, events.BatchName, batch)
+	if err != nil {
+		log.Println("error -> setJSONEvent -> flushEvents\n", err)
+		return err
+	}
+	ctx.events = nil
+	return nil
+}
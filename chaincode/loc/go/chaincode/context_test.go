@@ -0,0 +1,74 @@
+package chaincode
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"sample.com/lc/events"
+	"sample.com/lc/fabrictest"
+)
+
+func TestFlushEvents(t *testing.T) {
+	stub := fabrictest.NewStub()
+	ctx := &TransactionContext{TransactionContext: *fabrictest.NewTransactionContext(stub, fabrictest.NewClientIdentity("Org1MSP", "user1", nil))}
+	if err := flushEvents(ctx); err != nil {
+		t.Fatalf("flushEvents: %v", err)
+	}
+	if event, ok := stub.LastEvent(); ok {
+		t.Errorf("flushEvents without events emitted %s", event.Name)
+	}
+	first, second := events.Envelope{Type: "LoCAmendmentAcknowledged"}, events.Envelope{Type: "DocumentsAwaited"}
+	if err := addEvents(ctx, first, second); err != nil {
+		t.Fatalf("addEvents: %v", err)
+	}
+	if event, ok := stub.LastEvent(); ok || !reflect.DeepEqual(ctx.Events(), []events.Envelope{first, second}) {
+		t.Errorf("addEvents emitted %s, collected %+v", event.Name, ctx.Events())
+	}
+	if err := flushEvents(ctx); err != nil {
+		t.Fatalf("flushEvents: %v", err)
+	}
+	event, _ := stub.LastEvent()
+	envelopes, err := events.Unpack(event.Name, event.Payload)
+	if err != nil || event.Name != "LoCEvents" || !reflect.DeepEqual(envelopes, []events.Envelope{first, second}) {
+		t.Errorf("event %s = %s, %v", event.Name, event.Payload, err)
+	}
+	if len(ctx.Events()) != 0 {
+		t.Errorf("events %+v outlived their flush", ctx.Events())
+	}
+	// contexts that do not collect events would lose all but the last of them
+	stub.StartTransaction(stub.TxTime.Add(time.Hour))
+	plain := fabrictest.NewTransactionContext(stub, fabrictest.NewClientIdentity("Org1MSP", "user1", nil))
+	if err := addEvents(plain, first); err == nil || !strings.Contains(err.Error(), "collects no events") {
+		t.Errorf("addEvents without collector error = %v", err)
+	}
+	if event, ok := stub.LastEvent(); ok && event.TxID == stub.TxID {
+		t.Errorf("addEvents without collector emitted %s", event.Name)
+	}
+	if err := addEvents(plain); err != nil {
+		t.Errorf("addEvents of no events without collector: %v", err)
+	}
+}
+
+func TestLoCRepositoryEvents(t *testing.T) {
+	l := newLedger(t)
+	loc := l.issue("LC1", nil)
+	l.begin(time.Time{}, privateDetails())
+	ctx := l.orgs["Org1"]
+	// a raw event would replace the LoCEvents batch of the transaction
+	err := l.contract.locs().Put(ctx, loc.ID, loc, &Event{Name: "LoCClosed"})
+	if err == nil || !strings.Contains(err.Error(), "must carry its envelopes") {
+		t.Errorf("Put with a raw event error = %v", err)
+	}
+	envelope := events.Envelope{Type: "LoCClosed", LoCID: loc.ID}
+	if err := l.contract.locs().Put(ctx, loc.ID, loc, &Event{Name: "LoCClosed", Payload: []events.Envelope{envelope}}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if event, ok := l.stub.LastEvent(); ok && event.TxID == l.stub.TxID {
+		t.Errorf("Put set %s on the stub", event.Name)
+	}
+	if got := l.commit("Org1"); !reflect.DeepEqual(got, []events.Envelope{envelope}) {
+		t.Errorf("Put emitted %+v", got)
+	}
+}
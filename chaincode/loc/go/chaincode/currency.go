@@ -0,0 +1,8 @@
+package chaincode
+
+import "sample.com/lc/money"
+
+// isISO4217Currency reports whether code is an active ISO 4217 currency code, see the table of package money
+func isISO4217Currency(code string) bool {
+	return money.IsCurrency(code)
+}
@@ -0,0 +1,438 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/money"
+)
+
+// drawingIndex is the composite key object type drawings are stored under, attributes are LoC id & sequence
+const drawingIndex = "loc~drawing~seq"
+
+// partial shipments of field 43P
+const (
+	PartialShipmentsAllowed     = "ALLOWED"
+	PartialShipmentsNotAllowed  = "NOT ALLOWED"
+	PartialShipmentsConditional = "CONDITIONAL"
+)
+
+// DrawingStatus is the payment status of a drawing
+type DrawingStatus string
+
+const (
+	DrawingPaid         DrawingStatus = "PAID"
+	DrawingAcknowledged DrawingStatus = "ACKNOWLEDGED"
+)
+
+// Drawing is one utilization of an LoC, the payment of an accepted presentation. The world state holds every field but
+// the amount, which is kept with the private details of the LoC, see private.go.
+type Drawing struct {
+	DocType          string        `json:"doc_type"` // always "Drawing"
+	LoCID            string        `json:"loc_id"`
+	Seq              int           `json:"seq"`              // 1 for the first drawing of an LoC
+	PresentationSeq  int           `json:"presentation_seq"` // presentation paid, see presentation.go
+	Amount           money.Money   `json:"amount"`           // empty for clients not sharing the private details of the LoC
+	CurrencyCode     string        `json:"currency_code"`
+	Status           DrawingStatus `json:"status"`
+	PaidBy           string        `json:"paid_by"`
+	PaidAt           string        `json:"paid_at"`
+	PaidTxID         string        `json:"paid_tx_id"`
+	AcknowledgedBy   string        `json:"acknowledged_by"`
+	AcknowledgedAt   string        `json:"acknowledged_at"`
+	AcknowledgedTxID string        `json:"acknowledged_tx_id"`
+}
+
+// drawingPrivateDetails is the private part of a drawing, put in the private data collection of its LoC
+type drawingPrivateDetails struct {
+	DocType string      `json:"doc_type"` // always "DrawingPrivateDetails"
+	LoCID   string      `json:"loc_id"`
+	Seq     int         `json:"seq"`
+	Amount  money.Money `json:"amount"`
+}
+
+// drawingRequest is what ConfirmPayment takes from transient field loc_drawing
+type drawingRequest struct {
+	Amount money.Money `json:"amount"` // a bare amount, eg. "5000000.00", is in the currency of the LoC
+}
+
+// LoCBalance is the utilization of an LoC, amounts are in the currency of the LoC
+type LoCBalance struct {
+	LoCID                  string      `json:"loc_id"`
+	CurrencyCode           string      `json:"currency_code"`
+	Amount                 money.Money `json:"amount"`
+	MaxDrawable            money.Money `json:"max_drawable"` // amount plus tolerance, 39A
+	MinDrawing             money.Money `json:"min_drawing"`  // least the single drawing must be if partial drawings are not allowed, else 0
+	Drawn                  money.Money `json:"drawn"`
+	Available              money.Money `json:"available"`   // still drawable, tolerance included
+	Outstanding            money.Money `json:"outstanding"` // left of amount, tolerance excluded
+	DrawingCount           int         `json:"drawing_count"`
+	PartialDrawingsAllowed bool        `json:"partial_drawings_allowed"`
+}
+
+// partialDrawingsAllowed tells if loc can be drawn in several shipments, as per UCP 600 Article 31(a) unless 43P says otherwise
+func partialDrawingsAllowed(loc *LoC) bool {
+	return loc.PartialShipments != PartialShipmentsNotAllowed
+}
+
+// balanceOf returns the balance of loc once drawings are paid
+func balanceOf(loc *LoC, drawings []*Drawing) (*LoCBalance, error) {
+	zero, err := money.New(0, loc.CurrencyCode)
+	if err != nil {
+		return nil, err
+	}
+	balance := &LoCBalance{
+		LoCID:                  loc.ID,
+		CurrencyCode:           loc.CurrencyCode,
+		Amount:                 loc.Amount,
+		MinDrawing:             zero,
+		Drawn:                  zero,
+		DrawingCount:           len(drawings),
+		PartialDrawingsAllowed: partialDrawingsAllowed(loc),
+	}
+	plus, err := loc.Amount.Percent(loc.TolerancePlusPercent)
+	if err != nil {
+		return nil, err
+	}
+	if balance.MaxDrawable, err = loc.Amount.Add(plus); err != nil {
+		return nil, err
+	}
+	if !balance.PartialDrawingsAllowed {
+		minus, err := loc.Amount.Percent(loc.ToleranceMinusPercent)
+		if err != nil {
+			return nil, err
+		}
+		if balance.MinDrawing, err = loc.Amount.Sub(minus); err != nil {
+			return nil, err
+		}
+	}
+	for _, drawing := range drawings {
+		if balance.Drawn, err = balance.Drawn.Add(drawing.Amount); err != nil {
+			return nil, fmt.Errorf("drawing #%d: %v", drawing.Seq, err)
+		}
+	}
+	if balance.Available, err = atLeastZero(balance.MaxDrawable.Sub(balance.Drawn)); err != nil {
+		return nil, err
+	}
+	if balance.Outstanding, err = atLeastZero(balance.Amount.Sub(balance.Drawn)); err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+// atLeastZero returns amount, zero if it is negative
+func atLeastZero(amount money.Money, err error) (money.Money, error) {
+	if err != nil {
+		return money.Money{}, err
+	}
+	if sign, _ := amount.Sign(); sign < 0 {
+		return money.New(0, amount.Currency)
+	}
+	return amount, nil
+}
+
+// checkDrawing returns an error unless amount can be drawn on balance
+func checkDrawing(balance *LoCBalance, amount money.Money) error {
+	if sign, err := amount.Sign(); err != nil || sign <= 0 {
+		return fmt.Errorf("drawing amount must be positive, got %s", amount)
+	}
+	if cmp, err := amount.Cmp(balance.Available); err != nil {
+		return fmt.Errorf("drawing of LoC with Id@%s: %v", balance.LoCID, err)
+	} else if cmp > 0 {
+		return fmt.Errorf("drawing of %s exceeds the available balance of %s of LoC with Id@%s", amount, balance.Available, balance.LoCID)
+	}
+	if !balance.PartialDrawingsAllowed {
+		if balance.DrawingCount > 0 {
+			return fmt.Errorf("partial drawings are not allowed, LoC with Id@%s is already drawn", balance.LoCID)
+		}
+		if cmp, _ := amount.Cmp(balance.MinDrawing); cmp < 0 {
+			return fmt.Errorf("partial drawings are not allowed, drawing of %s is short of the %s tolerated for LoC with Id@%s", amount, balance.MinDrawing, balance.LoCID)
+		}
+	}
+	return nil
+}
+
+// checkDrawn returns an error if loc, as amended, no longer covers what was drawn on it, tolerance of 39A included
+func checkDrawn(ctx contractapi.TransactionContextInterface, loc *LoC) error {
+	if loc.DrawingCount == 0 {
+		return nil
+	}
+	drawings, err := readDrawings(ctx, loc)
+	if err != nil {
+		return err
+	}
+	balance, err := balanceOf(loc, drawings)
+	if err != nil {
+		return err
+	}
+	if cmp, err := balance.MaxDrawable.Cmp(balance.Drawn); err != nil {
+		return fmt.Errorf("balance of LoC with Id@%s: %v", loc.ID, err)
+	} else if cmp < 0 {
+		return fmt.Errorf("amount %s plus tolerance of %d%% is below the %s already drawn on LoC with Id@%s", loc.Amount, loc.TolerancePlusPercent, balance.Drawn, loc.ID)
+	}
+	return nil
+}
+
+// drawingKey returns the composite key of drawing {seq} of LoC {id}, zero padded so keys sort by sequence
+func drawingKey(ctx contractapi.TransactionContextInterface, id string, seq int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(drawingIndex, []string{id, fmt.Sprintf("%06d", seq)})
+}
+
+// putDrawing puts drawing of loc on the world state without its amount & the amount in the private data collection of loc.
+// Only the banks sharing the private details write the amount, other parties put the drawing as read.
+func putDrawing(ctx contractapi.TransactionContextInterface, loc *LoC, drawing *Drawing) error {
+	key, err := drawingKey(ctx, drawing.LoCID, drawing.Seq)
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	member, err := isPairMember(ctx, loc)
+	if err != nil {
+		return err
+	}
+	if member {
+		detailsJSON, err := json.Marshal(drawingPrivateDetails{DocType: "DrawingPrivateDetails", LoCID: drawing.LoCID, Seq: drawing.Seq, Amount: drawing.Amount})
+		if err != nil {
+			return fmt.Errorf("failed to marshal into Json: %v", err)
+		}
+		err = ctx.GetStub().PutPrivateData(privateCollection(loc), key, detailsJSON)
+		if err != nil {
+			return fmt.Errorf("failed to put private data: %v", err)
+		}
+	}
+	envelope := *drawing
+	envelope.Amount = money.Money{}
+	_, err = putJSON(ctx, key, &envelope)
+	return err
+}
+
+// loadDrawingAmount fills in the amount of drawing of loc when the submitting client shares the private details of loc
+func loadDrawingAmount(ctx contractapi.TransactionContextInterface, loc *LoC, drawing *Drawing) error {
+	member, err := isPairMember(ctx, loc)
+	if err != nil || !member {
+		return err
+	}
+	key, err := drawingKey(ctx, drawing.LoCID, drawing.Seq)
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	detailsJSON, err := ctx.GetStub().GetPrivateData(privateCollection(loc), key)
+	if err != nil {
+		return fmt.Errorf("failed to read private data: %v", err)
+	}
+	if detailsJSON == nil {
+		return nil
+	}
+	var details drawingPrivateDetails
+	err = json.Unmarshal(detailsJSON, &details)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	// drawings are in the currency of their LoC
+	if drawing.Amount, err = details.Amount.WithCurrency(drawing.CurrencyCode); err != nil {
+		return fmt.Errorf("drawing #%d of LoC with Id@%s: %v", drawing.Seq, drawing.LoCID, err)
+	}
+	return nil
+}
+
+// readDrawing returns drawing {seq} of loc, with its amount if the submitting client shares it
+func readDrawing(ctx contractapi.TransactionContextInterface, loc *LoC, seq int) (*Drawing, error) {
+	key, err := drawingKey(ctx, loc.ID, seq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite key: %v", err)
+	}
+	drawingJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if drawingJSON == nil {
+		return nil, fmt.Errorf("drawing #%d of LoC with Id@%s does not exist", seq, loc.ID)
+	}
+	var drawing Drawing
+	err = json.Unmarshal(drawingJSON, &drawing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	err = loadDrawingAmount(ctx, loc, &drawing)
+	if err != nil {
+		return nil, err
+	}
+	return &drawing, nil
+}
+
+// readDrawings returns every drawing of loc, oldest first, with their amounts if the submitting client shares them
+func readDrawings(ctx contractapi.TransactionContextInterface, loc *LoC) ([]*Drawing, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(drawingIndex, []string{loc.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get drawings: %v", err)
+	}
+	defer resultsIterator.Close()
+	drawings := make([]*Drawing, 0)
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from result iterator: %v", err)
+		}
+		var drawing Drawing
+		err = json.Unmarshal(queryResult.Value, &drawing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal query result: %v", err)
+		}
+		err = loadDrawingAmount(ctx, loc, &drawing)
+		if err != nil {
+			return nil, err
+		}
+		drawings = append(drawings, &drawing)
+	}
+	return drawings, nil
+}
+
+// transientDrawing reads the drawing paid by ConfirmPayment from transient field loc_drawing, nil if it is not set
+func transientDrawing(ctx contractapi.TransactionContextInterface) (*drawingRequest, error) {
+	transMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transient: %v", err)
+	}
+	requestJSON, ok := transMap[drawingTransientKey]
+	if !ok {
+		return nil, nil
+	}
+	var request drawingRequest
+	err = decodeStrict(string(requestJSON), &request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s from Json: %v", drawingTransientKey, err)
+	}
+	return &request, nil
+}
+
+// drawPayment records the drawing paid by the submitting client for the current presentation of loc, the amount
+// from transient field loc_drawing, eg. {"amount":"5000000.00"}, or the outstanding amount if it is not set. The drawing has to fit the balance.
+func drawPayment(ctx contractapi.TransactionContextInterface, loc *LoC) (*Drawing, error) {
+	member, err := isPairMember(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	if !member || loc.Amount.IsEmpty() {
+		return nil, fmt.Errorf("amount of LoC with Id@%s is not known to the invoking client", loc.ID)
+	}
+	drawings, err := readDrawings(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := balanceOf(loc, drawings)
+	if err != nil {
+		return nil, err
+	}
+	request, err := transientDrawing(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		request = &drawingRequest{Amount: balance.Outstanding}
+	}
+	amount, err := request.Amount.WithCurrency(loc.CurrencyCode)
+	if err != nil {
+		return nil, fmt.Errorf("drawing of LoC with Id@%s: %v", loc.ID, err)
+	}
+	err = checkDrawing(balance, amount)
+	if err != nil {
+		return nil, err
+	}
+	stamp, err := stampActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loc.DrawingCount++
+	drawing := &Drawing{
+		DocType:         "Drawing",
+		LoCID:           loc.ID,
+		Seq:             loc.DrawingCount,
+		PresentationSeq: loc.PresentationCount,
+		Amount:          amount,
+		CurrencyCode:    loc.CurrencyCode,
+		Status:          DrawingPaid,
+		PaidBy:          loc.ApplicantBank,
+		PaidAt:          stamp.timestamp,
+		PaidTxID:        stamp.txID,
+	}
+	err = putDrawing(ctx, loc, drawing)
+	if err != nil {
+		return nil, err
+	}
+	return drawing, nil
+}
+
+// acknowledgeDrawing marks the latest drawing of loc as acknowledged by the submitting client.
+// LoCs paid before drawings were recorded have none, there is nothing to acknowledge then.
+func acknowledgeDrawing(ctx contractapi.TransactionContextInterface, loc *LoC) error {
+	if loc.DrawingCount == 0 {
+		return nil
+	}
+	drawing, err := readDrawing(ctx, loc, loc.DrawingCount)
+	if err != nil {
+		return err
+	}
+	org, err := getOrgName(ctx)
+	if err != nil {
+		return err
+	}
+	txTime, err := GetTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	drawing.Status = DrawingAcknowledged
+	drawing.AcknowledgedBy = org
+	drawing.AcknowledgedAt = txTime.Format(time.RFC3339)
+	drawing.AcknowledgedTxID = ctx.GetStub().GetTxID()
+	return putDrawing(ctx, loc, drawing)
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// GetLoCDrawings returns every drawing of LoC with given {id}, oldest first. Amounts are only shown to the applicant & advising banks.
+func (c *LocContract) GetLoCDrawings(ctx contractapi.TransactionContextInterface, id string) ([]*Drawing, error) {
+	// only parties to the LoC can read its drawings
+	loc, err := c.GetLoCById(ctx, id)
+	if err != nil {
+		log.Println("error -> c.GetLoCById -> GetLoCDrawings\n", err)
+		return nil, err
+	}
+	drawings, err := readDrawings(ctx, loc)
+	if err != nil {
+		log.Println("error -> readDrawings -> GetLoCDrawings\n", err)
+		return nil, err
+	}
+	return drawings, nil
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// GetLoCBalance returns the drawn & available amounts of LoC with given {id}, tolerance of 39A included.
+// Amounts are private, only the applicant & advising banks can read the balance.
+func (c *LocContract) GetLoCBalance(ctx contractapi.TransactionContextInterface, id string) (*LoCBalance, error) {
+	// Get LoC if exists
+	loc, err := c.GetLoCById(ctx, id)
+	if err != nil {
+		log.Println("error -> c.GetLoCById -> GetLoCBalance\n", err)
+		return nil, err
+	}
+	member, err := isPairMember(ctx, loc)
+	if err != nil {
+		log.Println("error -> isPairMember -> GetLoCBalance\n", err)
+		return nil, err
+	}
+	if !member {
+		return nil, fmt.Errorf("balance of LoC with Id@%s is private to %s & %s", id, loc.ApplicantBank, loc.AdviseThroughBank)
+	}
+	drawings, err := readDrawings(ctx, loc)
+	if err != nil {
+		log.Println("error -> readDrawings -> GetLoCBalance\n", err)
+		return nil, err
+	}
+	balance, err := balanceOf(loc, drawings)
+	if err != nil {
+		log.Println("error -> balanceOf -> GetLoCBalance\n", err)
+		return nil, err
+	}
+	return balance, nil
+}
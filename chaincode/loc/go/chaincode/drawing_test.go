@@ -0,0 +1,182 @@
+package chaincode
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"sample.com/lc/money"
+)
+
+func TestCheckDrawing(t *testing.T) {
+	inr := func(amount string) money.Money { return money.Money{Amount: amount, Currency: "INR"} }
+	tests := []struct {
+		name     string
+		partial  string
+		plus     int
+		minus    int
+		drawings []string
+		amount   money.Money
+		err      string
+	}{
+		{name: "whole amount", amount: inr("1000.00")},
+		{name: "within tolerance", plus: 10, drawings: []string{"600"}, amount: inr("500.00")},
+		{name: "over tolerance", plus: 10, drawings: []string{"600"}, amount: inr("500.01"), err: "drawing of INR 500.01 exceeds the available balance of INR 500.00"},
+		{name: "nothing left", drawings: []string{"1000"}, amount: inr("0.01"), err: "exceeds the available balance of INR 0.00"},
+		{name: "zero", amount: inr("0"), err: "drawing amount must be positive, got INR 0"},
+		{name: "other currency", amount: money.Money{Amount: "10.00", Currency: "USD"}, err: "currency INR of INR"},
+		{name: "single drawing", partial: PartialShipmentsNotAllowed, minus: 5, amount: inr("950.00")},
+		{name: "short single drawing", partial: PartialShipmentsNotAllowed, minus: 5, amount: inr("949.99"), err: "drawing of INR 949.99 is short of the INR 950.00 tolerated"},
+		{name: "second drawing", partial: PartialShipmentsNotAllowed, plus: 10, drawings: []string{"950"}, amount: inr("10.00"), err: "partial drawings are not allowed, LoC with Id@LC1 is already drawn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := &LoC{ID: "LC1", CurrencyCode: "INR", Amount: inr("1000.00"), PartialShipments: tt.partial, TolerancePlusPercent: tt.plus, ToleranceMinusPercent: tt.minus}
+			drawings := make([]*Drawing, 0)
+			for i, amount := range tt.drawings {
+				drawings = append(drawings, &Drawing{Seq: i + 1, Amount: inr(amount)})
+			}
+			balance, err := balanceOf(loc, drawings)
+			if err != nil {
+				t.Fatalf("balanceOf: %v", err)
+			}
+			err = checkDrawing(balance, tt.amount)
+			if tt.err == "" && err != nil {
+				t.Errorf("checkDrawing: %v", err)
+			} else if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("checkDrawing error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestBalanceOf(t *testing.T) {
+	loc := &LoC{ID: "LC1", CurrencyCode: "JPY", Amount: money.Money{Amount: "1000", Currency: "JPY"}, TolerancePlusPercent: 10, ToleranceMinusPercent: 10, PartialShipments: PartialShipmentsNotAllowed}
+	balance, err := balanceOf(loc, []*Drawing{{Seq: 1, Amount: money.Money{Amount: "1050", Currency: "JPY"}}})
+	if err != nil {
+		t.Fatalf("balanceOf: %v", err)
+	}
+	for name, got := range map[string]money.Money{
+		"max_drawable": balance.MaxDrawable, "min_drawing": balance.MinDrawing, "drawn": balance.Drawn,
+		"available": balance.Available, "outstanding": balance.Outstanding,
+	} {
+		want := map[string]string{"max_drawable": "1100", "min_drawing": "900", "drawn": "1050", "available": "50", "outstanding": "0"}[name]
+		if got.Amount != want || got.Currency != "JPY" {
+			t.Errorf("%s = %s, want JPY %s", name, got, want)
+		}
+	}
+	if balance.DrawingCount != 1 || balance.PartialDrawingsAllowed {
+		t.Errorf("balance = %+v", balance)
+	}
+}
+
+func TestGetLoCDrawings(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", map[string]interface{}{"negotiating_bank": "Org3"})
+	l.run("LC1", []step{
+		{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+		{org: "Org3", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+		{org: "Org1", invoke: acceptDocuments, want: StatusDocumentsAccepted, event: "DocumentsAccepted"},
+		{org: "Org1", invoke: confirmPayment, transient: drawing("400"), want: StatusPaymentDone, event: "PaymentConfirmed"},
+		{org: "Org3", invoke: acknowledgePayment, want: StatusPaymentAcknowledged, event: "PaymentAcknowledged"},
+		{org: "Org3", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+		{org: "Org1", invoke: acceptDocuments, want: StatusDocumentsAccepted, event: "DocumentsAccepted"},
+		// the outstanding amount, without transient loc_drawing
+		{org: "Org1", invoke: confirmPayment, want: StatusPaymentDone, event: "PaymentConfirmed"},
+	})
+	tests := []struct {
+		org     string
+		amounts []string
+	}{
+		{"Org1", []string{"400.00", "600.00"}},
+		{"Org3", []string{"", ""}}, // the negotiating bank does not share the private details
+	}
+	for _, tt := range tests {
+		drawings, err := l.contract.GetLoCDrawings(l.orgs[tt.org], "LC1")
+		if err != nil || len(drawings) != 2 {
+			t.Fatalf("GetLoCDrawings as %s = %v, %v", tt.org, drawings, err)
+		}
+		for i, drawing := range drawings {
+			if drawing.Seq != i+1 || drawing.PresentationSeq != i+1 || drawing.Amount.Amount != tt.amounts[i] {
+				t.Errorf("drawing #%d as %s = %+v", i+1, tt.org, drawing)
+			}
+		}
+		if drawings[0].Status != DrawingAcknowledged || drawings[1].Status != DrawingPaid {
+			t.Errorf("drawing statuses %s, %s", drawings[0].Status, drawings[1].Status)
+		}
+	}
+	balance, err := l.contract.GetLoCBalance(l.orgs["Org2"], "LC1")
+	if err != nil || balance.Drawn.Amount != "1000.00" || balance.Available.Amount != "0.00" || balance.DrawingCount != 2 {
+		t.Errorf("GetLoCBalance = %+v, %v", balance, err)
+	}
+	if _, err := l.contract.GetLoCBalance(l.orgs["Org3"], "LC1"); err == nil || !strings.Contains(err.Error(), "is private to Org1 & Org2") {
+		t.Errorf("GetLoCBalance as the negotiating bank error = %v", err)
+	}
+	for key, value := range l.stub.State() {
+		if strings.Contains(key, drawingIndex) && strings.Contains(string(value), "400") {
+			t.Errorf("world state carries the drawn amount\n%s", value)
+		}
+	}
+}
+
+func TestConfirmPaymentDrawingErrors(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", nil)
+	l.run("LC1", []step{
+		{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+		{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+		{org: "Org1", invoke: acceptDocuments, want: StatusDocumentsAccepted, event: "DocumentsAccepted"},
+	})
+	tests := []struct {
+		transient map[string]string
+		want      string
+	}{
+		{drawing("1200"), "drawing of INR 1200.00 exceeds the available balance of INR 1000.00 of LoC with Id@LC1"},
+		{drawing("0"), "drawing amount must be positive, got INR 0.00"},
+		{drawing("12.345"), `drawing of LoC with Id@LC1: "12.345" has more than 2 decimals`},
+		{map[string]string{drawingTransientKey: `{"amt":"100"}`}, `failed to unmarshal loc_drawing from Json`},
+	}
+	for _, tt := range tests {
+		l.begin(time.Time{}, tt.transient)
+		if _, err := l.contract.ConfirmPayment(l.orgs["Org1"], "LC1"); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ConfirmPayment(%s) error = %v, want it to contain %q", tt.transient[drawingTransientKey], err, tt.want)
+		}
+	}
+	// refused drawings leave nothing behind
+	loc, err := l.contract.GetLoCById(l.orgs["Org1"], "LC1")
+	if err != nil || loc.CurrentStatus != StatusDocumentsAccepted || loc.DrawingCount != 0 {
+		t.Errorf("GetLoCById = %+v, %v", loc, err)
+	}
+	if drawings, err := l.contract.GetLoCDrawings(l.orgs["Org1"], "LC1"); err != nil || len(drawings) != 0 {
+		t.Errorf("GetLoCDrawings = %v, %v", drawings, err)
+	}
+}
+
+func TestAmendDrawnLoC(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", map[string]interface{}{"negotiating_bank": "Org3", "tolerance_plus_percent": 10})
+	l.run("LC1", []step{
+		{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+		{org: "Org3", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+		{org: "Org1", invoke: acceptDocuments, want: StatusDocumentsAccepted, event: "DocumentsAccepted"},
+		{org: "Org1", invoke: confirmPayment, transient: drawing("400"), want: StatusPaymentDone, event: "PaymentConfirmed"},
+		{org: "Org3", invoke: acknowledgePayment, want: StatusPaymentAcknowledged, event: "PaymentAcknowledged"},
+		{org: "Org3", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+		{org: "Org1", invoke: withArg((*LocContract).RaiseDiscrepancies, `[{"code":"DOCUMENT_MISSING"}]`), want: StatusDiscrepanciesRaised, event: "DiscrepanciesRaised"},
+		{org: "Org1", invoke: withArg((*LocContract).RefuseDocuments, "no bill of lading"), want: StatusAwaitingDocuments, event: "DocumentsRefused"},
+	})
+	// amount plus tolerance must cover the INR 400.00 drawn
+	below := func(invoke invoke, transient map[string]string, want string) {
+		t.Helper()
+		l.begin(time.Time{}, transient)
+		if _, err := invoke(l.contract, l.orgs["Org1"], "LC1"); err == nil || err.Error() != want {
+			t.Errorf("amendment below the drawn amount error = %v, want %s", err, want)
+		}
+	}
+	below(amendAmount, amountAmendment("363"), "amount INR 363.00 plus tolerance of 10% is below the INR 400.00 already drawn on LoC with Id@LC1")
+	l.run("LC1", []step{
+		{org: "Org1", invoke: amendAmount, transient: amountAmendment("364"), want: StatusAmended, event: "LoCAmendmentProposed"},
+		{org: "Org2", invoke: acknowledgeAmendment, want: StatusAwaitingDocuments, event: "LoCAmendmentAcknowledged"},
+	})
+	below(withArg((*LocContract).ProposeLoCAmendment, `{"tolerance_plus_percent":"0"}`), nil, "amount INR 364.00 plus tolerance of 0% is below the INR 400.00 already drawn on LoC with Id@LC1")
+}
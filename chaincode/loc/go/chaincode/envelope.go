@@ -0,0 +1,105 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/events"
+)
+
+// ignoredFields are left out of the changes of event envelopes, from_status & to_status tell the status change
+var ignoredFields = []string{"current_status", "status_log"}
+
+// snapshot is an LoC as it was before a transaction changed it, to tell in events what changed.
+// The nil snapshot is the one of a new LoC.
+type snapshot struct {
+	status     LoCStatus
+	logLength  int    // of the status log, the status changes of the transaction follow
+	publicJSON []byte // Json of the public envelope, private details never make it to events
+}
+
+// takeSnapshot returns the snapshot of loc
+func takeSnapshot(loc *LoC) (*snapshot, error) {
+	publicJSON, err := json.Marshal(publicLoC(loc))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal into Json: %v", err)
+	}
+	return &snapshot{status: loc.CurrentStatus, logLength: len(loc.StatusLog), publicJSON: publicJSON}, nil
+}
+
+// envelopes returns the envelopes of event name, loc changed from s by the current transaction. details, if not nil,
+// is what the event is about besides the LoC, eg. the amendment, it must not carry private details. The event has
+// the changes & the first status change of the transaction, a follow-up event with the same details comes after it
+// for each other one, eg. DocumentsAwaited once an amendment is acknowledged, see followUpEvents.
+func (s *snapshot) envelopes(ctx contractapi.TransactionContextInterface, name string, loc *LoC, details interface{}) ([]events.Envelope, error) {
+	publicJSON, err := json.Marshal(publicLoC(loc))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal into Json: %v", err)
+	}
+	event := events.Envelope{
+		SchemaVersion: events.SchemaVersion,
+		Type:          name,
+		LoCID:         loc.ID,
+		ToStatus:      string(loc.CurrentStatus),
+	}
+	var beforeJSON []byte
+	statusChanges := loc.StatusLog
+	if s != nil {
+		event.FromStatus = string(s.status)
+		beforeJSON = s.publicJSON
+		statusChanges = loc.StatusLog[s.logLength:]
+	}
+	event.Changes, err = events.Diff(beforeJSON, publicJSON, ignoredFields...)
+	if err != nil {
+		return nil, err
+	}
+	if details != nil {
+		event.Details, err = json.Marshal(details)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal into Json: %v", err)
+		}
+	}
+	event.ActorOrg, err = getOrgName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stamp, err := stampActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	event.TxID, event.Timestamp = stamp.txID, stamp.timestamp
+	if len(statusChanges) == 0 {
+		return []events.Envelope{event}, nil
+	}
+	event.ToStatus = string(statusChanges[0].ToStatus)
+	envelopes := []events.Envelope{event}
+	for _, statusChange := range statusChanges[1:] {
+		followUp := event
+		followUp.Type = followUpEvents[statusChange.ToStatus]
+		followUp.FromStatus, followUp.ToStatus = string(statusChange.FromStatus), string(statusChange.ToStatus)
+		followUp.Changes = make([]events.Change, 0)
+		envelopes = append(envelopes, followUp)
+	}
+	return envelopes, nil
+}
+
+// updateLoC updates the LoC with given {id} like Repository.Update. The event mutate returns has the details of the
+// event as payload, the repository adds its envelopes to the events of the transaction, see locs.
+func (c *LocContract) updateLoC(ctx contractapi.TransactionContextInterface, id string, mutate func(loc *LoC) (*Event, error)) (*LoC, error) {
+	return c.locs().Update(ctx, id, func(loc *LoC) (*Event, error) {
+		before, err := takeSnapshot(loc)
+		if err != nil {
+			return nil, err
+		}
+		event, err := mutate(loc)
+		if err != nil || event == nil {
+			return nil, err
+		}
+		envelopes, err := before.envelopes(ctx, event.Name, loc, event.Payload)
+		if err != nil {
+			return nil, err
+		}
+		return &Event{Name: event.Name, Payload: envelopes}, nil
+	})
+}
@@ -0,0 +1,149 @@
+package chaincode
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/couchdb"
+)
+
+// ExpiredError is returned when an action other than closure is attempted on an LoC past its date of expiry
+type ExpiredError struct {
+	ID           string
+	Action       Action
+	DateOfExpiry string
+}
+
+func (e *ExpiredError) Error() string {
+	return fmt.Sprintf("LoC with Id@%s expired on %s: %s is not allowed, it can only be closed", e.ID, e.DateOfExpiry, e.Action)
+}
+
+// pastExpiry tells if the transaction date is after the date of expiry of loc. An LoC expires at the end of its
+// date of expiry in the configured timezone, see SetTimeZone. LoCs without a readable date of expiry never do.
+func pastExpiry(ctx contractapi.TransactionContextInterface, loc *LoC) (bool, error) {
+	if loc.DateOfExpiry == "" {
+		return false, nil
+	}
+	expiry, err := parseLoCDate(loc.DateOfExpiry)
+	if err != nil {
+		log.Printf("the LoC with Id@%s has no readable date of expiry: %v\n", loc.ID, err)
+		return false, nil
+	}
+	txTime, err := GetTxTime(ctx)
+	if err != nil {
+		return false, err
+	}
+	txDate := time.Date(txTime.Year(), txTime.Month(), txTime.Day(), 0, 0, 0, 0, time.UTC)
+	return txDate.After(expiry), nil
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// GetLoCsPastExpiry returns the active LCs the invoking org is a party to that ExpireLoCs can expire, their date of expiry is before
+// the transaction date. Meant to be evaluated daily by a scheduler of any party, which then submits ExpireLoCs with their ids.
+func (c *LocContract) GetLoCsPastExpiry(ctx contractapi.TransactionContextInterface) ([]*LoC, error) {
+	org, err := getOrgName(ctx)
+	if err != nil {
+		log.Println("error -> getOrgName -> GetLoCsPastExpiry\n", err)
+		return nil, err
+	}
+	today, err := formatTxTime(ctx, LoCDateLayouts[0])
+	if err != nil {
+		log.Println("error -> formatTxTime -> GetLoCsPastExpiry\n", err)
+		return nil, err
+	}
+	// Query string, regulators only expire the LCs they are a party to
+	queryString, err := couchdb.NewQuery(couchdb.Eq("doc_type", "LoC"), couchdb.Eq("is_active", true), couchdb.Lt("date_of_expiry", today)).
+		Where(couchdb.Or(couchdb.Eq("applicant_bank", org), couchdb.Eq("advise_through_bank", org), couchdb.Eq("negotiating_bank", org), couchdb.Eq("reimbursing_bank", org))).
+		UseIndex("_design/indexLoCActiveDoc", "indexLoCActive").
+		Build()
+	if err != nil {
+		log.Println("error -> Build -> GetLoCsPastExpiry\n", err)
+		return nil, err
+	}
+	log.Println("queryString", queryString)
+	locs, err := c.queryLoCs(ctx, queryString)
+	if err != nil {
+		log.Println("error -> c.queryLoCs -> GetLoCsPastExpiry\n", err)
+		return nil, err
+	}
+	due := make([]*LoC, 0)
+	for _, loc := range locs {
+		// stored dates are compared as strings, LoCs put before dates were normalized are checked again here
+		past, err := pastExpiry(ctx, loc)
+		if err != nil {
+			log.Println("error -> pastExpiry -> GetLoCsPastExpiry\n", err)
+			return nil, err
+		}
+		if past && transitionsOf(loc)[ActionExpireLoCs].allows(loc.CurrentStatus) {
+			due = append(due, loc)
+		}
+	}
+	return due, nil
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// ExpireLoCs marks the LCs with given {jsonIDs}, eg. ["LC1","LC2"] as returned by GetLoCsPastExpiry, as EXPIRED. Each LC is read again
+// from the world state, the invoking org must be a party to it & its date of expiry before the transaction date, or nothing is expired.
+// Expired LCs can only be closed, presentations in progress included. Emits an LoCExpired event for each LC expired, together in the
+// LoCEvents batch of the transaction. Rich queries are not checked again when transactions are validated, so the sweep takes ids.
+func (c *LocContract) ExpireLoCs(ctx contractapi.TransactionContextInterface, jsonIDs string) ([]*LoC, error) {
+	ok, err := c.hasRequiredAttributes(ctx, ActionExpireLoCs)
+	if err != nil {
+		log.Println("error -> c.hasRequiredAttributes -> ExpireLoCs\n", err)
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("client is not authorized to %s: requires attributes %v", ActionExpireLoCs, c.RequiredAttributes[ActionExpireLoCs])
+	}
+	// Un-Marshal jsonIDs to ids
+	var ids []string
+	err = decodeStrict(jsonIDs, &ids)
+	if err != nil {
+		log.Println("error -> decodeStrict -> ExpireLoCs\n", err)
+		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	expired := make([]*LoC, 0, len(ids))
+	for _, id := range ids {
+		// Get LoC if exists, update it & put it back
+		loc, err := c.updateLoC(ctx, id, func(loc *LoC) (*Event, error) {
+			// any party to the LoC can do it, regulators only for the LoCs they are a party to
+			party, err := isParty(ctx, loc)
+			if err != nil {
+				return nil, err
+			}
+			if !party {
+				org, _ := getOrgName(ctx)
+				return nil, fmt.Errorf("client of org %s is not authorized to %s LoC with Id@%s: requires a party to the LoC", org, ActionExpireLoCs, loc.ID)
+			}
+			// check the LoC can move to the next status
+			transition, err := checkTransition(ctx, loc, ActionExpireLoCs)
+			if err != nil {
+				return nil, err
+			}
+			past, err := pastExpiry(ctx, loc)
+			if err != nil {
+				log.Println("error -> pastExpiry -> ExpireLoCs\n", err)
+				return nil, err
+			}
+			if !past {
+				return nil, fmt.Errorf("LoC with Id@%s expires on %s, it can not be expired before", loc.ID, loc.DateOfExpiry)
+			}
+			// current status & status log
+			err = recordStatus(ctx, loc, transition.To, fmt.Sprintf("LoC expired on %s", loc.DateOfExpiry))
+			if err != nil {
+				log.Println("error -> recordStatus -> ExpireLoCs\n", err)
+				return nil, err
+			}
+			loc.IsActive = false
+			return &Event{Name: "LoCExpired"}, nil
+		})
+		if err != nil {
+			log.Println("error -> c.updateLoC -> ExpireLoCs\n", err)
+			return nil, err
+		}
+		expired = append(expired, loc)
+	}
+	return expired, nil
+}
@@ -0,0 +1,95 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpireLoCs(t *testing.T) {
+	l := newLedger(t)
+	// LC1 & LC3 expire on 20220221, LC2 later, Org2 is no party to LC3
+	l.issue("LC1", nil)
+	l.issue("LC2", map[string]interface{}{"date_of_expiry": "20220228"})
+	l.issue("LC3", map[string]interface{}{"advise_through_bank": "Org3", "negotiating_bank": "Org3"})
+	// the 22nd in Asia/Kolkata, still the 21st in UTC
+	sweep := time.Date(2022, time.February, 21, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		org  string
+		at   time.Time
+		want []string
+	}{
+		{"Org2", sweep.Add(-2 * time.Hour), []string{}}, // 23:30 on the 21st in Asia/Kolkata
+		{"Reg", sweep, []string{}},                      // regulators only expire the LoCs they are a party to
+		{"Org2", sweep, []string{"LC1"}},
+		{"Org2", sweep.Add(time.Hour), []string{}}, // already expired
+		{"Org1", sweep.Add(2 * time.Hour), []string{"LC3"}},
+	}
+	for _, tt := range tests {
+		l.begin(tt.at, nil)
+		due, err := l.contract.GetLoCsPastExpiry(l.orgs[tt.org])
+		if got := ids(due); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetLoCsPastExpiry as %s at %s = %v, %v, want %v", tt.org, tt.at, got, err, tt.want)
+			continue
+		}
+		jsonIDs, _ := json.Marshal(ids(due))
+		expired, err := l.contract.ExpireLoCs(l.orgs[tt.org], string(jsonIDs))
+		if got := ids(expired); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExpireLoCs(%s) as %s at %s = %v, %v", jsonIDs, tt.org, tt.at, got, err)
+			continue
+		}
+		envelopes := l.commit(tt.org)
+		got := make([]string, 0, len(envelopes))
+		for _, envelope := range envelopes {
+			got = append(got, envelope.LoCID)
+			if envelope.Type != "LoCExpired" || envelope.ToStatus != string(StatusExpired) || envelope.ActorOrg != tt.org || envelope.Change("is_active") == nil {
+				t.Errorf("envelope of %s = %+v", envelope.LoCID, envelope)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExpireLoCs as %s at %s emitted events of %v, want %v", tt.org, tt.at, got, tt.want)
+		}
+		if event, _ := l.stub.LastEvent(); strings.Contains(string(event.Payload), applicant) {
+			t.Errorf("event %s carries private details\n%s", event.Name, event.Payload)
+		}
+	}
+	// every LoC is read again, the sweep fails as a whole
+	errs := []struct {
+		org     string
+		jsonIDs string
+		want    string
+	}{
+		{"Org1", `["LC2"]`, "LoC with Id@LC2 expires on 20220228, it can not be expired before"},
+		{"Org2", `["LC3"]`, "LC3 does not exist"},
+		{"Reg", `["LC1"]`, "is not authorized to ExpireLoCs LoC with Id@LC1: requires a party to the LoC"},
+		{"Org1", `["LC1"]`, `ExpireLoCs is not allowed from status "EXPIRED"`},
+		{"Org1", `["LC9"]`, "LC9 does not exist"},
+		{"Org1", `"LC2"`, "failed to unmarshal from Json"},
+	}
+	for _, tt := range errs {
+		l.begin(sweep.Add(3*time.Hour), nil)
+		before := l.stub.State()["LC2"]
+		if _, err := l.contract.ExpireLoCs(l.orgs[tt.org], tt.jsonIDs); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ExpireLoCs(%s) as %s error = %v, want it to contain %q", tt.jsonIDs, tt.org, err, tt.want)
+		}
+		if string(l.stub.State()["LC2"]) != string(before) {
+			t.Errorf("ExpireLoCs(%s) as %s changed LC2", tt.jsonIDs, tt.org)
+		}
+	}
+	loc, err := l.contract.GetLoCById(l.orgs["Org1"], "LC1")
+	if err != nil || loc.CurrentStatus != StatusExpired || loc.IsActive || loc.Amount.Amount != "1000.00" {
+		t.Errorf("expired LoC = %+v, %v", loc, err)
+	}
+}
+
+func TestExpireLoCsRequiredAttributes(t *testing.T) {
+	l := newLedger(t)
+	l.contract.RequiredAttributes = map[Action][]AttributeRequirement{ActionExpireLoCs: {{Name: "loc.role", Value: "scheduler"}}}
+	l.issue("LC1", nil)
+	l.begin(time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC), nil)
+	if _, err := l.contract.ExpireLoCs(l.orgs["Org1"], `["LC1"]`); err == nil {
+		t.Errorf("ExpireLoCs without attribute loc.role=scheduler")
+	}
+}
@@ -0,0 +1,90 @@
+package chaincode
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// LoCVersion is one write of an LoC on the world state, as kept by the history database of the peer
+type LoCVersion struct {
+	TxID      string `json:"tx_id"`
+	Timestamp string `json:"timestamp"` // transaction timestamp, RFC 3339
+	IsDelete  bool   `json:"is_delete"`
+	LoC       *LoC   `json:"loc,omitempty" metadata:",optional"` // nil if the LoC was deleted
+}
+
+// readLoCHistory returns every version of the LoC with given {id}, oldest first, without checking who reads it.
+// Versions are the public envelope as put on the world state, private details have no history, see private.go
+func readLoCHistory(ctx contractapi.TransactionContextInterface, id string) ([]LoCVersion, error) {
+	history, err := (&Repository[LoC]{}).History(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]LoCVersion, 0, len(history))
+	for _, version := range history {
+		locVersion := LoCVersion{TxID: version.TxID, IsDelete: version.IsDelete, LoC: version.Value}
+		if !version.Timestamp.IsZero() {
+			locVersion.Timestamp = version.Timestamp.In(timeLocation).Format(time.RFC3339Nano)
+		}
+		if version.Value != nil {
+			normalizeLoCAmount(version.Value)
+		}
+		versions = append(versions, locVersion)
+	}
+	return versions, nil
+}
+
+// versionTime returns the timestamp of version, zero if it has none
+func versionTime(version LoCVersion) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, version.Timestamp)
+	return t
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// GetLoCHistory returns every version of the LoC with given {id}, oldest first, with the transaction that wrote it.
+// Versions hold the LoC as put on the world state, private details are not in the history. Needs the peer history database.
+func (c *LocContract) GetLoCHistory(ctx contractapi.TransactionContextInterface, id string) ([]LoCVersion, error) {
+	// only parties to the LoC & regulators can read its history
+	_, err := c.GetLoCById(ctx, id)
+	if err != nil {
+		log.Println("error -> c.GetLoCById -> GetLoCHistory\n", err)
+		return nil, err
+	}
+	versions, err := readLoCHistory(ctx, id)
+	if err != nil {
+		log.Println("error -> readLoCHistory -> GetLoCHistory\n", err)
+		return nil, err
+	}
+	return versions, nil
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// GetLoCAsOf returns the LoC with given {id} as it was at {timestamp}, RFC 3339 eg. "2022-04-11T11:46:00+05:30",
+// the version of the last transaction committed at or before it. See GetLoCHistory for what versions hold.
+func (c *LocContract) GetLoCAsOf(ctx contractapi.TransactionContextInterface, id string, timestamp string) (*LoC, error) {
+	asOf, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		log.Println("error -> time.Parse -> GetLoCAsOf\n", err)
+		return nil, fmt.Errorf("timestamp %q is not RFC 3339, eg. 2022-04-11T11:46:00+05:30", timestamp)
+	}
+	versions, err := c.GetLoCHistory(ctx, id)
+	if err != nil {
+		log.Println("error -> c.GetLoCHistory -> GetLoCAsOf\n", err)
+		return nil, err
+	}
+	// latest version not after asOf
+	var found *LoCVersion
+	for i := range versions {
+		if versionTime(versions[i]).After(asOf) {
+			break
+		}
+		found = &versions[i]
+	}
+	if found == nil || found.IsDelete {
+		return nil, fmt.Errorf("the LoC with Id@%s did not exist at %s", id, timestamp)
+	}
+	return found.LoC, nil
+}
@@ -0,0 +1,106 @@
+package chaincode
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetLoCHistory(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", nil) // 2022-01-05 11:00 UTC
+	l.run("LC1", []step{
+		{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+		{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+	})
+	versions, err := l.contract.GetLoCHistory(l.orgs["Reg"], "LC1")
+	if err != nil || len(versions) != 3 {
+		t.Fatalf("GetLoCHistory = %v, %v", versions, err)
+	}
+	for i, want := range []LoCStatus{StatusIssued, StatusIssuanceAcknowledged, StatusDocumentsSubmitted} {
+		if versions[i].LoC.CurrentStatus != want || versions[i].IsDelete {
+			t.Errorf("version %d = %s, want %s", i, versions[i].LoC.CurrentStatus, want)
+		}
+		if versions[i].LoC.Applicant != "" {
+			t.Errorf("version %d carries private details", i)
+		}
+	}
+	if versions[0].Timestamp != "2022-01-05T16:30:00+05:30" || versions[0].TxID == versions[1].TxID {
+		t.Errorf("version 0 of tx %s at %s", versions[0].TxID, versions[0].Timestamp)
+	}
+	if _, err := l.contract.GetLoCHistory(l.orgs["Org3"], "LC1"); err == nil {
+		t.Errorf("GetLoCHistory as Org3 reads an LoC Org3 is not a party to")
+	}
+}
+
+func TestGetLoCAsOf(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", nil) // 2022-01-05 11:00 UTC
+	l.run("LC1", []step{{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"}})
+	tests := []struct {
+		timestamp string
+		want      LoCStatus
+		err       string
+	}{
+		{timestamp: "2022-01-05T11:00:00Z", want: StatusIssued},
+		{timestamp: "2022-01-05T17:00:00+05:30", want: StatusIssued},
+		{timestamp: "2022-01-05T12:00:00Z", want: StatusIssuanceAcknowledged},
+		{timestamp: "2023-01-01T00:00:00Z", want: StatusIssuanceAcknowledged},
+		{timestamp: "2022-01-05T10:59:59Z", err: "did not exist"},
+		{timestamp: "20220105", err: `timestamp "20220105" is not RFC 3339`},
+	}
+	for _, tt := range tests {
+		loc, err := l.contract.GetLoCAsOf(l.orgs["Org2"], "LC1", tt.timestamp)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("GetLoCAsOf(%s) error = %v, want it to contain %q", tt.timestamp, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || loc.CurrentStatus != tt.want {
+			t.Errorf("GetLoCAsOf(%s) = %v, %v, want %s", tt.timestamp, loc, err, tt.want)
+		}
+	}
+}
+
+func TestGetLoCAsOfDeleted(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", nil) // 2022-01-05 11:00 UTC
+	// LC1 deleted from the world state at 12:00 UTC & issued again at 13:00 UTC
+	l.begin(time.Time{}, nil)
+	if err := l.stub.DelState("LC1"); err != nil {
+		t.Fatalf("DelState: %v", err)
+	}
+	l.issue("LC1", nil)
+	versions, err := l.contract.GetLoCHistory(l.orgs["Org1"], "LC1")
+	if err != nil || len(versions) != 3 {
+		t.Fatalf("GetLoCHistory = %v, %v", versions, err)
+	}
+	if !versions[1].IsDelete || versions[1].LoC != nil || versions[1].Timestamp != "2022-01-05T17:30:00+05:30" {
+		t.Errorf("version 1 = %+v, want the deletion", versions[1])
+	}
+	tests := []struct {
+		timestamp string
+		err       string
+	}{
+		{timestamp: "2022-01-05T11:30:00Z"},
+		{timestamp: "2022-01-05T12:30:00Z", err: "the LoC with Id@LC1 did not exist at 2022-01-05T12:30:00Z"},
+		{timestamp: "2022-01-05T13:30:00Z"},
+	}
+	for _, tt := range tests {
+		loc, err := l.contract.GetLoCAsOf(l.orgs["Org1"], "LC1", tt.timestamp)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("GetLoCAsOf(%s) error = %v, want %q", tt.timestamp, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || loc.CurrentStatus != StatusIssued {
+			t.Errorf("GetLoCAsOf(%s) = %v, %v, want %s", tt.timestamp, loc, err, StatusIssued)
+		}
+	}
+	// only parties read past versions
+	if _, err := l.contract.GetLoCAsOf(l.orgs["Org3"], "LC1", "2022-01-05T11:30:00Z"); err == nil || !strings.Contains(err.Error(), "does not exist or access is forbidden") {
+		t.Errorf("GetLoCAsOf as Org3 error = %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package chaincode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InstrumentType is the kind of undertaking an LoC is, it decides the state machine & the terms of the LoC
+type InstrumentType string
+
+const (
+	// InstrumentDocumentaryCredit is a commercial documentary credit, paid against documents presented, see presentation.go
+	InstrumentDocumentaryCredit InstrumentType = "DOCUMENTARY_CREDIT"
+	// InstrumentStandby is a standby LC, paid against a claim of the beneficiary, see claim.go
+	InstrumentStandby InstrumentType = "STANDBY"
+	// InstrumentGuarantee is a demand guarantee, paid against a claim of the beneficiary, see claim.go
+	InstrumentGuarantee InstrumentType = "GUARANTEE"
+)
+
+// instrument is how an instrument type is run: its state machine & the optional terms it carries
+type instrument struct {
+	transitions map[Action]Transition
+	terms       map[string]bool // json names of the terms of instrumentTerms the instrument carries
+}
+
+// instruments are the instrument types an LoC can be issued as
+var instruments = map[InstrumentType]instrument{
+	InstrumentDocumentaryCredit: {
+		transitions: locTransitions,
+		terms: map[string]bool{
+			"available_with_by": true, "drafts_at": true, "loading_from": true, "transportation_to": true,
+			"latest_date_of_shipment": true, "shipment_period": true, "partial_shipments": true,
+		},
+	},
+	// a standby is available by payment & may call for drafts, nothing is shipped under it
+	InstrumentStandby: {
+		transitions: claimTransitions,
+		terms:       map[string]bool{"available_with_by": true, "drafts_at": true},
+	},
+	// a guarantee is paid by the guarantor on demand
+	InstrumentGuarantee: {
+		transitions: claimTransitions,
+		terms:       map[string]bool{},
+	},
+}
+
+// instrumentTerms returns the terms of loc only some instruments carry, by json name
+func instrumentTerms(loc *LoC) []struct{ name, value string } {
+	return []struct{ name, value string }{
+		{"available_with_by", loc.AvailableWithBy},
+		{"drafts_at", loc.DraftsAt},
+		{"loading_from", loc.LoadingFrom},
+		{"transportation_to", loc.TransportationTo},
+		{"latest_date_of_shipment", loc.LatestDateOfShipment},
+		{"shipment_period", loc.ShipmentPeriod},
+		{"partial_shipments", loc.PartialShipments},
+	}
+}
+
+// instrumentOf returns the instrument type of loc, LoCs issued before instrument types were recorded are documentary credits
+func instrumentOf(loc *LoC) InstrumentType {
+	if loc.InstrumentType == "" {
+		return InstrumentDocumentaryCredit
+	}
+	return loc.InstrumentType
+}
+
+// normalizeInstrumentType gives an LoC issued without instrument type the one its form names, a standby for
+// eg. "IRREVOCABLE STANDBY" as in field 40A of the MT700, a documentary credit otherwise
+func normalizeInstrumentType(loc *LoC) {
+	if loc.InstrumentType != "" {
+		return
+	}
+	loc.InstrumentType = InstrumentDocumentaryCredit
+	if strings.Contains(strings.ToUpper(loc.FormOfDocumentaryCredit), "STANDBY") {
+		loc.InstrumentType = InstrumentStandby
+	}
+}
+
+// transitionsOf returns the state machine of loc, the one of documentary credits for an unknown instrument type
+func transitionsOf(loc *LoC) map[Action]Transition {
+	if instrument, ok := instruments[instrumentOf(loc)]; ok {
+		return instrument.transitions
+	}
+	return locTransitions
+}
+
+// isKnownAction tells if action is in the state machine of any instrument type
+func isKnownAction(action Action) bool {
+	for _, instrument := range instruments {
+		if _, ok := instrument.transitions[action]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateInstrument checks the instrument type of loc & that loc only carries the terms of its instrument
+func validateInstrument(loc *LoC, verr *ValidationError) {
+	instrumentType := instrumentOf(loc)
+	instrument, ok := instruments[instrumentType]
+	if !ok {
+		verr.add("instrument_type must be one of %s, %s, %s, got %q", InstrumentDocumentaryCredit, InstrumentStandby, InstrumentGuarantee, loc.InstrumentType)
+		return
+	}
+	for _, term := range instrumentTerms(loc) {
+		if term.value != "" && !instrument.terms[term.name] {
+			verr.add("%s does not apply to a %s", term.name, instrumentType)
+		}
+	}
+}
+
+// InstrumentError is returned when an action is attempted on an LoC whose instrument type has no such action,
+// eg. SubmitDocuments on a standby
+type InstrumentError struct {
+	ID             string
+	Action         Action
+	InstrumentType InstrumentType
+}
+
+func (e *InstrumentError) Error() string {
+	return fmt.Sprintf("%s does not apply to LoC with Id@%s, it is a %s", e.Action, e.ID, e.InstrumentType)
+}
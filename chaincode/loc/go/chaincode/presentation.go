@@ -0,0 +1,678 @@
+package chaincode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/money"
+)
+
+// presentationIndex is the composite key object type presentations are stored under, attributes are LoC id & sequence
+const presentationIndex = "loc~presentation~seq"
+
+// PresentationStatus is the lifecycle state of one presentation of documents under an LoC
+type PresentationStatus string
+
+const (
+	PresentationPresented       PresentationStatus = "PRESENTED"
+	PresentationAccepted        PresentationStatus = "ACCEPTED"
+	PresentationDiscrepant      PresentationStatus = "DISCREPANT"
+	PresentationWaiverRequested PresentationStatus = "WAIVER_REQUESTED"
+	PresentationWaived          PresentationStatus = "WAIVED"
+	PresentationRefused         PresentationStatus = "REFUSED"
+)
+
+// PresentationKind tells what was presented
+type PresentationKind string
+
+const (
+	PresentationOfDocuments PresentationKind = "DOCUMENTS" // documents under a documentary credit
+	PresentationOfClaim     PresentationKind = "CLAIM"     // a claim under a standby or guarantee, see claim.go
+)
+
+// discrepancyCodes are the reasons documents can be found discrepant for
+var discrepancyCodes = map[string]string{
+	"LATE_PRESENTATION":       "documents presented later than the period for presentation",
+	"CREDIT_EXPIRED":          "documents presented after expiry of the credit",
+	"LATE_SHIPMENT":           "shipment effected after the latest date of shipment",
+	"CREDIT_OVERDRAWN":        "drawing exceeds the credit amount",
+	"DOCUMENT_MISSING":        "a required document was not presented",
+	"DOCUMENTS_INCONSISTENT":  "data in documents conflict with each other or the credit",
+	"GOODS_DESCRIPTION":       "description of goods in the invoice does not correspond with the credit",
+	"INSURANCE_INSUFFICIENT":  "insurance cover is short or in the wrong currency",
+	"TRANSPORT_DOCUMENT":      "transport document is claused, not signed or not as required",
+	"DRAFT_IRREGULAR":         "bill of exchange is irregular",
+	"BENEFICIARY_ENDORSEMENT": "documents not signed or endorsed by the beneficiary as required",
+	"OTHER":                   "other discrepancy, see description",
+}
+
+// Document is one document of a presentation
+type Document struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"` // hex encoded SHA-256 digest of the document
+	Type   string `json:"type"`   // eg. BILL_OF_EXCHANGE, COMMERCIAL_INVOICE, BILL_OF_LADING
+}
+
+// Discrepancy is a reason the applicant bank found a presentation not complying with the credit
+type Discrepancy struct {
+	Code        string `json:"code"` // one of discrepancyCodes
+	Description string `json:"description"`
+}
+
+// PresentationStatusEvent records one status change of a presentation
+type PresentationStatusEvent struct {
+	FromStatus PresentationStatus `json:"from_status"`
+	ToStatus   PresentationStatus `json:"to_status"`
+	ActorMSP   string             `json:"actor_msp"`
+	ClientID   string             `json:"client_id"`
+	TxID       string             `json:"tx_id"`
+	Timestamp  string             `json:"timestamp"` // transaction timestamp, RFC 3339
+	Comment    string             `json:"comment,omitempty" metadata:",optional"`
+}
+
+// Presentation is one set of documents presented under an LoC, a partially shipped LoC has one per shipment.
+// Discrepant documents can be re-presented, so a presentation may go through several rounds.
+// A claim under a standby or guarantee is a presentation too, of the demand & its supporting documents.
+type Presentation struct {
+	DocType       string                    `json:"doc_type"` // always "Presentation"
+	LoCID         string                    `json:"loc_id"`
+	Seq           int                       `json:"seq"`   // 1 for the first presentation of an LoC
+	Round         int                       `json:"round"` // 1 when first presented, +1 for every re-presentation
+	Kind          PresentationKind          `json:"kind"`
+	Amount        money.Money               `json:"amount"` // claimed, empty until recorded & for clients not sharing the private details of the LoC
+	PresentedBy   string                    `json:"presented_by"`
+	Documents     []Document                `json:"documents"`
+	Status        PresentationStatus        `json:"status"`
+	Discrepancies []Discrepancy             `json:"discrepancies"`
+	RefusalReason string                    `json:"refusal_reason"`
+	History       []PresentationStatusEvent `json:"history"`
+}
+
+// presentationPrivateDetails is the private part of a presentation, put in the private data collection of its LoC
+type presentationPrivateDetails struct {
+	DocType string      `json:"doc_type"` // always "PresentationPrivateDetails"
+	LoCID   string      `json:"loc_id"`
+	Seq     int         `json:"seq"`
+	Amount  money.Money `json:"amount"`
+}
+
+// presentationRequest is what presentation transactions take from transient field loc_presentation
+type presentationRequest struct {
+	Amount money.Money `json:"amount"` // a bare amount, eg. "5000000.00", is in the currency of the LoC
+}
+
+// presentationStep is how an action moves the current presentation of an LoC & the event it emits.
+// A step without From starts a new presentation of Kind.
+type presentationStep struct {
+	From  []PresentationStatus
+	To    PresentationStatus
+	Event string
+	Kind  PresentationKind
+}
+
+// presentationSteps is the presentation state machine, it runs alongside locTransitions
+var presentationSteps = map[Action]presentationStep{
+	ActionSubmitDocuments:    {To: PresentationPresented, Event: "DocumentsSubmitted", Kind: PresentationOfDocuments},
+	ActionAcceptDocuments:    {From: []PresentationStatus{PresentationPresented}, To: PresentationAccepted, Event: "DocumentsAccepted"},
+	ActionRaiseDiscrepancies: {From: []PresentationStatus{PresentationPresented}, To: PresentationDiscrepant, Event: "DiscrepanciesRaised"},
+	ActionRepresentDocuments: {From: []PresentationStatus{PresentationDiscrepant}, To: PresentationPresented, Event: "DocumentsRepresented"},
+	ActionRequestWaiver:      {From: []PresentationStatus{PresentationDiscrepant}, To: PresentationWaiverRequested, Event: "DiscrepancyWaiverRequested"},
+	ActionWaiveDiscrepancies: {From: []PresentationStatus{PresentationWaiverRequested}, To: PresentationWaived, Event: "DiscrepanciesWaived"},
+	ActionRefuseDocuments:    {From: []PresentationStatus{PresentationDiscrepant, PresentationWaiverRequested}, To: PresentationRefused, Event: "DocumentsRefused"},
+	// claims emit the events of documents, consumers tell them apart by the kind of the presentation
+	ActionSubmitClaim: {To: PresentationPresented, Event: "DocumentsSubmitted", Kind: PresentationOfClaim},
+	ActionHonourClaim: {From: []PresentationStatus{PresentationPresented}, To: PresentationAccepted, Event: "DocumentsAccepted"},
+	ActionRejectClaim: {From: []PresentationStatus{PresentationPresented}, To: PresentationRefused, Event: "DocumentsRefused"},
+}
+
+// decodeDocuments un-marshals the documents of a presentation, every document has to carry the hex encoded
+// SHA-256 digest of its file so it can be verified later against the url, see VerifyDocument
+func decodeDocuments(jsonDocuments string) ([]Document, error) {
+	documents := make([]Document, 0)
+	if err := decodeStrict(jsonDocuments, &documents); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	verr := &ValidationError{}
+	if len(documents) == 0 {
+		verr.add("at least one document must be presented")
+	}
+	for i := range documents {
+		documents[i].URL = strings.TrimSpace(documents[i].URL)
+		documents[i].SHA256 = normalizeDigest(documents[i].SHA256)
+		if documents[i].URL == "" {
+			verr.add("document %d: url is required", i)
+		}
+		if documents[i].SHA256 == "" {
+			verr.add("document %d: sha256 is required", i)
+		} else if !isSHA256Hex(documents[i].SHA256) {
+			verr.add("document %d: sha256 must be a hex encoded SHA-256 digest", i)
+		}
+	}
+	return documents, verr.orNil()
+}
+
+// normalizeDigest trims & lower cases a hex digest so digests compare regardless of how clients render them
+func normalizeDigest(digest string) string {
+	return strings.ToLower(strings.TrimSpace(digest))
+}
+
+// isSHA256Hex tells if digest is a hex encoded SHA-256 digest
+func isSHA256Hex(digest string) bool {
+	decoded, err := hex.DecodeString(digest)
+	return err == nil && len(decoded) == sha256.Size
+}
+
+// decodeDiscrepancies un-marshals & checks the discrepancies raised on a presentation
+func decodeDiscrepancies(jsonDiscrepancies string) ([]Discrepancy, error) {
+	discrepancies := make([]Discrepancy, 0)
+	if err := decodeStrict(jsonDiscrepancies, &discrepancies); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	verr := &ValidationError{}
+	if len(discrepancies) == 0 {
+		verr.add("at least one discrepancy must be raised")
+	}
+	for i, discrepancy := range discrepancies {
+		if _, ok := discrepancyCodes[discrepancy.Code]; !ok {
+			codes := make([]string, 0, len(discrepancyCodes))
+			for code := range discrepancyCodes {
+				codes = append(codes, code)
+			}
+			sort.Strings(codes)
+			verr.add("discrepancy %d: code %q is not one of %s", i, discrepancy.Code, strings.Join(codes, ", "))
+		}
+		if discrepancy.Code == "OTHER" && strings.TrimSpace(discrepancy.Description) == "" {
+			verr.add("discrepancy %d: description is required for code OTHER", i)
+		}
+	}
+	return discrepancies, verr.orNil()
+}
+
+// presentationKey returns the composite key of presentation {seq} of LoC {id}, zero padded so keys sort by sequence
+func presentationKey(ctx contractapi.TransactionContextInterface, id string, seq int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(presentationIndex, []string{id, fmt.Sprintf("%06d", seq)})
+}
+
+// readPresentation returns presentation {seq} of loc, with the amount claimed if the submitting client shares it
+func readPresentation(ctx contractapi.TransactionContextInterface, loc *LoC, seq int) (*Presentation, error) {
+	key, err := presentationKey(ctx, loc.ID, seq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite key: %v", err)
+	}
+	presentationJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if presentationJSON == nil {
+		return nil, fmt.Errorf("presentation #%d of LoC with Id@%s does not exist", seq, loc.ID)
+	}
+	var presentation Presentation
+	err = json.Unmarshal(presentationJSON, &presentation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	err = loadPresentationAmount(ctx, loc, &presentation)
+	if err != nil {
+		return nil, err
+	}
+	return &presentation, nil
+}
+
+// loadPresentationAmount fills in the amount claimed by presentation of loc when the submitting client shares the
+// private details of loc & the amount was recorded
+func loadPresentationAmount(ctx contractapi.TransactionContextInterface, loc *LoC, presentation *Presentation) error {
+	member, err := isPairMember(ctx, loc)
+	if err != nil || !member {
+		return err
+	}
+	key, err := presentationKey(ctx, presentation.LoCID, presentation.Seq)
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	detailsJSON, err := ctx.GetStub().GetPrivateData(privateCollection(loc), key)
+	if err != nil {
+		return fmt.Errorf("failed to read private data: %v", err)
+	}
+	if detailsJSON == nil {
+		return nil
+	}
+	var details presentationPrivateDetails
+	err = json.Unmarshal(detailsJSON, &details)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	// presentations claim amounts in the currency of their LoC
+	if presentation.Amount, err = details.Amount.WithCurrency(loc.CurrencyCode); err != nil {
+		return fmt.Errorf("presentation #%d of LoC with Id@%s: %v", presentation.Seq, presentation.LoCID, err)
+	}
+	return nil
+}
+
+// putPresentation puts presentation of loc on the world state without the amount claimed & the amount in the private
+// data collection of loc. Only the banks sharing the private details write the amount, other parties put it as read.
+func putPresentation(ctx contractapi.TransactionContextInterface, loc *LoC, presentation *Presentation) error {
+	key, err := presentationKey(ctx, presentation.LoCID, presentation.Seq)
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	member, err := isPairMember(ctx, loc)
+	if err != nil {
+		return err
+	}
+	if member && !presentation.Amount.IsEmpty() {
+		detailsJSON, err := json.Marshal(presentationPrivateDetails{DocType: "PresentationPrivateDetails", LoCID: presentation.LoCID, Seq: presentation.Seq, Amount: presentation.Amount})
+		if err != nil {
+			return fmt.Errorf("failed to marshal into Json: %v", err)
+		}
+		err = ctx.GetStub().PutPrivateData(privateCollection(loc), key, detailsJSON)
+		if err != nil {
+			return fmt.Errorf("failed to put private data: %v", err)
+		}
+	}
+	_, err = putJSON(ctx, key, publicPresentation(presentation))
+	return err
+}
+
+// publicPresentation returns a copy of presentation without the amount claimed, for the world state & events
+func publicPresentation(presentation *Presentation) *Presentation {
+	envelope := *presentation
+	envelope.Amount = money.Money{}
+	return &envelope
+}
+
+// claimPresentation records the amount claimed by presentation of loc from transient field loc_presentation,
+// eg. {"amount":"5000000.00"}, nothing if it is not set. The amount is private, only the banks sharing the private
+// details of loc can record it, the presenting bank when it is one of them, else the applicant bank as it examines.
+func claimPresentation(ctx contractapi.TransactionContextInterface, loc *LoC, presentation *Presentation) error {
+	transMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("failed to get transient: %v", err)
+	}
+	requestJSON, ok := transMap[presentationTransientKey]
+	if !ok {
+		return nil
+	}
+	member, err := isPairMember(ctx, loc)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return fmt.Errorf("amount claimed by presentations of LoC with Id@%s is private to %s & %s", loc.ID, loc.ApplicantBank, loc.AdviseThroughBank)
+	}
+	var request presentationRequest
+	err = decodeStrict(string(requestJSON), &request)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal %s from Json: %v", presentationTransientKey, err)
+	}
+	amount, err := request.Amount.WithCurrency(loc.CurrencyCode)
+	if err != nil {
+		return fmt.Errorf("amount claimed by presentation #%d of LoC with Id@%s: %v", presentation.Seq, loc.ID, err)
+	}
+	if sign, _ := amount.Sign(); sign <= 0 {
+		return fmt.Errorf("amount claimed must be positive, got %s", amount)
+	}
+	presentation.Amount = amount
+	return nil
+}
+
+// recordPresentationStatus moves presentation to status & appends the change to its history
+func recordPresentationStatus(ctx contractapi.TransactionContextInterface, presentation *Presentation, status PresentationStatus, comment string) error {
+	stamp, err := stampActor(ctx)
+	if err != nil {
+		return err
+	}
+	presentation.History = append(presentation.History, PresentationStatusEvent{
+		FromStatus: presentation.Status,
+		ToStatus:   status,
+		ActorMSP:   stamp.mspID,
+		ClientID:   stamp.clientID,
+		TxID:       stamp.txID,
+		Timestamp:  stamp.timestamp,
+		Comment:    comment,
+	})
+	presentation.Status = status
+	return nil
+}
+
+// followUpEvents are the events of the statuses an LoC settles in after passing through another one
+var followUpEvents = map[LoCStatus]string{
+	StatusDocumentsAccepted: "DocumentsAccepted",
+	StatusAwaitingDocuments: "DocumentsAwaited",
+	StatusAwaitingClaim:     "ClaimAwaited",
+}
+
+// followUpComment describes the status an LoC settles in after passing through another one
+func followUpComment(loc *LoC, status LoCStatus) string {
+	switch status {
+	case StatusDocumentsAccepted:
+		return fmt.Sprintf("Documents accepted by %s from %s", loc.ApplicantBank, loc.NegotiatingBank)
+	case StatusAwaitingDocuments:
+		return fmt.Sprintf("%s awaiting documents from %s", loc.ApplicantBank, loc.NegotiatingBank)
+	case StatusAwaitingClaim:
+		return fmt.Sprintf("%s awaiting a claim from %s", loc.ApplicantBank, loc.NegotiatingBank)
+	}
+	return ""
+}
+
+// movePresentation takes action on the current presentation of LoC {id}, or starts a new one. It checks role,
+// LoC & presentation status, lets change update both & describe what it did, then records the new statuses,
+// puts both on the ledger & emits the event of the action.
+func (c *LocContract) movePresentation(ctx contractapi.TransactionContextInterface, id string, action Action, change func(loc *LoC, presentation *Presentation) (string, error)) (*LoC, error) {
+	// Get LoC if exists, update it & put it back
+	loc, err := c.updateLoC(ctx, id, func(loc *LoC) (*Event, error) {
+		// only the role of the action can do it
+		err := c.authorize(ctx, loc, action)
+		if err != nil {
+			return nil, err
+		}
+		// check the LoC can move to the next status
+		transition, err := checkTransition(ctx, loc, action)
+		if err != nil {
+			return nil, err
+		}
+		// Get current presentation, or start the next one
+		step := presentationSteps[action]
+		var presentation *Presentation
+		if step.From == nil {
+			loc.PresentationCount++
+			presentation = &Presentation{
+				DocType:       "Presentation",
+				LoCID:         loc.ID,
+				Seq:           loc.PresentationCount,
+				Kind:          step.Kind,
+				PresentedBy:   loc.NegotiatingBank,
+				Discrepancies: make([]Discrepancy, 0),
+				History:       make([]PresentationStatusEvent, 0),
+			}
+		} else {
+			presentation, err = readPresentation(ctx, loc, loc.PresentationCount)
+			if err != nil {
+				log.Printf("error -> readPresentation -> %s\n%v", action, err)
+				return nil, err
+			}
+			allowed := false
+			for _, from := range step.From {
+				allowed = allowed || presentation.Status == from
+			}
+			if !allowed {
+				return nil, fmt.Errorf("presentation #%d of LoC with Id@%s is %s, %s is not allowed", presentation.Seq, loc.ID, presentation.Status, action)
+			}
+		}
+		comment, err := change(loc, presentation)
+		if err != nil {
+			log.Printf("error -> change -> %s\n%v", action, err)
+			return nil, err
+		}
+		// amount claimed, private
+		err = claimPresentation(ctx, loc, presentation)
+		if err != nil {
+			log.Printf("error -> claimPresentation -> %s\n%v", action, err)
+			return nil, err
+		}
+		// presentation status & history
+		err = recordPresentationStatus(ctx, presentation, step.To, comment)
+		if err != nil {
+			log.Printf("error -> recordPresentationStatus -> %s\n%v", action, err)
+			return nil, err
+		}
+		// current status & status log
+		err = recordStatus(ctx, loc, transition.To, fmt.Sprintf("Presentation #%d: %s", presentation.Seq, comment))
+		if err != nil {
+			log.Printf("error -> recordStatus -> %s\n%v", action, err)
+			return nil, err
+		}
+		if transition.Then != StatusNone {
+			err = recordStatus(ctx, loc, transition.Then, followUpComment(loc, transition.Then))
+			if err != nil {
+				log.Printf("error -> recordStatus -> %s\n%v", action, err)
+				return nil, err
+			}
+		}
+		// Put presentation on ledger
+		err = putPresentation(ctx, loc, presentation)
+		if err != nil {
+			log.Printf("error -> putPresentation -> %s\n%v", action, err)
+			return nil, err
+		}
+		return &Event{Name: step.Event, Payload: publicPresentation(presentation)}, nil
+	})
+	if err != nil {
+		log.Printf("error -> c.updateLoC -> %s\n%v", action, err)
+		return nil, err
+	}
+	return loc, nil
+}
+
+// documentUrls lists the urls of documents
+func documentUrls(documents []Document) []string {
+	urls := make([]string, 0, len(documents))
+	for _, document := range documents {
+		urls = append(urls, document.URL)
+	}
+	return urls
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// SubmitDocuments presents documents under LoC {id} as a new presentation,
+// eg. [{"url":"https://...","sha256":"9f86d0...","type":"COMMERCIAL_INVOICE"}]. The sha256 of every document is required.
+// The amount claimed is private, it is passed in transient field loc_presentation, eg. {"amount":"5000000.00"}, by a
+// presenting bank sharing the private details of the LoC, else by the applicant bank as it examines the documents.
+func (c *LocContract) SubmitDocuments(ctx contractapi.TransactionContextInterface, id string, jsonDocuments string) (*LoC, error) {
+	documents, err := decodeDocuments(jsonDocuments)
+	if err != nil {
+		log.Println("error -> decodeDocuments -> SubmitDocuments\n", err)
+		return nil, err
+	}
+	return c.movePresentation(ctx, id, ActionSubmitDocuments, func(loc *LoC, presentation *Presentation) (string, error) {
+		// a paid presentation can only be followed by another one for partial shipments
+		if loc.DrawingCount > 0 && !partialDrawingsAllowed(loc) {
+			return "", fmt.Errorf("partial shipments are not allowed, LoC with Id@%s is already drawn", loc.ID)
+		}
+		presentation.Round = 1
+		presentation.Documents = documents
+		// anchor documents on the LoC, docs Urls array keeps the same order
+		loc.Documents = append(loc.Documents, documents...)
+		loc.DocsUrls = append(loc.DocsUrls, documentUrls(documents)...)
+		return fmt.Sprintf("%d document(s) submitted by %s to %s", len(documents), loc.NegotiatingBank, loc.ApplicantBank), nil
+	})
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// AcceptDocuments accepts the current presentation of LoC {id} as complying
+func (c *LocContract) AcceptDocuments(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+	return c.movePresentation(ctx, id, ActionAcceptDocuments, func(loc *LoC, presentation *Presentation) (string, error) {
+		return fmt.Sprintf("Documents accepted by %s from %s", loc.ApplicantBank, loc.NegotiatingBank), nil
+	})
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// RaiseDiscrepancies finds the current presentation of LoC {id} discrepant, eg. [{"code":"LATE_SHIPMENT","description":"B/L dated 20220301"}]
+func (c *LocContract) RaiseDiscrepancies(ctx contractapi.TransactionContextInterface, id string, jsonDiscrepancies string) (*LoC, error) {
+	discrepancies, err := decodeDiscrepancies(jsonDiscrepancies)
+	if err != nil {
+		log.Println("error -> decodeDiscrepancies -> RaiseDiscrepancies\n", err)
+		return nil, err
+	}
+	return c.movePresentation(ctx, id, ActionRaiseDiscrepancies, func(loc *LoC, presentation *Presentation) (string, error) {
+		presentation.Discrepancies = discrepancies
+		return fmt.Sprintf("Discrepancies %s raised by %s", discrepancyList(discrepancies), loc.ApplicantBank), nil
+	})
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// RepresentDocuments presents corrected documents for the discrepant presentation of LoC {id}
+func (c *LocContract) RepresentDocuments(ctx contractapi.TransactionContextInterface, id string, jsonDocuments string) (*LoC, error) {
+	documents, err := decodeDocuments(jsonDocuments)
+	if err != nil {
+		log.Println("error -> decodeDocuments -> RepresentDocuments\n", err)
+		return nil, err
+	}
+	return c.movePresentation(ctx, id, ActionRepresentDocuments, func(loc *LoC, presentation *Presentation) (string, error) {
+		corrected := discrepancyList(presentation.Discrepancies)
+		presentation.Round++
+		presentation.Documents = documents
+		presentation.Discrepancies = make([]Discrepancy, 0)
+		// anchor documents on the LoC, docs Urls array keeps the same order
+		loc.Documents = append(loc.Documents, documents...)
+		loc.DocsUrls = append(loc.DocsUrls, documentUrls(documents)...)
+		return fmt.Sprintf("%d document(s) re-presented by %s for discrepancies %s", len(documents), loc.NegotiatingBank, corrected), nil
+	})
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// RequestDiscrepancyWaiver asks the applicant bank to waive the discrepancies of the current presentation of LoC {id}
+func (c *LocContract) RequestDiscrepancyWaiver(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+	return c.movePresentation(ctx, id, ActionRequestWaiver, func(loc *LoC, presentation *Presentation) (string, error) {
+		return fmt.Sprintf("Waiver of discrepancies %s requested by %s", discrepancyList(presentation.Discrepancies), loc.NegotiatingBank), nil
+	})
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// WaiveDiscrepancies waives the discrepancies of the current presentation of LoC {id}, the documents are then accepted
+func (c *LocContract) WaiveDiscrepancies(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+	return c.movePresentation(ctx, id, ActionWaiveDiscrepancies, func(loc *LoC, presentation *Presentation) (string, error) {
+		return fmt.Sprintf("Discrepancies %s waived by %s", discrepancyList(presentation.Discrepancies), loc.ApplicantBank), nil
+	})
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// RefuseDocuments refuses the discrepant presentation of LoC {id} for given {reason}, the LoC awaits a new presentation
+func (c *LocContract) RefuseDocuments(ctx contractapi.TransactionContextInterface, id string, reason string) (*LoC, error) {
+	reason, err := checkReason(ActionRefuseDocuments, reason)
+	if err != nil {
+		log.Println("error -> checkReason -> RefuseDocuments\n", err)
+		return nil, err
+	}
+	return c.movePresentation(ctx, id, ActionRefuseDocuments, func(loc *LoC, presentation *Presentation) (string, error) {
+		presentation.RefusalReason = reason
+		return fmt.Sprintf("Documents refused by %s: %s", loc.ApplicantBank, reason), nil
+	})
+}
+
+// checkReason returns the trimmed {reason} given to refuse documents or reject a claim by action, it must not be blank
+func checkReason(action Action, reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	verr := &ValidationError{}
+	if reason == "" {
+		verr.add("reason is required to %s", action)
+	}
+	return reason, verr.orNil()
+}
+
+// discrepancyList renders the codes of discrepancies, eg. "LATE_SHIPMENT, DOCUMENT_MISSING"
+func discrepancyList(discrepancies []Discrepancy) string {
+	var codes bytes.Buffer
+	for i, discrepancy := range discrepancies {
+		if i > 0 {
+			codes.WriteString(", ")
+		}
+		codes.WriteString(discrepancy.Code)
+	}
+	return codes.String()
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// GetLoCPresentations returns every presentation made under LoC with given {id}, oldest first. Amounts claimed are
+// only shown to the applicant & advising banks.
+func (c *LocContract) GetLoCPresentations(ctx contractapi.TransactionContextInterface, id string) ([]*Presentation, error) {
+	// only parties to the LoC can read its presentations
+	loc, err := c.GetLoCById(ctx, id)
+	if err != nil {
+		log.Println("error -> c.GetLoCById -> GetLoCPresentations\n", err)
+		return nil, err
+	}
+	// Get result iterator
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(presentationIndex, []string{id})
+	if err != nil {
+		log.Println("error -> ctx.GetStub.GetStateByPartialCompositeKey -> GetLoCPresentations\n", err)
+		return nil, fmt.Errorf("failed to get presentations: %v", err)
+	}
+	defer resultsIterator.Close()
+	presentations := make([]*Presentation, 0)
+	// Iterate the results, unmarshal & append to presentations & return
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			log.Println("error -> resultsIterator.Next -> GetLoCPresentations\n", err)
+			return nil, fmt.Errorf("failed to read from result iterator: %v", err)
+		}
+		var presentation Presentation
+		err = json.Unmarshal(queryResult.Value, &presentation)
+		if err != nil {
+			log.Println("error -> json.Unmarshal -> GetLoCPresentations\n", err)
+			return nil, fmt.Errorf("failed to unmarshal query result: %v", err)
+		}
+		err = loadPresentationAmount(ctx, loc, &presentation)
+		if err != nil {
+			log.Println("error -> loadPresentationAmount -> GetLoCPresentations\n", err)
+			return nil, err
+		}
+		presentations = append(presentations, &presentation)
+	}
+	return presentations, nil
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// GetLoCPresentation returns presentation {seq} made under LoC with given {id}
+func (c *LocContract) GetLoCPresentation(ctx contractapi.TransactionContextInterface, id string, seq int) (*Presentation, error) {
+	// only parties to the LoC can read its presentations
+	loc, err := c.GetLoCById(ctx, id)
+	if err != nil {
+		log.Println("error -> c.GetLoCById -> GetLoCPresentation\n", err)
+		return nil, err
+	}
+	presentation, err := readPresentation(ctx, loc, seq)
+	if err != nil {
+		log.Println("error -> readPresentation -> GetLoCPresentation\n", err)
+		return nil, err
+	}
+	return presentation, nil
+}
+
+// DocumentVerification is the result of checking a digest against a document anchored on an LoC
+type DocumentVerification struct {
+	LoCID    string   `json:"loc_id"`
+	DocIndex int      `json:"doc_index"`
+	Document Document `json:"document"` // as anchored on the ledger
+	SHA256   string   `json:"sha256"`   // digest that was checked
+	Matches  bool     `json:"matches"`
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// VerifyDocument checks {digest}, the hex encoded SHA-256 digest of a file, against document {docIndex} of LoC with given {id}.
+// Documents are indexed in the order they were presented, the same as docs_urls.
+func (c *LocContract) VerifyDocument(ctx contractapi.TransactionContextInterface, id string, docIndex int, digest string) (*DocumentVerification, error) {
+	// Get LoC if exists
+	loc, err := c.GetLoCById(ctx, id)
+	if err != nil {
+		log.Println("error -> c.GetLoCById -> VerifyDocument\n", err)
+		return nil, err
+	}
+	digest = normalizeDigest(digest)
+	if !isSHA256Hex(digest) {
+		return nil, fmt.Errorf("digest must be a hex encoded SHA-256 digest")
+	}
+	if docIndex < 0 || docIndex >= len(loc.DocsUrls) {
+		return nil, fmt.Errorf("document %d of LoC with Id@%s does not exist, it has %d document(s)", docIndex, id, len(loc.DocsUrls))
+	}
+	// documents presented before digests were anchored only have a url
+	offset := len(loc.DocsUrls) - len(loc.Documents)
+	if docIndex < offset {
+		return nil, fmt.Errorf("document %d of LoC with Id@%s was presented without a digest", docIndex, id)
+	}
+	document := loc.Documents[docIndex-offset]
+	return &DocumentVerification{
+		LoCID:    loc.ID,
+		DocIndex: docIndex,
+		Document: document,
+		SHA256:   digest,
+		Matches:  document.SHA256 == digest,
+	}, nil
+}
@@ -0,0 +1,282 @@
+package chaincode
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeDocuments(t *testing.T) {
+	tests := []struct {
+		name      string
+		documents string
+		err       string
+	}{
+		{name: "valid", documents: `[{"url":" https://docs.example.com/invoice ","sha256":" ` + strings.ToUpper(digest) + `","type":"INVOICE"}]`},
+		{name: "none", documents: `[]`, err: "at least one document must be presented"},
+		{name: "no url", documents: `[{"sha256":"` + digest + `"}]`, err: "document 0: url is required"},
+		{name: "no digest", documents: `[{"url":"https://docs.example.com/invoice"}]`, err: "document 0: sha256 is required"},
+		{name: "short digest", documents: `[{"url":"https://docs.example.com/invoice","sha256":"abcd"}]`, err: "document 0: sha256 must be a hex encoded SHA-256 digest"},
+		{name: "unknown field", documents: `[{"url":"https://docs.example.com/invoice","sha256":"` + digest + `","hash":"x"}]`, err: `unknown field "hash"`},
+	}
+	for _, tt := range tests {
+		documents, err := decodeDocuments(tt.documents)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: decodeDocuments: %v", tt.name, err)
+		case tt.err == "" && (documents[0].URL != "https://docs.example.com/invoice" || documents[0].SHA256 != digest):
+			t.Errorf("%s: decodeDocuments did not normalize %+v", tt.name, documents[0])
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: decodeDocuments error = %v, want it to contain %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestDecodeDiscrepancies(t *testing.T) {
+	tests := []struct {
+		discrepancies string
+		err           string
+	}{
+		{`[{"code":"LATE_SHIPMENT"},{"code":"OTHER","description":"invoice not signed"}]`, ""},
+		{`[]`, "at least one discrepancy must be raised"},
+		{`[{"code":"OTHER"}]`, "discrepancy 0: description is required for code OTHER"},
+		{`[{"code":"LATE_SHIPMENT"},{"code":"late_shipment"}]`, `discrepancy 1: code "late_shipment" is not one of BENEFICIARY_ENDORSEMENT, CREDIT_EXPIRED`},
+	}
+	for _, tt := range tests {
+		_, err := decodeDiscrepancies(tt.discrepancies)
+		if tt.err == "" && err != nil {
+			t.Errorf("decodeDiscrepancies(%s): %v", tt.discrepancies, err)
+		} else if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("decodeDiscrepancies(%s) error = %v, want it to contain %q", tt.discrepancies, err, tt.err)
+		}
+	}
+}
+
+func TestGetLoCPresentations(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", nil)
+	l.run("LC1", []step{
+		{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+		{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+		{org: "Org1", invoke: withArg((*LocContract).RaiseDiscrepancies, `[{"code":"DOCUMENT_MISSING"}]`), want: StatusDiscrepanciesRaised, event: "DiscrepanciesRaised"},
+		{org: "Org1", invoke: withArg((*LocContract).RefuseDocuments, "no bill of lading"), want: StatusAwaitingDocuments, event: "DocumentsRefused"},
+		{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+		{org: "Org1", invoke: withArg((*LocContract).RaiseDiscrepancies, `[{"code":"DOCUMENT_MISSING"}]`), want: StatusDiscrepanciesRaised, event: "DiscrepanciesRaised"},
+		{org: "Org2", invoke: withArg((*LocContract).RepresentDocuments, documents("BILL_OF_LADING")), want: StatusDocumentsSubmitted, event: "DocumentsRepresented"},
+		{org: "Org1", invoke: acceptDocuments, want: StatusDocumentsAccepted, event: "DocumentsAccepted"},
+	})
+	presentations, err := l.contract.GetLoCPresentations(l.orgs["Org1"], "LC1")
+	if err != nil || len(presentations) != 2 {
+		t.Fatalf("GetLoCPresentations = %v, %v", presentations, err)
+	}
+	refused, accepted := presentations[0], presentations[1]
+	if refused.Seq != 1 || refused.Status != PresentationRefused || refused.RefusalReason != "no bill of lading" || len(refused.History) != 3 {
+		t.Errorf("presentation #1 = %+v", refused)
+	}
+	if accepted.Seq != 2 || accepted.Round != 2 || accepted.Kind != PresentationOfDocuments || accepted.Status != PresentationAccepted || len(accepted.Documents) != 1 {
+		t.Errorf("presentation #2 = %+v", accepted)
+	}
+	presentation, err := l.contract.GetLoCPresentation(l.orgs["Org2"], "LC1", 2)
+	if err != nil || presentation.Documents[0].Type != "BILL_OF_LADING" {
+		t.Errorf("GetLoCPresentation(2) = %+v, %v", presentation, err)
+	}
+	if _, err := l.contract.GetLoCPresentation(l.orgs["Org2"], "LC1", 3); err == nil {
+		t.Errorf("GetLoCPresentation(3) of 2 presentations")
+	}
+	// a presentation can only move on from its own status
+	l.begin(l.stub.TxTime, nil)
+	if _, err := l.contract.RefuseDocuments(l.orgs["Org1"], "LC1", " "); err == nil || err.Error() != "invalid LoC: reason is required to RefuseDocuments" {
+		t.Errorf("RefuseDocuments without reason error = %v", err)
+	}
+	if _, err := l.contract.RequestDiscrepancyWaiver(l.orgs["Org2"], "LC1"); err == nil {
+		t.Errorf("RequestDiscrepancyWaiver on accepted documents")
+	}
+}
+
+func TestPresentationKind(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]interface{}
+		submit invoke
+		want   PresentationKind
+	}{
+		{"documents", nil, withArg((*LocContract).SubmitDocuments, documents("INVOICE")), PresentationOfDocuments},
+		{"claim", map[string]interface{}{"instrument_type": InstrumentStandby}, withArg((*LocContract).SubmitClaim, documents(demandDocumentType)), PresentationOfClaim},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLedger(t)
+			l.issue("LC1", tt.fields)
+			l.run("LC1", []step{{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"}})
+			l.begin(time.Time{}, nil)
+			if _, err := tt.submit(l.contract, l.orgs["Org2"], "LC1"); err != nil {
+				t.Fatal(err)
+			}
+			// consumers tell claims from documents by the kind in the event
+			envelopes := l.commit("Org2")
+			if len(envelopes) == 0 || !strings.Contains(string(envelopes[0].Details), `"kind":"`+string(tt.want)+`"`) {
+				t.Errorf("DocumentsSubmitted events = %+v, want kind %s", envelopes, tt.want)
+			}
+			presentation, err := l.contract.GetLoCPresentation(l.orgs["Org1"], "LC1", 1)
+			if err != nil || presentation.Kind != tt.want {
+				t.Errorf("GetLoCPresentation = %+v, %v, want kind %s", presentation, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRejectClaimReason(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", map[string]interface{}{"instrument_type": InstrumentStandby})
+	l.run("LC1", []step{
+		{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+		{org: "Org2", invoke: withArg((*LocContract).SubmitClaim, documents(demandDocumentType)), want: StatusClaimSubmitted, event: "DocumentsSubmitted"},
+	})
+	l.begin(time.Time{}, nil)
+	if _, err := l.contract.RejectClaim(l.orgs["Org1"], "LC1", "\t"); err == nil || err.Error() != "invalid LoC: reason is required to RejectClaim" {
+		t.Errorf("RejectClaim without reason error = %v", err)
+	}
+	l.run("LC1", []step{{org: "Org1", invoke: withArg((*LocContract).RejectClaim, " demand not signed "), want: StatusAwaitingClaim, event: "DocumentsRefused"}})
+	presentation, err := l.contract.GetLoCPresentation(l.orgs["Org2"], "LC1", 1)
+	if err != nil || presentation.RefusalReason != "demand not signed" {
+		t.Errorf("GetLoCPresentation = %+v, %v", presentation, err)
+	}
+}
+
+func TestPresentationAmount(t *testing.T) {
+	claim := func(amount string) map[string]string {
+		return map[string]string{presentationTransientKey: `{"amount":"` + amount + `"}`}
+	}
+	l := newLedger(t)
+	l.issue("LC1", map[string]interface{}{"negotiating_bank": "Org3"})
+	l.run("LC1", []step{{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"}})
+	// the negotiating bank does not share the private details
+	l.begin(time.Time{}, claim("400"))
+	if _, err := l.contract.SubmitDocuments(l.orgs["Org3"], "LC1", documents("INVOICE")); err == nil || !strings.Contains(err.Error(), "is private to Org1 & Org2") {
+		t.Errorf("SubmitDocuments with amount claimed by the negotiating bank error = %v", err)
+	}
+	l.run("LC1", []step{{org: "Org3", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"}})
+	l.begin(time.Time{}, claim("-1"))
+	if _, err := l.contract.RaiseDiscrepancies(l.orgs["Org1"], "LC1", `[{"code":"DOCUMENT_MISSING"}]`); err == nil || err.Error() != "amount claimed must be positive, got INR -1.00" {
+		t.Errorf("RaiseDiscrepancies with a negative amount claimed error = %v", err)
+	}
+	// the applicant bank records it as it examines the documents
+	l.run("LC1", []step{{org: "Org1", invoke: withArg((*LocContract).RaiseDiscrepancies, `[{"code":"DOCUMENT_MISSING"}]`), transient: claim("400"), want: StatusDiscrepanciesRaised, event: "DiscrepanciesRaised"}})
+	if event, _ := l.stub.LastEvent(); strings.Contains(string(event.Payload), "400") {
+		t.Errorf("event carries the amount claimed\n%s", event.Payload)
+	}
+	for org, want := range map[string]string{"Org1": "400.00", "Org2": "400.00", "Org3": ""} {
+		presentation, err := l.contract.GetLoCPresentation(l.orgs[org], "LC1", 1)
+		if err != nil || presentation.Amount.Amount != want {
+			t.Errorf("GetLoCPresentation as %s = %+v, %v, want amount %q", org, presentation, err, want)
+		}
+	}
+	// kept when the negotiating bank moves the presentation on
+	l.run("LC1", []step{{org: "Org3", invoke: requestWaiver, want: StatusWaiverRequested, event: "DiscrepancyWaiverRequested"}})
+	presentations, err := l.contract.GetLoCPresentations(l.orgs["Org2"], "LC1")
+	if err != nil || len(presentations) != 1 || presentations[0].Amount.Amount != "400.00" {
+		t.Errorf("GetLoCPresentations as Org2 = %+v, %v", presentations, err)
+	}
+	for key, value := range l.stub.State() {
+		if strings.Contains(key, presentationIndex) && strings.Contains(string(value), "400") {
+			t.Errorf("world state carries the amount claimed\n%s", value)
+		}
+	}
+}
+
+func TestVerifyDocument(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", nil)
+	l.run("LC1", []step{
+		{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+		{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+	})
+	tests := []struct {
+		name     string
+		docIndex int
+		sha256   string
+		matches  bool
+		err      string
+	}{
+		{name: "same digest", sha256: strings.ToUpper(digest), matches: true},
+		{name: "other digest", sha256: strings.Repeat("0", 64)},
+		{name: "not a digest", sha256: "abc", err: "digest must be a hex encoded SHA-256 digest"},
+		{name: "no such document", docIndex: 1, sha256: digest, err: "document 1 of LoC with Id@LC1 does not exist, it has 1 document(s)"},
+	}
+	for _, tt := range tests {
+		verification, err := l.contract.VerifyDocument(l.orgs["Org1"], "LC1", tt.docIndex, tt.sha256)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: VerifyDocument error = %v, want it to contain %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || verification.Matches != tt.matches || verification.Document.URL != "https://docs.example.com/invoice" {
+			t.Errorf("%s: VerifyDocument = %+v, %v, want matches %t", tt.name, verification, err, tt.matches)
+		}
+	}
+}
+
+func TestVerifyDocumentWithoutDigest(t *testing.T) {
+	// the LoC of InitLedger was presented a document before digests were anchored
+	l := newLedger(t)
+	l.begin(time.Time{}, nil)
+	if err := l.contract.InitLedger(l.orgs["Org1"]); err != nil {
+		t.Fatalf("InitLedger: %v", err)
+	}
+	l.begin(time.Time{}, nil)
+	if _, err := l.contract.VerifyDocument(l.orgs["Org1"], "INLCU0100220001", 0, digest); err == nil || !strings.Contains(err.Error(), "document 0 of LoC with Id@INLCU0100220001 was presented without a digest") {
+		t.Errorf("VerifyDocument of a document without digest error = %v", err)
+	}
+	if _, err := l.contract.VerifyDocument(l.orgs["Org1"], "INLCU0100220001", -1, digest); err == nil || !strings.Contains(err.Error(), "document -1 of LoC with Id@INLCU0100220001 does not exist") {
+		t.Errorf("VerifyDocument(-1) error = %v", err)
+	}
+	// documents presented since are indexed after it
+	l.run("INLCU0100220001", []step{
+		{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+		{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), at: time.Date(2022, time.February, 1, 0, 0, 0, 0, time.UTC), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+	})
+	verification, err := l.contract.VerifyDocument(l.orgs["Org2"], "INLCU0100220001", 1, digest)
+	if err != nil || !verification.Matches || verification.Document.Type != "INVOICE" {
+		t.Errorf("VerifyDocument(1) = %+v, %v", verification, err)
+	}
+}
+
+func TestPresentationWaiver(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", nil)
+	l.run("LC1", []step{
+		{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+		{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+		{org: "Org1", invoke: withArg((*LocContract).RaiseDiscrepancies, `[{"code":"LATE_SHIPMENT","description":"shipped on 20220210"}]`), want: StatusDiscrepanciesRaised, event: "DiscrepanciesRaised"},
+		{org: "Org2", invoke: withArg((*LocContract).RepresentDocuments, documents("BILL_OF_LADING")), want: StatusDocumentsSubmitted, event: "DocumentsRepresented"},
+		{org: "Org1", invoke: withArg((*LocContract).RaiseDiscrepancies, `[{"code":"OTHER","description":"not signed"}]`), want: StatusDiscrepanciesRaised, event: "DiscrepanciesRaised"},
+		{org: "Org2", invoke: requestWaiver, want: StatusWaiverRequested, event: "DiscrepancyWaiverRequested"},
+		{org: "Org1", invoke: waiveDiscrepancies, want: StatusDocumentsAccepted, event: "DiscrepanciesWaived"},
+	})
+	presentation, err := l.contract.GetLoCPresentation(l.orgs["Org2"], "LC1", 1)
+	if err != nil {
+		t.Fatalf("GetLoCPresentation: %v", err)
+	}
+	// one presentation, its second round waived
+	statuses := make([]PresentationStatus, 0)
+	for _, event := range presentation.History {
+		statuses = append(statuses, event.ToStatus)
+	}
+	want := []PresentationStatus{PresentationPresented, PresentationDiscrepant, PresentationPresented, PresentationDiscrepant, PresentationWaiverRequested, PresentationWaived}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("presentation statuses = %v, want %v", statuses, want)
+	}
+	if presentation.Status != PresentationWaived || presentation.Round != 2 || len(presentation.Discrepancies) != 1 || presentation.Discrepancies[0].Code != "OTHER" {
+		t.Errorf("presentation = %+v", presentation)
+	}
+	if comment := presentation.History[len(presentation.History)-1].Comment; comment != "Discrepancies OTHER waived by Org1" {
+		t.Errorf("waiver comment = %q", comment)
+	}
+	// documents of every round stay anchored on the LoC
+	loc, _ := l.contract.GetLoCById(l.orgs["Org1"], "LC1")
+	if len(loc.Documents) != 2 || len(loc.DocsUrls) != 2 || loc.Documents[1].Type != "BILL_OF_LADING" {
+		t.Errorf("documents of the LoC = %+v, urls %v", loc.Documents, loc.DocsUrls)
+	}
+}
@@ -0,0 +1,255 @@
+package chaincode
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/money"
+)
+
+// privateDetailsTransientKey is the transient field IssueLoC takes the private details of the LoC from
+const privateDetailsTransientKey = "loc_private"
+
+// amendmentTransientKey is the transient field ProposeLoCAmendment takes changes of private fields from
+const amendmentTransientKey = "loc_amendment"
+
+// drawingTransientKey is the transient field ConfirmPayment takes the amount of the drawing paid from
+const drawingTransientKey = "loc_drawing"
+
+// presentationTransientKey is the transient field presentation transactions take the amount claimed from
+const presentationTransientKey = "loc_presentation"
+
+// LoCPrivateDetails are the commercially sensitive terms of an LoC. They are kept out of the channel world state,
+// in the private data collection of the applicant bank & the advising bank, see privateCollection.
+type LoCPrivateDetails struct {
+	DocType                       string      `json:"doc_type"` // always "LoCPrivateDetails"
+	ID                            string      `json:"ID"`
+	Applicant                     string      `json:"applicant"`
+	Amount                        money.Money `json:"amount"`
+	DescriptionOfGoodsAndServices string      `json:"description_of_goods_and_services"`
+	Charges                       string      `json:"charges"`
+}
+
+// privateFields are the json names of the LoC fields held in LoCPrivateDetails
+var privateFields = map[string]bool{
+	"applicant":                         true,
+	"amount":                            true,
+	"description_of_goods_and_services": true,
+	"charges":                           true,
+}
+
+// pairCollection returns the private data collection shared by two orgs, eg. "collectionLoCOrg1Org2" for Org2 & Org1
+func pairCollection(orgA string, orgB string) string {
+	orgs := []string{orgA, orgB}
+	sort.Strings(orgs)
+	return "collectionLoC" + strings.Join(orgs, "")
+}
+
+// privateCollection returns the collection the private details of loc are kept in, the one of its applicant &
+// advising banks. Other parties, negotiating & reimbursing banks or regulators, only see the public envelope.
+func privateCollection(loc *LoC) string {
+	return pairCollection(loc.ApplicantBank, loc.AdviseThroughBank)
+}
+
+// isPairMember reports whether the submitting client belongs to one of the banks sharing the private details of loc
+func isPairMember(ctx contractapi.TransactionContextInterface, loc *LoC) (bool, error) {
+	org, err := getOrgName(ctx)
+	if err != nil {
+		return false, err
+	}
+	return org != "" && (org == loc.ApplicantBank || org == loc.AdviseThroughBank), nil
+}
+
+// privateDetailsOf returns the private details held by loc
+func privateDetailsOf(loc *LoC) *LoCPrivateDetails {
+	return &LoCPrivateDetails{
+		DocType:                       "LoCPrivateDetails",
+		ID:                            loc.ID,
+		Applicant:                     loc.Applicant,
+		Amount:                        loc.Amount,
+		DescriptionOfGoodsAndServices: loc.DescriptionOfGoodsAndServices,
+		Charges:                       loc.Charges,
+	}
+}
+
+// applyTo sets the private fields of loc from details
+func (details *LoCPrivateDetails) applyTo(loc *LoC) {
+	loc.Applicant = details.Applicant
+	loc.Amount = details.Amount
+	loc.DescriptionOfGoodsAndServices = details.DescriptionOfGoodsAndServices
+	loc.Charges = details.Charges
+}
+
+// hasPrivateDetails tells if any private field of loc is set
+func hasPrivateDetails(loc *LoC) bool {
+	return loc.Applicant != "" || !loc.Amount.IsEmpty() || loc.DescriptionOfGoodsAndServices != "" || loc.Charges != ""
+}
+
+// publicAmendment returns a copy of amendment with the values of private fields blanked, for events
+func publicAmendment(amendment *Amendment) *Amendment {
+	redacted := *amendment
+	redacted.Changes = make([]FieldChange, 0, len(amendment.Changes))
+	for _, change := range amendment.Changes {
+		if privateFields[change.Field] {
+			change.OldValue, change.NewValue = "", ""
+		}
+		redacted.Changes = append(redacted.Changes, change)
+	}
+	return &redacted
+}
+
+// publicLoC returns a copy of loc without its private details, the envelope that goes on the channel world state & events
+func publicLoC(loc *LoC) *LoC {
+	envelope := *loc
+	(&LoCPrivateDetails{}).applyTo(&envelope)
+	return &envelope
+}
+
+// SplitLoC splits loc for IssueLoC, into the public envelope passed as jsonLoC & the private details passed in transient field loc_private
+func SplitLoC(loc *LoC) (*LoC, *LoCPrivateDetails) {
+	return publicLoC(loc), privateDetailsOf(loc)
+}
+
+// putLoC puts the public envelope of loc on the world state & its private details in its private data collection.
+// Private details are only written by their own banks, other parties never read them, so loc is put as read, which
+// only carries details for LoCs put before details were private.
+func putLoC(ctx contractapi.TransactionContextInterface, loc *LoC) error {
+	member, err := isPairMember(ctx, loc)
+	if err != nil {
+		return err
+	}
+	envelope := loc
+	if member {
+		envelope = publicLoC(loc)
+		detailsJSON, err := json.Marshal(privateDetailsOf(loc))
+		if err != nil {
+			return fmt.Errorf("failed to marshal into Json: %v", err)
+		}
+		err = ctx.GetStub().PutPrivateData(privateCollection(loc), loc.ID, detailsJSON)
+		if err != nil {
+			return fmt.Errorf("failed to put private details: %v", err)
+		}
+	}
+	_, err = putJSON(ctx, loc.ID, envelope)
+	return err
+}
+
+// loadPrivateDetails fills in the private details of loc when the submitting client belongs to one of its banks.
+// LoCs put before details were private still carry them in the envelope, they are kept until the LoC is put again.
+func loadPrivateDetails(ctx contractapi.TransactionContextInterface, loc *LoC) error {
+	normalizeLoCAmount(loc)
+	member, err := isPairMember(ctx, loc)
+	if err != nil || !member {
+		return err
+	}
+	detailsJSON, err := ctx.GetStub().GetPrivateData(privateCollection(loc), loc.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read private details: %v", err)
+	}
+	if detailsJSON == nil {
+		return nil
+	}
+	var details LoCPrivateDetails
+	err = json.Unmarshal(detailsJSON, &details)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	details.applyTo(loc)
+	normalizeLoCAmount(loc)
+	return nil
+}
+
+// transientPrivateDetails reads the private details of an LoC from transient field loc_private
+func transientPrivateDetails(ctx contractapi.TransactionContextInterface) (*LoCPrivateDetails, error) {
+	transMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transient: %v", err)
+	}
+	// private details are passed in transient field, so they never reach the block
+	detailsJSON, ok := transMap[privateDetailsTransientKey]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in the transient map, amount, applicant, description_of_goods_and_services & charges are private", privateDetailsTransientKey)
+	}
+	var details LoCPrivateDetails
+	err = decodeStrict(string(detailsJSON), &details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s from Json: %v", privateDetailsTransientKey, err)
+	}
+	details.DocType = "LoCPrivateDetails"
+	return &details, nil
+}
+
+// transientAmendment reads changes of private fields from transient field loc_amendment, none if it is not set
+func transientAmendment(ctx contractapi.TransactionContextInterface) (map[string]string, error) {
+	proposed := map[string]string{}
+	transMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transient: %v", err)
+	}
+	changesJSON, ok := transMap[amendmentTransientKey]
+	if !ok {
+		return proposed, nil
+	}
+	err = decodeStrict(string(changesJSON), &proposed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s from Json: %v", amendmentTransientKey, err)
+	}
+	return proposed, nil
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// GetLoCPrivateDetailsHash returns the hex encoded SHA-256 hash of the private details of LoC with given {id}, as recorded on
+// the channel. Parties without access to the details can check details shared with them off-chain against it.
+func (c *LocContract) GetLoCPrivateDetailsHash(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	// Get LoC if exists
+	loc, err := c.GetLoCById(ctx, id)
+	if err != nil {
+		log.Println("error -> c.GetLoCById -> GetLoCPrivateDetailsHash\n", err)
+		return "", err
+	}
+	// GetPrivateDataHash works for any collection of the chaincode, members or not
+	hashAsBytes, err := ctx.GetStub().GetPrivateDataHash(privateCollection(loc), loc.ID)
+	if err != nil {
+		log.Println("error -> ctx.GetStub.GetPrivateDataHash -> GetLoCPrivateDetailsHash\n", err)
+		return "", fmt.Errorf("failed to get private details hash: %v", err)
+	}
+	if hashAsBytes == nil {
+		return "", fmt.Errorf("private details of LoC with Id@%s do not exist", id)
+	}
+	return hex.EncodeToString(hashAsBytes), nil
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// VerifyLoCPrivateDetails checks the private details passed in transient field loc_private against the hash recorded for
+// LoC with given {id}. The details must be exactly the ones issued or last amended, doc_type & ID included.
+func (c *LocContract) VerifyLoCPrivateDetails(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	loc, err := c.GetLoCById(ctx, id)
+	if err != nil {
+		log.Println("error -> c.GetLoCById -> VerifyLoCPrivateDetails\n", err)
+		return false, err
+	}
+	recorded, err := c.GetLoCPrivateDetailsHash(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	details, err := transientPrivateDetails(ctx)
+	if err != nil {
+		log.Println("error -> transientPrivateDetails -> VerifyLoCPrivateDetails\n", err)
+		return false, err
+	}
+	details.ID = id
+	// a bare amount is taken in the currency of the LoC, the recorded details carry it
+	if amount, err := details.Amount.WithCurrency(loc.CurrencyCode); err == nil {
+		details.Amount = amount
+	}
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal into Json: %v", err)
+	}
+	return GetSHA256HexOfBytes(detailsJSON) == recorded, nil
+}
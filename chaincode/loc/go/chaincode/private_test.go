@@ -0,0 +1,106 @@
+package chaincode
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrivateDetails(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", map[string]interface{}{"negotiating_bank": "Org3"})
+	tests := []struct {
+		org       string
+		applicant string
+		amount    string
+	}{
+		{"Org1", applicant, "INR 1000.00"},
+		{"Org2", applicant, "INR 1000.00"},
+		{"Org3", "", ""}, // negotiating bank
+		{"Reg", "", ""},
+	}
+	for _, tt := range tests {
+		loc, err := l.contract.GetLoCById(l.orgs[tt.org], "LC1")
+		if err != nil {
+			t.Errorf("GetLoCById as %s: %v", tt.org, err)
+			continue
+		}
+		if loc.Applicant != tt.applicant || loc.Amount.String() != tt.amount {
+			t.Errorf("GetLoCById as %s: applicant %q, amount %q, want %q, %q", tt.org, loc.Applicant, loc.Amount, tt.applicant, tt.amount)
+		}
+	}
+	if strings.Contains(string(l.stub.State()["LC1"]), applicant) {
+		t.Errorf("world state carries private details\n%s", l.stub.State()["LC1"])
+	}
+}
+
+func TestVerifyLoCPrivateDetails(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", map[string]interface{}{"negotiating_bank": "Org3"})
+	hash, err := l.contract.GetLoCPrivateDetailsHash(l.orgs["Reg"], "LC1")
+	if err != nil || len(hash) != 64 {
+		t.Fatalf("GetLoCPrivateDetailsHash = %q, %v", hash, err)
+	}
+	tests := []struct {
+		name    string
+		details string
+		want    bool
+	}{
+		{"as issued", `{"amount":1000,"applicant":"` + applicant + `"}`, true},
+		{"with currency", `{"doc_type":"LoCPrivateDetails","ID":"LC1","amount":{"amount":"1000.00","currency":"INR"},"applicant":"` + applicant + `"}`, true},
+		{"other amount", `{"amount":1001,"applicant":"` + applicant + `"}`, false},
+		{"no applicant", `{"amount":1000}`, false},
+	}
+	for _, tt := range tests {
+		l.begin(time.Time{}, map[string]string{privateDetailsTransientKey: tt.details})
+		got, err := l.contract.VerifyLoCPrivateDetails(l.orgs["Org3"], "LC1")
+		if err != nil || got != tt.want {
+			t.Errorf("%s: VerifyLoCPrivateDetails = %t, %v, want %t", tt.name, got, err, tt.want)
+		}
+	}
+	// the hash follows amendments
+	l.run("LC1", []step{
+		{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+		{org: "Org1", invoke: amendAmount, transient: amountAmendment("1500"), want: StatusAmended, event: "LoCAmendmentProposed"},
+		{org: "Org2", invoke: acknowledgeAmendment, want: StatusAwaitingDocuments, event: "LoCAmendmentAcknowledged"},
+	})
+	l.begin(time.Time{}, map[string]string{privateDetailsTransientKey: `{"amount":1500,"applicant":"` + applicant + `"}`})
+	if got, err := l.contract.VerifyLoCPrivateDetails(l.orgs["Org3"], "LC1"); err != nil || !got {
+		t.Errorf("VerifyLoCPrivateDetails of amended details = %t, %v", got, err)
+	}
+	if _, err := l.contract.GetLoCPrivateDetailsHash(l.orgs["Org3"], "LC9"); err == nil {
+		t.Errorf("GetLoCPrivateDetailsHash of an unknown LoC")
+	}
+}
+
+func TestSplitLoC(t *testing.T) {
+	l := newLedger(t)
+	loc := l.issue("LC1", nil)
+	envelope, details := SplitLoC(loc)
+	if hasPrivateDetails(envelope) || envelope.ID != loc.ID || envelope.CurrentStatus != loc.CurrentStatus {
+		t.Errorf("SplitLoC envelope = %+v", envelope)
+	}
+	if details.ID != "LC1" || details.DocType != "LoCPrivateDetails" || details.Applicant != applicant || details.Amount != loc.Amount {
+		t.Errorf("SplitLoC details = %+v", details)
+	}
+	if loc.Applicant != applicant {
+		t.Errorf("SplitLoC changed the LoC")
+	}
+}
+
+func TestPublicAmendment(t *testing.T) {
+	amendment := &Amendment{Changes: []FieldChange{
+		{Field: "amount", OldValue: "1000.00", NewValue: "2000.00"},
+		{Field: "date_of_expiry", OldValue: "20220221", NewValue: "20220301"},
+	}}
+	redacted := publicAmendment(amendment)
+	want := []FieldChange{{Field: "amount"}, {Field: "date_of_expiry", OldValue: "20220221", NewValue: "20220301"}}
+	for i := range want {
+		if redacted.Changes[i] != want[i] {
+			t.Errorf("publicAmendment change %d = %+v, want %+v", i, redacted.Changes[i], want[i])
+		}
+	}
+	if amendment.Changes[0].NewValue != "2000.00" {
+		t.Errorf("publicAmendment changed the amendment")
+	}
+}
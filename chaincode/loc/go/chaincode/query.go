@@ -0,0 +1,231 @@
+package chaincode
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/couchdb"
+	"sample.com/lc/money"
+)
+
+// LoCFilter narrows QueryLoCs down, every field is optional & set fields must all match
+type LoCFilter struct {
+	Role         Role      `json:"role"` // role of the invoking org in the LoC, any party if empty
+	Status       LoCStatus `json:"status"`
+	IsActive     *bool     `json:"is_active"`
+	CurrencyCode string    `json:"currency_code"`
+	AmountMin    *int64    `json:"amount_min"` // whole units of the currency of the LoC, amount is private, see QueryLoCs
+	AmountMax    *int64    `json:"amount_max"`
+	IssuedFrom   string    `json:"issued_from"` // date_of_issue on or after, YYYYMMDD or YYYY-MM-DD
+	IssuedTo     string    `json:"issued_to"`   // date_of_issue on or before
+	ExpiresFrom  string    `json:"expires_from"`
+	ExpiresTo    string    `json:"expires_to"`
+	Beneficiary  string    `json:"beneficiary"`
+}
+
+// LoCPage is one page of QueryLoCs, pass Bookmark to get the next one. Records only falls short of the page size
+// on the last page, FetchedRecordsCount can be above it when LoCs out of the amount range were skipped.
+type LoCPage struct {
+	Records             []*LoC `json:"records"`
+	FetchedRecordsCount int32  `json:"fetched_records_count"` // records read from the state database for this page
+	Bookmark            string `json:"bookmark"`
+}
+
+// locIndexes are the indexes of META-INF/statedb/couchdb/indexes QueryLoCs picks from, most selective first.
+// CouchDB only uses an index if the selector has all of its fields.
+var locIndexes = []struct {
+	name   string
+	fields []string
+}{
+	{"indexLoCBeneficiary", []string{"doc_type", "beneficiary"}},
+	{"indexLoCStatus", []string{"doc_type", "current_status"}},
+	{"indexLoCApplicantBank", []string{"doc_type", "applicant_bank"}},
+	{"indexLoCAdvisingBank", []string{"doc_type", "advise_through_bank"}},
+	{"indexLoCNegotiatingBank", []string{"doc_type", "negotiating_bank"}},
+	{"indexLoCCurrency", []string{"doc_type", "currency_code"}},
+	{"indexLoCActive", []string{"doc_type", "is_active", "date_of_expiry"}},
+	{"indexLoCExpiryDate", []string{"doc_type", "date_of_expiry"}},
+	{"indexLoCIssueDate", []string{"doc_type", "date_of_issue"}},
+}
+
+// roleFields are the LoC fields holding the org of each role
+var roleFields = map[Role]string{
+	RoleApplicantBank:   "applicant_bank",
+	RoleAdvisingBank:    "advise_through_bank",
+	RoleNegotiatingBank: "negotiating_bank",
+}
+
+// dateRange adds the conditions of dates from & to on field to query
+func dateRange(query *couchdb.Query, field string, name string, from string, to string, verr *ValidationError) {
+	for _, bound := range []struct {
+		value string
+		cond  func(field string, value interface{}) couchdb.Condition
+	}{{from, couchdb.Gte}, {to, couchdb.Lte}} {
+		if bound.value == "" {
+			continue
+		}
+		date, err := parseLoCDate(bound.value)
+		if err != nil {
+			verr.add("%s %v", name, err)
+			continue
+		}
+		// stored dates are normalized, see normalizeLoCDates
+		query.Where(bound.cond(field, date.Format(LoCDateLayouts[0])))
+	}
+}
+
+// query builds the CouchDB query of filter for an org, every LoC of the channel for a regulator without role
+func (filter *LoCFilter) query(org string, regulator bool) (*couchdb.Query, error) {
+	verr := &ValidationError{}
+	query := couchdb.NewQuery(couchdb.Eq("doc_type", "LoC"))
+	if filter.Role != "" {
+		field, ok := roleFields[filter.Role]
+		if !ok {
+			verr.add("role %q is not one of %s, %s, %s", filter.Role, RoleApplicantBank, RoleAdvisingBank, RoleNegotiatingBank)
+		}
+		query.Where(couchdb.Eq(field, org))
+	} else if !regulator {
+		query.Where(couchdb.Or(couchdb.Eq("applicant_bank", org), couchdb.Eq("advise_through_bank", org), couchdb.Eq("negotiating_bank", org), couchdb.Eq("reimbursing_bank", org)))
+	}
+	if filter.Status != "" {
+		query.Where(couchdb.Eq("current_status", filter.Status))
+	}
+	if filter.IsActive != nil {
+		query.Where(couchdb.Eq("is_active", *filter.IsActive))
+	}
+	if filter.CurrencyCode != "" {
+		query.Where(couchdb.Eq("currency_code", filter.CurrencyCode))
+	}
+	if filter.Beneficiary != "" {
+		query.Where(couchdb.Eq("beneficiary", filter.Beneficiary))
+	}
+	dateRange(query, "date_of_issue", "issued", filter.IssuedFrom, filter.IssuedTo, verr)
+	dateRange(query, "date_of_expiry", "expires", filter.ExpiresFrom, filter.ExpiresTo, verr)
+	if filter.AmountMin != nil && filter.AmountMax != nil && *filter.AmountMin > *filter.AmountMax {
+		verr.add("amount_min %d must not be greater than amount_max %d", *filter.AmountMin, *filter.AmountMax)
+	}
+	if len(verr.Violations) > 0 {
+		return nil, fmt.Errorf("invalid filter: %s", strings.Join(verr.Violations, "; "))
+	}
+	filter.index(query)
+	return query, nil
+}
+
+// index makes query use the first of locIndexes the filter has every field of, sorted on the fields of the index
+// so pages follow the index, LoCs with the same values by Id. Without such an index CouchDB pages by Id.
+func (filter *LoCFilter) index(query *couchdb.Query) {
+	fields := map[string]bool{
+		"doc_type":              true,
+		roleFields[filter.Role]: filter.Role != "",
+		"current_status":        filter.Status != "",
+		"is_active":             filter.IsActive != nil,
+		"currency_code":         filter.CurrencyCode != "",
+		"beneficiary":           filter.Beneficiary != "",
+		"date_of_issue":         filter.IssuedFrom != "" || filter.IssuedTo != "",
+		"date_of_expiry":        filter.ExpiresFrom != "" || filter.ExpiresTo != "",
+	}
+	for _, index := range locIndexes {
+		covered := true
+		for _, field := range index.fields {
+			covered = covered && fields[field]
+		}
+		if !covered {
+			continue
+		}
+		query.UseIndex("_design/"+index.name+"Doc", index.name)
+		for _, field := range index.fields {
+			query.SortBy(field, false)
+		}
+		return
+	}
+}
+
+// matchesAmount tells if the amount of loc is within the amount range of filter. Issued LoCs have a positive
+// amount, without it the invoking client does not share the private details of loc & it can not match a range.
+func (filter *LoCFilter) matchesAmount(loc *LoC) bool {
+	if filter.AmountMin == nil && filter.AmountMax == nil {
+		return true
+	}
+	if sign, err := loc.Amount.Sign(); err != nil || sign <= 0 {
+		return false
+	}
+	// within tells if the amount is not on the excluded side of bound
+	within := func(bound *int64, excluded int) bool {
+		if bound == nil {
+			return true
+		}
+		limit, err := money.FromMajor(*bound, loc.Amount.Currency)
+		if err != nil {
+			return false
+		}
+		cmp, err := loc.Amount.Cmp(limit)
+		return err == nil && cmp != excluded
+	}
+	return within(filter.AmountMin, -1) && within(filter.AmountMax, 1)
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// QueryLoCs returns a page of at most {pageSize} LCs matching {jsonFilter}, see LoCFilter, starting at {bookmark}, "" for the first page.
+// eg. {"role":"APPLICANT_BANK","status":"AWAITING_DOCUMENTS","currency_code":"INR","expires_to":"20220331"}
+// Amount is private, so the amount range is checked on the LoCs read, against those whose private details the invoking
+// client shares: LoCs are read until the page is full or none is left, see LoCPage.
+func (c *LocContract) QueryLoCs(ctx contractapi.TransactionContextInterface, jsonFilter string, pageSize int32, bookmark string) (*LoCPage, error) {
+	// Un-Marshal jsonFilter to filter, unknown fields are rejected
+	var filter LoCFilter
+	if jsonFilter != "" {
+		err := decodeStrict(jsonFilter, &filter)
+		if err != nil {
+			log.Println("error -> decodeStrict -> QueryLoCs\n", err)
+			return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+		}
+	}
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+	org, err := getOrgName(ctx)
+	if err != nil {
+		log.Println("error -> getOrgName -> QueryLoCs\n", err)
+		return nil, err
+	}
+	regulator, err := c.isRegulator(ctx)
+	if err != nil {
+		log.Println("error -> c.isRegulator -> QueryLoCs\n", err)
+		return nil, err
+	}
+	query, err := filter.query(org, regulator)
+	if err != nil {
+		log.Println("error -> filter.query -> QueryLoCs\n", err)
+		return nil, err
+	}
+	// Query string
+	queryString, err := query.Build()
+	if err != nil {
+		log.Println("error -> Build -> QueryLoCs\n", err)
+		return nil, err
+	}
+	log.Println("queryString", queryString)
+	// Get the page, of the LCs the invoking client may read, reading only as many as the page still misses
+	// so the bookmark never passes a matching LC
+	result := &LoCPage{Records: make([]*LoC, 0), Bookmark: bookmark}
+	for missing := pageSize; missing > 0; missing = pageSize - int32(len(result.Records)) {
+		page, err := c.locs().QueryPage(ctx, queryString, missing, result.Bookmark)
+		if err != nil {
+			log.Println("error -> c.locs.QueryPage -> QueryLoCs\n", err)
+			return nil, err
+		}
+		result.FetchedRecordsCount += page.FetchedRecordsCount
+		result.Bookmark = page.Bookmark
+		// amount range, on private details
+		for _, loc := range values(page.Entries) {
+			if filter.matchesAmount(loc) {
+				result.Records = append(result.Records, loc)
+			}
+		}
+		if page.FetchedRecordsCount < missing {
+			break
+		}
+	}
+	return result, nil
+}
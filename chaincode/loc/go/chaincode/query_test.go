@@ -0,0 +1,260 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// queryLedger returns a ledger of three LoCs:
+// LC1 issued by Org1 through Org2 for INR 1000.00, LC2 issued by Org1 through Org2 & negotiated by Org3 for USD 5000.00,
+// acknowledged, & LC3 issued by Org3 through Org2 & negotiated by Org1 for INR 300.00
+func queryLedger(t *testing.T) *ledger {
+	t.Helper()
+	l := newLedger(t)
+	for _, issue := range []struct {
+		org    string
+		amount string
+		fields map[string]interface{}
+	}{
+		{"Org1", "1000", nil},
+		{"Org1", "5000", map[string]interface{}{"ID": "LC2", "negotiating_bank": "Org3", "currency_code": "USD", "date_of_issue": "2022-01-10", "date_of_expiry": "20220310"}},
+		{"Org3", "300", map[string]interface{}{"ID": "LC3", "applicant_bank": "Org3", "negotiating_bank": "Org1", "reimbursing_bank": "Org3", "date_of_issue": "20220115", "date_of_expiry": "20220401", "beneficiary": "TATA STEEL LTD"}},
+	} {
+		l.begin(time.Time{}, map[string]string{privateDetailsTransientKey: `{"amount":"` + issue.amount + `"}`})
+		if _, err := l.contract.IssueLoC(l.orgs[issue.org], testLoC("LC1", issue.fields)); err != nil {
+			t.Fatalf("IssueLoC: %v", err)
+		}
+	}
+	l.run("LC2", []step{{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"}})
+	return l
+}
+
+// ids returns the IDs of locs
+func ids(locs []*LoC) []string {
+	ids := make([]string, 0, len(locs))
+	for _, loc := range locs {
+		ids = append(ids, loc.ID)
+	}
+	return ids
+}
+
+func TestQueryLoCs(t *testing.T) {
+	l := queryLedger(t)
+	tests := []struct {
+		org    string
+		filter string
+		want   []string
+	}{
+		{"Org1", ``, []string{"LC1", "LC2", "LC3"}},
+		{"Org1", `{"role":"APPLICANT_BANK"}`, []string{"LC1", "LC2"}},
+		{"Org1", `{"role":"NEGOTIATING_BANK"}`, []string{"LC3"}},
+		{"Org2", `{"role":"ADVISING_BANK"}`, []string{"LC1", "LC2", "LC3"}},
+		{"Org1", `{"status":"ISSUANCE_ACKNOWLEDGED_BY_ADVISING_BANK"}`, []string{"LC2"}},
+		{"Org1", `{"is_active":false}`, []string{}},
+		{"Org1", `{"currency_code":"INR"}`, []string{"LC1", "LC3"}},
+		{"Org1", `{"beneficiary":"TATA STEEL LTD"}`, []string{"LC3"}},
+		{"Org1", `{"issued_from":"2022-01-06","issued_to":"20220115"}`, []string{"LC2", "LC3"}},
+		{"Org1", `{"expires_to":"20220310"}`, []string{"LC1", "LC2"}},
+		// Org1 does not share the private details of LC3, its amount can not match
+		{"Org1", `{"amount_min":500}`, []string{"LC1", "LC2"}},
+		{"Org1", `{"amount_max":1000}`, []string{"LC1"}},
+		{"Org3", ``, []string{"LC2", "LC3"}},
+		{"Org3", `{"amount_min":1}`, []string{"LC3"}},
+		{"Reg", ``, []string{"LC1", "LC2", "LC3"}},
+		{"Reg", `{"amount_min":1}`, []string{}},
+		{"Reg", `{"role":"APPLICANT_BANK"}`, []string{}},
+	}
+	for _, tt := range tests {
+		page, err := l.contract.QueryLoCs(l.orgs[tt.org], tt.filter, 10, "")
+		if err != nil {
+			t.Errorf("QueryLoCs(%s) as %s: %v", tt.filter, tt.org, err)
+			continue
+		}
+		if got := ids(page.Records); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("QueryLoCs(%s) as %s = %v, want %v", tt.filter, tt.org, got, tt.want)
+		}
+	}
+}
+
+func TestQueryLoCsPages(t *testing.T) {
+	l := queryLedger(t)
+	var got []string
+	bookmark := ""
+	for pages := 0; pages < 2; pages++ {
+		page, err := l.contract.QueryLoCs(l.orgs["Org1"], "", 2, bookmark)
+		if err != nil {
+			t.Fatalf("QueryLoCs page %d: %v", pages+1, err)
+		}
+		if page.FetchedRecordsCount != int32(len(page.Records)) {
+			t.Errorf("page %d: fetched %d records, got %d", pages+1, page.FetchedRecordsCount, len(page.Records))
+		}
+		got = append(got, ids(page.Records)...)
+		bookmark = page.Bookmark
+	}
+	if want := []string{"LC1", "LC2", "LC3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+}
+
+func TestQueryLoCsAmountPages(t *testing.T) {
+	l := queryLedger(t)
+	// amount bounds are inclusive
+	page, err := l.contract.QueryLoCs(l.orgs["Org1"], `{"amount_min":1000,"amount_max":5000}`, 10, "")
+	if err != nil {
+		t.Fatalf("QueryLoCs: %v", err)
+	}
+	if got := ids(page.Records); !reflect.DeepEqual(got, []string{"LC1", "LC2"}) {
+		t.Errorf("QueryLoCs of amounts 1000 to 5000 = %v", got)
+	}
+	// LoCs out of the amount range are read past until the page is full, for Org3 LC2 is private & LC3 is not
+	tests := []struct {
+		fetched int32
+		want    []string
+	}{
+		{2, []string{"LC3"}},
+		{0, []string{}},
+	}
+	bookmark := ""
+	for i, tt := range tests {
+		page, err := l.contract.QueryLoCs(l.orgs["Org3"], `{"amount_min":1}`, 1, bookmark)
+		if err != nil {
+			t.Fatalf("QueryLoCs page %d: %v", i+1, err)
+		}
+		if got := ids(page.Records); page.FetchedRecordsCount != tt.fetched || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("page %d = %v of %d fetched, want %v of %d", i+1, got, page.FetchedRecordsCount, tt.want, tt.fetched)
+		}
+		bookmark = page.Bookmark
+	}
+}
+
+func TestLoCFilterIndex(t *testing.T) {
+	tests := []struct {
+		filter string
+		index  string
+	}{
+		{``, ""},
+		{`{"role":"ADVISING_BANK","currency_code":"INR"}`, "indexLoCAdvisingBank"},
+		{`{"currency_code":"INR","status":"EXPIRED"}`, "indexLoCStatus"},
+		{`{"is_active":true}`, ""},
+		{`{"is_active":true,"expires_to":"20220331"}`, "indexLoCActive"},
+		{`{"expires_to":"20220331","issued_from":"20220101"}`, "indexLoCExpiryDate"},
+		{`{"beneficiary":"TATA STEEL LTD","role":"APPLICANT_BANK"}`, "indexLoCBeneficiary"},
+	}
+	for _, tt := range tests {
+		var filter LoCFilter
+		if tt.filter != "" {
+			if err := decodeStrict(tt.filter, &filter); err != nil {
+				t.Fatalf("decodeStrict(%s): %v", tt.filter, err)
+			}
+		}
+		query, err := filter.query("Org1", false)
+		if err != nil {
+			t.Fatalf("query(%s): %v", tt.filter, err)
+		}
+		queryString, err := query.Build()
+		if err != nil {
+			t.Fatalf("Build(%s): %v", tt.filter, err)
+		}
+		var built struct {
+			Sort     []map[string]string `json:"sort"`
+			UseIndex []string            `json:"use_index"`
+		}
+		if err := json.Unmarshal([]byte(queryString), &built); err != nil {
+			t.Fatalf("query %s: %v", queryString, err)
+		}
+		if tt.index == "" {
+			if built.UseIndex != nil || built.Sort != nil {
+				t.Errorf("query(%s) = %s, want no index", tt.filter, queryString)
+			}
+			continue
+		}
+		// sorted on every field of the index
+		index := couchIndex(t, tt.index)
+		sort := make([]map[string]string, 0, len(index.Index.Fields))
+		for _, field := range index.Index.Fields {
+			sort = append(sort, map[string]string{field: "asc"})
+		}
+		if !reflect.DeepEqual(built.UseIndex, []string{"_design/" + index.Ddoc, index.Name}) || !reflect.DeepEqual(built.Sort, sort) {
+			t.Errorf("query(%s) = %s, want index %s sorted on %v", tt.filter, queryString, tt.index, index.Index.Fields)
+		}
+	}
+	// QueryLoCs knows the fields of the indexes it picks from
+	for _, index := range locIndexes {
+		if fields := couchIndex(t, index.name).Index.Fields; !reflect.DeepEqual(fields, index.fields) {
+			t.Errorf("index %s has fields %v, locIndexes says %v", index.name, fields, index.fields)
+		}
+	}
+}
+
+// couchIndexDefinition is an index of META-INF/statedb/couchdb/indexes
+type couchIndexDefinition struct {
+	Index struct {
+		Fields []string `json:"fields"`
+	} `json:"index"`
+	Ddoc string `json:"ddoc"`
+	Name string `json:"name"`
+}
+
+// couchIndex reads the index {name} the chaincode ships
+func couchIndex(t *testing.T, name string) couchIndexDefinition {
+	t.Helper()
+	var index couchIndexDefinition
+	indexJSON, err := os.ReadFile(filepath.Join("..", "META-INF", "statedb", "couchdb", "indexes", name+".json"))
+	if err == nil {
+		err = json.Unmarshal(indexJSON, &index)
+	}
+	if err != nil || index.Name != name {
+		t.Fatalf("index %s = %+v, %v", name, index, err)
+	}
+	return index
+}
+
+func TestQueryLoCsErrors(t *testing.T) {
+	l := queryLedger(t)
+	tests := []struct {
+		filter   string
+		pageSize int32
+		want     string
+	}{
+		{`{"role":"BENEFICIARY"}`, 10, `role "BENEFICIARY" is not one of APPLICANT_BANK, ADVISING_BANK, NEGOTIATING_BANK`},
+		{`{"issued_from":"05/01/2022","expires_to":"x"}`, 10, `invalid filter: issued "05/01/2022" is not a date in format YYYYMMDD or YYYY-MM-DD; expires "x"`},
+		{`{"amount_min":10,"amount_max":5}`, 10, "amount_min 10 must not be greater than amount_max 5"},
+		{`{"currency":"INR"}`, 10, `unknown field "currency"`},
+		{``, 0, "page size must be positive, got 0"},
+	}
+	for _, tt := range tests {
+		_, err := l.contract.QueryLoCs(l.orgs["Org1"], tt.filter, tt.pageSize, "")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("QueryLoCs(%s, %d) error = %v, want it to contain %q", tt.filter, tt.pageSize, err, tt.want)
+		}
+	}
+}
+
+func TestGetLoCs(t *testing.T) {
+	l := queryLedger(t)
+	tests := []struct {
+		name  string
+		query func(c *LocContract, ctx contractapi.TransactionContextInterface) ([]*LoC, error)
+		org   string
+		want  []string
+	}{
+		{"issued", (*LocContract).GetIssuedLoCs, "Org1", []string{"LC1", "LC2"}},
+		{"advising", (*LocContract).GetAdvisingLoCs, "Org2", []string{"LC1", "LC2", "LC3"}},
+		{"negotiating", (*LocContract).GetNegotiatingLoCs, "Org3", []string{"LC2"}},
+		{"all", (*LocContract).GetAllLoCs, "Org3", []string{"LC2", "LC3"}},
+		{"all of a regulator", (*LocContract).GetAllLoCs, "Reg", []string{"LC1", "LC2", "LC3"}},
+	}
+	for _, tt := range tests {
+		locs, err := tt.query(l.contract, l.orgs[tt.org])
+		if got := ids(locs); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s as %s = %v, %v, want %v", tt.name, tt.org, got, err, tt.want)
+		}
+	}
+}
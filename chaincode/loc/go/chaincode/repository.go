@@ -0,0 +1,310 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Repository reads & writes values of type T, put on the world state as Json under their key.
+// Writes optionally emit an event, errors of the stub are wrapped with what failed.
+// asset-transfer-basic & fabcar keep a minimal port of it, without events, queries & history.
+type Repository[T any] struct {
+	// Describe names the value under key in errors, eg. "the asset asset1", the key if nil
+	Describe func(key string) string
+	// Load completes a value read from the world state, eg. with private data, optional.
+	// Values it returns a NotFoundError for are missing for Get & left out of queries.
+	Load func(ctx contractapi.TransactionContextInterface, key string, value *T) error
+	// Store puts value under key, eg. without private data on the world state.
+	// The Json of value is put on the world state if nil.
+	Store func(ctx contractapi.TransactionContextInterface, key string, value *T) error
+	// Emit emits the event of a write, eg. by adding it to the events of the transaction.
+	// The event is set on the stub, with the Json of its payload, if nil.
+	Emit func(ctx contractapi.TransactionContextInterface, event *Event) error
+}
+
+// Event is emitted by a write of a Repository, eg. &Event{Name: "LoCClosed"}
+type Event struct {
+	Name string
+	// Payload is emitted as Json, none if nil
+	Payload interface{}
+}
+
+// Entry is a value read by a query of a Repository, with the key it is stored under
+type Entry[T any] struct {
+	Key   string
+	Value *T
+}
+
+// Page is a page of a paginated query of a Repository
+type Page[T any] struct {
+	Entries             []Entry[T]
+	FetchedRecordsCount int32
+	Bookmark            string // of the next page
+}
+
+// Version is one write of a value, as kept by the history database of the peer
+type Version[T any] struct {
+	TxID      string
+	Timestamp time.Time // zero if the peer did not record it
+	IsDelete  bool
+	Value     *T // nil if the value was deleted
+}
+
+// NotFoundError is returned for a key without value
+type NotFoundError struct {
+	Name string // of the value, eg. "the asset asset1"
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s does not exist", e.Name)
+}
+
+// ExistsError is returned when creating a value under a key that already has one
+type ExistsError struct {
+	Name string // of the value, eg. "the asset asset1"
+}
+
+func (e *ExistsError) Error() string {
+	return fmt.Sprintf("%s already exists", e.Name)
+}
+
+// describe names the value under key in errors
+func (r *Repository[T]) describe(key string) string {
+	if r.Describe == nil {
+		return key
+	}
+	return r.Describe(key)
+}
+
+// Get returns the value under key, a NotFoundError if there is none
+func (r *Repository[T]) Get(ctx contractapi.TransactionContextInterface, key string) (*T, error) {
+	valueJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if valueJSON == nil {
+		return nil, &NotFoundError{Name: r.describe(key)}
+	}
+	var value T
+	err = json.Unmarshal(valueJSON, &value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	if r.Load != nil {
+		err = r.Load(ctx, key, &value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &value, nil
+}
+
+// Exists reports whether there is a value under key, without loading it
+func (r *Repository[T]) Exists(ctx contractapi.TransactionContextInterface, key string) (bool, error) {
+	valueJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	return valueJSON != nil, nil
+}
+
+// Create puts value under key & emits event unless it is nil, an ExistsError if key already has a value
+func (r *Repository[T]) Create(ctx contractapi.TransactionContextInterface, key string, value *T, event *Event) error {
+	exists, err := r.Exists(ctx, key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return &ExistsError{Name: r.describe(key)}
+	}
+	return r.Put(ctx, key, value, event)
+}
+
+// Put puts value under key, whether key has a value or not, & emits event unless it is nil
+func (r *Repository[T]) Put(ctx contractapi.TransactionContextInterface, key string, value *T, event *Event) error {
+	if r.Store != nil {
+		err := r.Store(ctx, key, value)
+		if err != nil {
+			return err
+		}
+	} else {
+		valueJSON, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal into Json: %v", err)
+		}
+		err = ctx.GetStub().PutState(key, valueJSON)
+		if err != nil {
+			return fmt.Errorf("failed to put to world state: %v", err)
+		}
+	}
+	return r.emit(ctx, event)
+}
+
+// Update reads the value under key, changes it with mutate & puts it back, then emits the event mutate returns
+// unless it is nil. Nothing is put if mutate fails.
+func (r *Repository[T]) Update(ctx contractapi.TransactionContextInterface, key string, mutate func(value *T) (*Event, error)) (*T, error) {
+	value, err := r.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	event, err := mutate(value)
+	if err != nil {
+		return nil, err
+	}
+	err = r.Put(ctx, key, value, event)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Delete deletes the value under key & emits event unless it is nil, a NotFoundError if key has no value
+func (r *Repository[T]) Delete(ctx contractapi.TransactionContextInterface, key string, event *Event) error {
+	valueJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if valueJSON == nil {
+		return &NotFoundError{Name: r.describe(key)}
+	}
+	err = ctx.GetStub().DelState(key)
+	if err != nil {
+		return fmt.Errorf("failed to delete from world state: %v", err)
+	}
+	return r.emit(ctx, event)
+}
+
+// Query returns the values matching a CouchDB rich query, eg. {"selector":{"doc_type":"LoC"}}
+func (r *Repository[T]) Query(ctx contractapi.TransactionContextInterface, query string) ([]Entry[T], error) {
+	resultsIterator, err := ctx.GetStub().GetQueryResult(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query result: %v", err)
+	}
+	defer resultsIterator.Close()
+	return r.collect(ctx, resultsIterator)
+}
+
+// QueryPage returns a page of at most pageSize values matching a CouchDB rich query, starting at bookmark, "" for the first page
+func (r *Repository[T]) QueryPage(ctx contractapi.TransactionContextInterface, query string, pageSize int32, bookmark string) (*Page[T], error) {
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetQueryResultWithPagination(query, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query result: %v", err)
+	}
+	defer resultsIterator.Close()
+	entries, err := r.collect(ctx, resultsIterator)
+	if err != nil {
+		return nil, err
+	}
+	return &Page[T]{Entries: entries, FetchedRecordsCount: responseMetadata.FetchedRecordsCount, Bookmark: responseMetadata.Bookmark}, nil
+}
+
+// Range returns the values with keys from startKey to endKey, excluded, "" & "" for all of them
+func (r *Repository[T]) Range(ctx contractapi.TransactionContextInterface, startKey string, endKey string) ([]Entry[T], error) {
+	resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state by range: %v", err)
+	}
+	defer resultsIterator.Close()
+	return r.collect(ctx, resultsIterator)
+}
+
+// collect reads the values of a query result, the ones Load does not find are left out
+func (r *Repository[T]) collect(ctx contractapi.TransactionContextInterface, resultsIterator shim.StateQueryIteratorInterface) ([]Entry[T], error) {
+	entries := make([]Entry[T], 0)
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from result iterator: %v", err)
+		}
+		var value T
+		err = json.Unmarshal(queryResult.Value, &value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal query result: %v", err)
+		}
+		if r.Load != nil {
+			err = r.Load(ctx, queryResult.Key, &value)
+			var notFound *NotFoundError
+			if errors.As(err, &notFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, Entry[T]{Key: queryResult.Key, Value: &value})
+	}
+	return entries, nil
+}
+
+// History returns every version of the value under key, oldest first. Versions are as put on the world state,
+// they are not loaded. Needs the peer history database.
+func (r *Repository[T]) History(ctx contractapi.TransactionContextInterface, key string) ([]Version[T], error) {
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %v", err)
+	}
+	defer resultsIterator.Close()
+	versions := make([]Version[T], 0)
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from history iterator: %v", err)
+		}
+		version := Version[T]{TxID: modification.TxId, IsDelete: modification.IsDelete}
+		if modification.Timestamp != nil {
+			version.Timestamp = time.Unix(modification.Timestamp.Seconds, int64(modification.Timestamp.Nanos))
+		}
+		if !modification.IsDelete {
+			var value T
+			err = json.Unmarshal(modification.Value, &value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to unmarshal version of tx %s from Json: %v", modification.TxId, err)
+			}
+			version.Value = &value
+		}
+		versions = append(versions, version)
+	}
+	// peers before Fabric v2 return the oldest version first, later ones the newest
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Timestamp.Before(versions[j].Timestamp)
+	})
+	return versions, nil
+}
+
+// values returns the values of entries
+func values[T any](entries []Entry[T]) []*T {
+	values := make([]*T, 0, len(entries))
+	for _, entry := range entries {
+		values = append(values, entry.Value)
+	}
+	return values
+}
+
+// emit emits event with Emit, or sets it on the stub if Emit is nil, nothing if event is nil
+func (r *Repository[T]) emit(ctx contractapi.TransactionContextInterface, event *Event) error {
+	if event == nil {
+		return nil
+	}
+	if r.Emit != nil {
+		return r.Emit(ctx, event)
+	}
+	var payloadJSON []byte
+	if event.Payload != nil {
+		var err error
+		payloadJSON, err = json.Marshal(event.Payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal into Json: %v", err)
+		}
+	}
+	err := ctx.GetStub().SetEvent(event.Name, payloadJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set event: %v", err)
+	}
+	return nil
+}
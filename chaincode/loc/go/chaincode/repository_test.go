@@ -0,0 +1,173 @@
+package chaincode
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/fabrictest"
+)
+
+// item is the value of the repository tests
+type item struct {
+	Name   string `json:"name"`
+	Hidden bool   `json:"hidden"`
+}
+
+// items returns a repository of items that does not find hidden ones & the context of its tests
+func items() (*Repository[item], *fabrictest.Stub, contractapi.TransactionContextInterface) {
+	stub := fabrictest.NewStub()
+	ctx := fabrictest.NewTransactionContext(stub, fabrictest.NewClientIdentity("Org1MSP", "user1", nil))
+	return &Repository[item]{
+		Describe: func(key string) string { return "the item " + key },
+		Load: func(ctx contractapi.TransactionContextInterface, key string, value *item) error {
+			if value.Hidden {
+				return &NotFoundError{Name: "the item " + key}
+			}
+			return nil
+		},
+	}, stub, ctx
+}
+
+func TestRepositoryWrites(t *testing.T) {
+	repo, stub, ctx := items()
+	if err := repo.Create(ctx, "a", &item{Name: "A"}, &Event{Name: "ItemCreated"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if event, _ := stub.LastEvent(); event.Name != "ItemCreated" || event.Payload != nil {
+		t.Errorf("Create emitted %s %s", event.Name, event.Payload)
+	}
+	var exists *ExistsError
+	if err := repo.Create(ctx, "a", &item{Name: "B"}, nil); !errors.As(err, &exists) || err.Error() != "the item a already exists" {
+		t.Errorf("Create of an existing item error = %v", err)
+	}
+	stub.StartTransaction(stub.TxTime.Add(time.Hour))
+	updated, err := repo.Update(ctx, "a", func(value *item) (*Event, error) {
+		value.Name = "A2"
+		return &Event{Name: "ItemRenamed", Payload: map[string]string{"to": value.Name}}, nil
+	})
+	if err != nil || updated.Name != "A2" || string(stub.State()["a"]) != `{"name":"A2","hidden":false}` {
+		t.Errorf("Update = %+v, %v, state %s", updated, err, stub.State()["a"])
+	}
+	if event, _ := stub.LastEvent(); event.Name != "ItemRenamed" || string(event.Payload) != `{"to":"A2"}` {
+		t.Errorf("Update emitted %s %s", event.Name, event.Payload)
+	}
+	stub.StartTransaction(stub.TxTime.Add(time.Hour))
+	_, err = repo.Update(ctx, "a", func(value *item) (*Event, error) {
+		value.Name = "A3"
+		return nil, fmt.Errorf("not now")
+	})
+	if err == nil || err.Error() != "not now" || string(stub.State()["a"]) != `{"name":"A2","hidden":false}` {
+		t.Errorf("failed Update error = %v, state %s", err, stub.State()["a"])
+	}
+	if event, ok := stub.LastEvent(); ok && event.TxID == stub.TxID {
+		t.Errorf("failed Update emitted %s", event.Name)
+	}
+	var notFound *NotFoundError
+	if _, err := repo.Update(ctx, "b", func(value *item) (*Event, error) { return nil, nil }); !errors.As(err, &notFound) || err.Error() != "the item b does not exist" {
+		t.Errorf("Update of a missing item error = %v", err)
+	}
+	if err := repo.Delete(ctx, "a", &Event{Name: "ItemDeleted"}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if event, _ := stub.LastEvent(); event.Name != "ItemDeleted" || event.Payload != nil {
+		t.Errorf("Delete emitted %s %s", event.Name, event.Payload)
+	}
+	if exists, err := repo.Exists(ctx, "a"); exists || err != nil {
+		t.Errorf("Exists of a deleted item = %t, %v", exists, err)
+	}
+	if err := repo.Delete(ctx, "a", nil); !errors.As(err, &notFound) {
+		t.Errorf("Delete of a deleted item error = %v", err)
+	}
+}
+
+func TestRepositoryEmit(t *testing.T) {
+	repo, stub, ctx := items()
+	emitted := make([]string, 0)
+	repo.Emit = func(ctx contractapi.TransactionContextInterface, event *Event) error {
+		emitted = append(emitted, event.Name)
+		if event.Name == "ItemLost" {
+			return fmt.Errorf("no batch")
+		}
+		return nil
+	}
+	_ = repo.Create(ctx, "a", &item{Name: "A"}, &Event{Name: "ItemCreated"})
+	_ = repo.Put(ctx, "a", &item{Name: "A2"}, nil)
+	if err := repo.Delete(ctx, "a", &Event{Name: "ItemLost"}); err == nil || err.Error() != "no batch" {
+		t.Errorf("Delete with a failing Emit error = %v", err)
+	}
+	if !reflect.DeepEqual(emitted, []string{"ItemCreated", "ItemLost"}) {
+		t.Errorf("Emit got %v", emitted)
+	}
+	if _, ok := stub.LastEvent(); ok {
+		t.Errorf("events were set on the stub besides Emit")
+	}
+}
+
+func TestRepositoryReads(t *testing.T) {
+	repo, stub, ctx := items()
+	for _, key := range []string{"c", "a", "b"} {
+		_ = repo.Put(ctx, key, &item{Name: key, Hidden: key == "b"}, nil)
+	}
+	_ = stub.PutState("junk", []byte("not json"))
+	if value, err := repo.Get(ctx, "a"); err != nil || value.Name != "a" {
+		t.Errorf("Get(a) = %+v, %v", value, err)
+	}
+	var notFound *NotFoundError
+	for _, key := range []string{"b", "z"} {
+		if _, err := repo.Get(ctx, key); !errors.As(err, &notFound) || err.Error() != fmt.Sprintf("the item %s does not exist", key) {
+			t.Errorf("Get(%s) error = %v", key, err)
+		}
+	}
+	if _, err := repo.Get(ctx, "junk"); err == nil {
+		t.Errorf("Get of junk")
+	}
+	// hidden items are left out
+	keys := func(entries []Entry[item]) []string {
+		keys := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			keys = append(keys, entry.Key)
+		}
+		return keys
+	}
+	if entries, err := repo.Range(ctx, "a", "d"); err != nil || !reflect.DeepEqual(keys(entries), []string{"a", "c"}) {
+		t.Errorf("Range = %v, %v", keys(entries), err)
+	}
+	if entries, err := repo.Query(ctx, `{"selector":{"name":{"$in":["b","c"]}}}`); err != nil || !reflect.DeepEqual(keys(entries), []string{"c"}) || entries[0].Value.Name != "c" {
+		t.Errorf("Query = %v, %v", keys(entries), err)
+	}
+	page, err := repo.QueryPage(ctx, `{"selector":{"name":{"$gte":"a"}}}`, 2, "")
+	if err != nil || !reflect.DeepEqual(keys(page.Entries), []string{"a"}) || page.FetchedRecordsCount != 2 || page.Bookmark == "" {
+		t.Errorf("QueryPage = %+v, %v", page, err)
+	}
+	if _, err := repo.Query(ctx, `{"selector":{}`); err == nil {
+		t.Errorf("Query with an invalid query")
+	}
+	if _, err := repo.Range(ctx, "", ""); err == nil {
+		t.Errorf("Range over junk")
+	}
+}
+
+func TestRepositoryHistory(t *testing.T) {
+	repo, stub, ctx := items()
+	start := stub.TxTime
+	_ = repo.Put(ctx, "a", &item{Name: "v1"}, nil)
+	tx2 := stub.StartTransaction(start.Add(time.Hour))
+	_ = repo.Put(ctx, "a", &item{Name: "v2", Hidden: true}, nil)
+	stub.StartTransaction(start.Add(2 * time.Hour))
+	_ = repo.Delete(ctx, "a", nil)
+	versions, err := repo.History(ctx, "a")
+	if err != nil || len(versions) != 3 {
+		t.Fatalf("History = %+v, %v", versions, err)
+	}
+	// oldest first, hidden versions too
+	if versions[0].Value.Name != "v1" || versions[1].Value.Name != "v2" || versions[1].TxID != tx2 || !versions[1].Timestamp.Equal(start.Add(time.Hour)) {
+		t.Errorf("History = %+v", versions)
+	}
+	if !versions[2].IsDelete || versions[2].Value != nil {
+		t.Errorf("deleted version = %+v", versions[2])
+	}
+}
@@ -1,57 +1,67 @@
 package chaincode
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/couchdb"
+	"sample.com/lc/events"
+	"sample.com/lc/money"
 )
 
-// TODOS:::::::::::::::
-// C H A I N C O D E
-// checks to ensure , the correct in order movement of states- chaincode, AND accordingly, only correct actions displayed/can-be-taken on UI
-// TODO Check in issuance , with same id, present or not, before issuing
-// TODO- only advising-org can call its flow
-// TODO- only issuing-org can call its flow
-// TODO- only negotiating-org can call its flow
-// TODO- only parties involved can access read locs from ledger(restrict)
-
 // SmartContract provides functions for creating & managing our LoC
 type LocContract struct {
 	contractapi.Contract
+	// RequiredAttributes optionally restricts actions to clients whose certificate carries the given attributes
+	RequiredAttributes map[Action][]AttributeRequirement
+	// RegulatorMSPs can read every LoC, other clients only read the LoCs their org is a party to
+	RegulatorMSPs []string
 }
 
-// LoC describes basic details of what makes up a letter of credit
+// LoC describes basic details of what makes up a letter of credit.
+// Applicant, amount, description of goods & charges are private to the applicant & advising banks, the world state
+// only has the rest of the LoC, see private.go
 type LoC struct {
-	ID                                                  string   `json:"ID"`       // serial number which uniquely identifies the LoC
-	DocType                                             string   `json:"doc_type"` // doc_type is used to distinguish the various types of objects in state database
-	DocumentaryCreditNumber                             string   `json:"documentary_credit_number"`
-	FormOfDocumentaryCredit                             string   `json:"form_of_documentary_credit"`
-	DateOfIssue                                         string   `json:"date_of_issue"`
-	DateOfExpiry                                        string   `json:"date_of_expiry"`
-	PlaceOfExpiry                                       string   `json:"place_of_expiry"`
-	ApplicantBank                                       string   `json:"applicant_bank"`
-	Applicant                                           string   `json:"applicant"`
-	Beneficiary                                         string   `json:"beneficiary"`
-	CurrencyCode                                        string   `json:"currency_code"`
-	Amount                                              int64    `json:"amount"`
-	AvailableWithBy                                     string   `json:"available_with_by"`
-	DraftsAt                                            string   `json:"drafts_at"`
-	LoadingFrom                                         string   `json:"loading_from"`
-	TransportationTo                                    string   `json:"transportation_to"`
-	DescriptionOfGoodsAndServices                       string   `json:"description_of_goods_and_services"`
-	DocumentsRequired                                   string   `json:"documents_required"`
-	Charges                                             string   `json:"charges"`
-	PeriodForPresentation                               string   `json:"period_for_presentation"`
-	ReimbursingBank                                     string   `json:"reimbursing_bank"`
-	InstructionsToThePayingOrAcceptingOrNegotiatingBank string   `json:"instructions_to_the_paying_or_accepting_or_negotiating_bank"`
-	AdviseThroughBank                                   string   `json:"advise_through_bank"`
-	NegotiatingBank                                     string   `json:"negotiating_bank"`
-	IsActive                                            bool     `json:"is_active"` // Is LoC active/expired
-	CurrentStatus                                       string   `json:"current_status"`
-	StatusLog                                           []string `json:"status_log"`
-	DocsUrls                                            []string `json:"docs_urls"`
+	ID                                                  string         `json:"ID"`       // serial number which uniquely identifies the LoC
+	DocType                                             string         `json:"doc_type"` // doc_type is used to distinguish the various types of objects in state database
+	DocumentaryCreditNumber                             string         `json:"documentary_credit_number"`
+	FormOfDocumentaryCredit                             string         `json:"form_of_documentary_credit"`
+	InstrumentType                                      InstrumentType `json:"instrument_type"` // DOCUMENTARY_CREDIT, STANDBY or GUARANTEE, see instrument.go
+	DateOfIssue                                         string         `json:"date_of_issue"`
+	DateOfExpiry                                        string         `json:"date_of_expiry"`
+	PlaceOfExpiry                                       string         `json:"place_of_expiry"`
+	ApplicantBank                                       string         `json:"applicant_bank"`
+	Applicant                                           string         `json:"applicant"`
+	Beneficiary                                         string         `json:"beneficiary"`
+	CurrencyCode                                        string         `json:"currency_code"`
+	Amount                                              money.Money    `json:"amount"`                  // in currency_code, eg. {"amount":"11436300.00","currency":"INR"}
+	TolerancePlusPercent                                int            `json:"tolerance_plus_percent"`  // 39A, eg. 10 for +10%
+	ToleranceMinusPercent                               int            `json:"tolerance_minus_percent"` // 39A, eg. 10 for -10%
+	AvailableWithBy                                     string         `json:"available_with_by"`
+	DraftsAt                                            string         `json:"drafts_at"`
+	LoadingFrom                                         string         `json:"loading_from"`
+	TransportationTo                                    string         `json:"transportation_to"`
+	LatestDateOfShipment                                string         `json:"latest_date_of_shipment"` // 44C
+	ShipmentPeriod                                      string         `json:"shipment_period"`         // 44D
+	PartialShipments                                    string         `json:"partial_shipments"`       // 43P, ALLOWED if empty, see drawing.go
+	DescriptionOfGoodsAndServices                       string         `json:"description_of_goods_and_services"`
+	DocumentsRequired                                   string         `json:"documents_required"`
+	Charges                                             string         `json:"charges"`
+	PeriodForPresentation                               string         `json:"period_for_presentation"`
+	ReimbursingBank                                     string         `json:"reimbursing_bank"`
+	InstructionsToThePayingOrAcceptingOrNegotiatingBank string         `json:"instructions_to_the_paying_or_accepting_or_negotiating_bank"`
+	AdviseThroughBank                                   string         `json:"advise_through_bank"`
+	NegotiatingBank                                     string         `json:"negotiating_bank"`
+	IsActive                                            bool           `json:"is_active"` // Is LoC active/expired
+	CurrentStatus                                       LoCStatus      `json:"current_status"`
+	StatusLog                                           StatusLog      `json:"status_log"`
+	DocsUrls                                            []string       `json:"docs_urls"`
+	Documents                                           []Document     `json:"documents,omitempty" metadata:",optional"` // anchored documents, the last len(Documents) of DocsUrls
+	AmendmentCount                                      int            `json:"amendment_count"`                          // sequence of the latest amendment, see amendment.go
+	PresentationCount                                   int            `json:"presentation_count"`                       // sequence of the latest presentation, see presentation.go
+	DrawingCount                                        int            `json:"drawing_count"`                            // sequence of the latest drawing, see drawing.go
 }
 
 // ********************** HAPPY FLOW START **********************
@@ -62,7 +72,7 @@ type LoC struct {
 // vvv ISSUANCE_ACKNOWLEDGED_BY_ADVISING_BANK
 // ----------------------------------------------------------------
 // vvv AMENDED_BY_APPLICANT_BANK
-// vvv AMENDMENT_ACKNOWLEDGED_BY_ADVISING_BANK
+// vvv AMENDMENT_ACKNOWLEDGED_BY_ADVISING_BANK (or AMENDMENT_REJECTED_BY_ADVISING_BANK)
 // ----------------------------------------------------------------
 // vvv AWAITING_DOCUMENTS
 // ----------------------------------------------------------------
@@ -71,6 +81,7 @@ type LoC struct {
 // ----------------------------------------------------------------
 // vvv DOCUMENTS_SUBMITTED_BY_NEGOTIATING_BANK
 // vvv DOCUMENTS_ACCEPTED_BY_APPLICANT_BANK
+// (or DISCREPANCIES_RAISED_BY_APPLICANT_BANK, see presentation.go)
 // ----------------------------------------------------------------
 // vvv PAYMENT_MADE_TO_NEGOTIATING_BANK
 // ----------------------------------------------------------------
@@ -78,413 +89,326 @@ type LoC struct {
 // ----------------------------------------------------------------
 // vvv CLOSED_BY_APPLICANT_BANK
 // ----------------------------------------------------------------
+// (or EXPIRED past date of expiry & then CLOSED_BY_APPLICANT_BANK, see expiry.go)
+// ----------------------------------------------------------------
 // ********************** HAPPY FLOW END **********************
+// Standbys & guarantees await a claim instead of documents, see claim.go
 
 // -------------------------------------------------------------------------------------------------------------------------------------
 // IssueLoC issues a new LoC and puts on the ledger
 func (c *LocContract) IssueLoC(ctx contractapi.TransactionContextInterface, jsonLoC string) (*LoC, error) {
-	// only applicant bank can do it- check
-	// Un-Marshal jsonLoC to loc
+	// Un-Marshal jsonLoC to loc, unknown fields are rejected
 	var loc LoC
-	json.Unmarshal([]byte(jsonLoC), &loc)
-	// current status
-	loc.CurrentStatus = "ISSUED_BY_APPLICANT_BANK"
-	// status log
-	current_time := GetTodaysDateTimeFormatted()
-	status := fmt.Sprintf("LoC issued by %s on %s", loc.ApplicantBank, current_time)
-	loc.StatusLog = append(loc.StatusLog, status)
-	// is_Active
-	loc.IsActive = true
-	// doc_urls - empty array of strings initialised on its own
-	loc.DocsUrls = make([]string, 0)
-	// Marshal loc
-	locJSON, err := json.Marshal(loc)
+	err := decodeStrict(jsonLoC, &loc)
 	if err != nil {
-		log.Println("error -> json.Marshal -> IssueLoC\n", err)
-		return nil, fmt.Errorf("failed to marshal into Json: %v", err)
+		log.Println("error -> decodeStrict -> IssueLoC\n", err)
+		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
 	}
-	// Put on ledger
-	err = ctx.GetStub().PutState(loc.ID, locJSON)
-	if err != nil {
-		log.Println("error -> ctx.GetStub.PutState -> IssueLoC\n", err)
-		return nil, fmt.Errorf("failed to put on ledger: %v", err)
+	// private details come in transient field, jsonLoC ends up in the block for every org to see
+	if hasPrivateDetails(&loc) {
+		log.Println("error -> private details in jsonLoC -> IssueLoC")
+		return nil, fmt.Errorf("amount, applicant, description_of_goods_and_services & charges are private, pass them in transient field %s", privateDetailsTransientKey)
 	}
-	// Emit the LoCIssued event
-	err = ctx.GetStub().SetEvent("LoCIssued", locJSON)
+	details, err := transientPrivateDetails(ctx)
 	if err != nil {
-		log.Println("error -> ctx.GetStub.SetEvent -> IssueLoC\n", err)
-		return nil, fmt.Errorf("failed to set event: %v", err)
+		log.Println("error -> transientPrivateDetails -> IssueLoC\n", err)
+		return nil, err
 	}
-	return &loc, nil
-}
-
-// -------------------------------------------------------------------------------------------------------------------------------------
-// AcknowledgeLoCIssuance acknowledges issued LoC and updates status
-func (c *LocContract) AcknowledgeLoCIssuance(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
-	// only advising bank can do it- check
-	// Get LoC if exists
-	loc, err := c.GetLoCById(ctx, id)
+	details.applyTo(&loc)
+	// instrument type decides the terms & the state machine of the LoC
+	normalizeInstrumentType(&loc)
+	// validate required fields, all violations are reported together
+	err = validateLoC(&loc)
 	if err != nil {
-		log.Println("error -> c.GetLoCById -> AcknowledgeLoCIssuance\n", err)
-		return nil, fmt.Errorf("LoC with Id@%s does not exist", id)
+		log.Println("error -> validateLoC -> IssueLoC\n", err)
+		return nil, err
 	}
-	// current status
-	loc.CurrentStatus = "ISSUANCE_ACKNOWLEDGED_BY_ADVISING_BANK"
-	// status log
-	current_time := GetTodaysDateTimeFormatted()
-	status := fmt.Sprintf("LoC issuance acknowledged by %s on %s", loc.AdviseThroughBank, current_time)
-	loc.StatusLog = append(loc.StatusLog, status)
-	// Marshal loc
-	locJSON, err := json.Marshal(loc)
+	normalizeLoCDates(&loc)
+	normalizeLoCAmount(&loc)
+	// only applicant bank can do it
+	err = c.authorize(ctx, &loc, ActionIssueLoC)
 	if err != nil {
-		log.Println("error -> json.Marshal -> AcknowledgeLoCIssuance\n", err)
-		return nil, fmt.Errorf("failed to marshal into Json: %v", err)
+		return nil, err
 	}
-	// Put on ledger
-	err = ctx.GetStub().PutState(loc.ID, locJSON)
-	if err != nil {
-		log.Println("error -> ctx.GetStub.PutState -> AcknowledgeLoCIssuance\n", err)
-		return nil, fmt.Errorf("failed to put on ledger: %v", err)
-	}
-	// Emit the LoCIssuanceAcknowledged event
-	err = ctx.GetStub().SetEvent("LoCIssuanceAcknowledged", locJSON)
-	if err != nil {
-		log.Println("error -> ctx.GetStub.SetEvent -> AcknowledgeLoCIssuance\n", err)
-		return nil, fmt.Errorf("failed to set event: %v", err)
-	}
-	return loc, nil
-}
-
-// -------------------------------------------------------------------------------------------------------------------------------------
-// AmendLoCAmount amends the LoC amount for LoC with given {id} and amount
-func (c *LocContract) AmendLoCAmount(ctx contractapi.TransactionContextInterface, id string, amount int64) (*LoC, error) {
-	// only applicant bank can do it- check
-	// Get LoC if exists
-	loc, err := c.GetLoCById(ctx, id)
+	// doc_type is what the rich queries select on
+	loc.DocType = "LoC"
+	// check the LoC can be issued, a new LoC starts without status & status log
+	loc.CurrentStatus = StatusNone
+	loc.StatusLog = make(StatusLog, 0)
+	loc.AmendmentCount = 0
+	loc.PresentationCount = 0
+	loc.DrawingCount = 0
+	transition, err := checkTransition(ctx, &loc, ActionIssueLoC)
 	if err != nil {
-		log.Println("error -> c.GetLoCById -> AmendLoCAmount\n", err)
-		return nil, fmt.Errorf("LoC with Id@%s does not exist", id)
+		return nil, err
 	}
-	// Update LoC amount
-	oldAmount := loc.Amount
-	loc.Amount = amount
-	// current status
-	loc.CurrentStatus = "AMENDED_BY_APPLICANT_BANK"
-	// status log
-	current_time := GetTodaysDateTimeFormatted()
-	status := fmt.Sprintf("LoC amount amednded by %s from %d to %d on %s", loc.ApplicantBank, oldAmount, amount, current_time)
-	loc.StatusLog = append(loc.StatusLog, status)
-	// Marshal loc
-	locJSON, err := json.Marshal(loc)
+	// current status & status log
+	err = recordStatus(ctx, &loc, transition.To, fmt.Sprintf("LoC issued by %s", loc.ApplicantBank))
 	if err != nil {
-		log.Println("error -> json.Marshal -> AmendLoCAmount\n", err)
-		return nil, fmt.Errorf("failed to marshal into Json: %v", err)
+		log.Println("error -> recordStatus -> IssueLoC\n", err)
+		return nil, err
 	}
-	// Put on ledger
-	err = ctx.GetStub().PutState(loc.ID, locJSON)
+	// is_Active
+	loc.IsActive = true
+	// doc_urls - empty array of strings initialised on its own
+	loc.DocsUrls = make([]string, 0)
+	loc.Documents = make([]Document, 0)
+	// LoCIssued event, a new LoC has no snapshot
+	var issued *snapshot
+	envelopes, err := issued.envelopes(ctx, "LoCIssued", &loc, nil)
 	if err != nil {
-		log.Println("error -> ctx.GetStub.PutState -> AmendLoCAmount\n", err)
-		return nil, fmt.Errorf("failed to put on ledger: %v", err)
+		log.Println("error -> envelopes -> IssueLoC\n", err)
+		return nil, err
 	}
-	// Emit the LoCAmountAmended event
-	err = ctx.GetStub().SetEvent("LoCAmountAmended", locJSON)
+	// Put on ledger, an LoC with same id must not be present already
+	err = c.locs().Create(ctx, loc.ID, &loc, &Event{Name: "LoCIssued", Payload: envelopes})
 	if err != nil {
-		log.Println("error -> ctx.GetStub.SetEvent -> AmendLoCAmount\n", err)
-		return nil, fmt.Errorf("failed to set event: %v", err)
+		log.Println("error -> c.locs.Create -> IssueLoC\n", err)
+		return nil, err
 	}
-	return loc, nil
+	return &loc, nil
 }
 
 // -------------------------------------------------------------------------------------------------------------------------------------
-// AcknowledgeLoCAmendment acknowledges amended LoC and updates status
-func (c *LocContract) AcknowledgeLoCAmendment(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
-	// only advising bank can do it- check
-	// Get LoC if exists
-	loc, err := c.GetLoCById(ctx, id)
-	if err != nil {
-		log.Println("error -> c.GetLoCById -> AcknowledgeLoCAmendment\n", err)
-		return nil, fmt.Errorf("LoC with Id@%s does not exist", id)
-	}
-	// current status
-	loc.CurrentStatus = "AMENDMENT_ACKNOWLEDGED_BY_ADVISING_BANK"
-	// status log
-	current_time := GetTodaysDateTimeFormatted()
-	status := fmt.Sprintf("LoC amendment acknowledged by %s on %s", loc.AdviseThroughBank, current_time)
-	loc.StatusLog = append(loc.StatusLog, status)
-	// current status
-	loc.CurrentStatus = "AWAITING_DOCUMENTS"
-	// status log
-	status = fmt.Sprintf("%s awaiting documents from %s", loc.ApplicantBank, loc.NegotiatingBank)
-	loc.StatusLog = append(loc.StatusLog, status)
-	// Marshal loc
-	locJSON, err := json.Marshal(loc)
-	if err != nil {
-		log.Println("error -> json.Marshal -> AcknowledgeLoCAmendment\n", err)
-		return nil, fmt.Errorf("failed to marshal into Json: %v", err)
-	}
-	// Put on ledger
-	err = ctx.GetStub().PutState(loc.ID, locJSON)
-	if err != nil {
-		log.Println("error -> ctx.GetStub.PutState -> AcknowledgeLoCAmendment\n", err)
-		return nil, fmt.Errorf("failed to put on ledger: %v", err)
-	}
-	// Emit the LoCAmendmentAcknowledged event
-	err = ctx.GetStub().SetEvent("LoCAmendmentAcknowledged", locJSON)
+// AcknowledgeLoCIssuance acknowledges issued LoC and updates status
+func (c *LocContract) AcknowledgeLoCIssuance(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+	// Get LoC if exists, update it & put it back
+	loc, err := c.updateLoC(ctx, id, func(loc *LoC) (*Event, error) {
+		// only advising bank can do it
+		err := c.authorize(ctx, loc, ActionAcknowledgeLoCIssuance)
+		if err != nil {
+			return nil, err
+		}
+		// check the LoC can move to the next status
+		transition, err := checkTransition(ctx, loc, ActionAcknowledgeLoCIssuance)
+		if err != nil {
+			return nil, err
+		}
+		// current status & status log
+		err = recordStatus(ctx, loc, transition.To, fmt.Sprintf("LoC issuance acknowledged by %s", loc.AdviseThroughBank))
+		if err != nil {
+			log.Println("error -> recordStatus -> AcknowledgeLoCIssuance\n", err)
+			return nil, err
+		}
+		return &Event{Name: "LoCIssuanceAcknowledged"}, nil
+	})
 	if err != nil {
-		log.Println("error -> ctx.GetStub.SetEvent -> AcknowledgeLoCAmendment\n", err)
-		return nil, fmt.Errorf("failed to set event: %v", err)
+		log.Println("error -> c.updateLoC -> AcknowledgeLoCIssuance\n", err)
+		return nil, err
 	}
 	return loc, nil
 }
 
 // -------------------------------------------------------------------------------------------------------------------------------------
-// SubmitDocuments just updates in ledger that docs for given LoC {id} has been submitted, TODO S3
-func (c *LocContract) SubmitDocuments(ctx contractapi.TransactionContextInterface, id string, jsonDocsUrls string) (*LoC, error) {
-	// only negotiating bank can do it- check
-	docsUrls := []string{}
-	json.Unmarshal([]byte(jsonDocsUrls), &docsUrls)
-	log.Println("docsUrls >>>>>> \n", docsUrls)
-	// Get LoC if exists
-	loc, err := c.GetLoCById(ctx, id)
-	if err != nil {
-		log.Println("error -> c.GetLoCById -> SubmitDocuments\n", err)
-		return nil, fmt.Errorf("LoC with Id@%s does not exist", id)
-	}
-	// append to docs Urls array
-	loc.DocsUrls = docsUrls
-	// current status
-	loc.CurrentStatus = "DOCUMENTS_SUBMITTED_BY_NEGOTIATING_BANK"
-	// status log
-	current_time := GetTodaysDateTimeFormatted()
-	status := fmt.Sprintf("Document(s) submitted by %s to %s on %s", loc.NegotiatingBank, loc.ApplicantBank, current_time)
-	loc.StatusLog = append(loc.StatusLog, status)
-	// Marshal loc
-	locJSON, err := json.Marshal(loc)
-	if err != nil {
-		log.Println("error -> json.Marshal -> SubmitDocuments\n", err)
-		return nil, fmt.Errorf("failed to marshal into Json: %v", err)
-	}
-	// Put on ledger
-	err = ctx.GetStub().PutState(loc.ID, locJSON)
-	if err != nil {
-		log.Println("error -> ctx.GetStub.PutState -> SubmitDocuments\n", err)
-		return nil, fmt.Errorf("failed to put on ledger: %v", err)
-	}
-	// Emit the DocumentsSubmitted event
-	err = ctx.GetStub().SetEvent("DocumentsSubmitted", locJSON)
+// ConfirmPayment updates in ledger that payment to negotiating bank for given LoC {id} has been done & records the drawing.
+// The amount paid is private, it is passed in transient field loc_drawing, eg. {"amount":5000000}, the outstanding amount
+// is drawn if it is not set. Payments beyond the available balance, tolerance included, are rejected.
+// Documentary credits are paid for accepted documents, standbys & guarantees for an honoured claim.
+func (c *LocContract) ConfirmPayment(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+	// Get LoC if exists, update it & put it back
+	loc, err := c.updateLoC(ctx, id, func(loc *LoC) (*Event, error) {
+		// only applicant bank can do it
+		err := c.authorize(ctx, loc, ActionConfirmPayment)
+		if err != nil {
+			return nil, err
+		}
+		// check the LoC can move to the next status
+		transition, err := checkTransition(ctx, loc, ActionConfirmPayment)
+		if err != nil {
+			return nil, err
+		}
+		// drawing of the accepted presentation, within the balance
+		drawing, err := drawPayment(ctx, loc)
+		if err != nil {
+			log.Println("error -> drawPayment -> ConfirmPayment\n", err)
+			return nil, err
+		}
+		// current status & status log
+		err = recordStatus(ctx, loc, transition.To, fmt.Sprintf("Payment of drawing #%d confirmed from %s to %s", drawing.Seq, loc.ApplicantBank, loc.NegotiatingBank))
+		if err != nil {
+			log.Println("error -> recordStatus -> ConfirmPayment\n", err)
+			return nil, err
+		}
+		return &Event{Name: "PaymentConfirmed"}, nil
+	})
 	if err != nil {
-		log.Println("error -> ctx.GetStub.SetEvent -> SubmitDocuments\n", err)
-		return nil, fmt.Errorf("failed to set event: %v", err)
+		log.Println("error -> c.updateLoC -> ConfirmPayment\n", err)
+		return nil, err
 	}
 	return loc, nil
 }
 
 // -------------------------------------------------------------------------------------------------------------------------------------
-// AcceptDocuments is done after its accepted by applicant bank for given LoC, it updates status
-func (c *LocContract) AcceptDocuments(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
-	// only applicant bank can do it- check
-	// Get LoC if exists
-	loc, err := c.GetLoCById(ctx, id)
-	if err != nil {
-		log.Println("error -> c.GetLoCById -> AcceptDocuments\n", err)
-		return nil, fmt.Errorf("LoC with Id@%s does not exist", id)
-	}
-	// current status
-	loc.CurrentStatus = "DOCUMENTS_ACCEPTED_BY_APPLICANT_BANK"
-	// status log
-	current_time := GetTodaysDateTimeFormatted()
-	status := fmt.Sprintf("Documents accepted by %s from %s on %s", loc.ApplicantBank, loc.NegotiatingBank, current_time)
-	loc.StatusLog = append(loc.StatusLog, status)
-	// Marshal loc
-	locJSON, err := json.Marshal(loc)
-	if err != nil {
-		log.Println("error -> json.Marshal -> AcceptDocuments\n", err)
-		return nil, fmt.Errorf("failed to marshal into Json: %v", err)
-	}
-	// Put on ledger
-	err = ctx.GetStub().PutState(loc.ID, locJSON)
-	if err != nil {
-		log.Println("error -> ctx.GetStub.PutState -> AcceptDocuments\n", err)
-		return nil, fmt.Errorf("failed to put on ledger: %v", err)
-	}
-	// Emit the LoCAmendmentAcknowledged event
-	err = ctx.GetStub().SetEvent("DocumentsAccepted", locJSON)
+// AcknowledgePayment is done after payment_receive is checked by negotiating bank for given LoC, it updates status
+func (c *LocContract) AcknowledgePayment(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+	// Get LoC if exists, update it & put it back
+	loc, err := c.updateLoC(ctx, id, func(loc *LoC) (*Event, error) {
+		// only negotiating bank can do it
+		err := c.authorize(ctx, loc, ActionAcknowledgePayment)
+		if err != nil {
+			return nil, err
+		}
+		// check the LoC can move to the next status
+		transition, err := checkTransition(ctx, loc, ActionAcknowledgePayment)
+		if err != nil {
+			return nil, err
+		}
+		// drawing paid
+		err = acknowledgeDrawing(ctx, loc)
+		if err != nil {
+			log.Println("error -> acknowledgeDrawing -> AcknowledgePayment\n", err)
+			return nil, err
+		}
+		// current status & status log
+		err = recordStatus(ctx, loc, transition.To, fmt.Sprintf("Payment acknowledged from %s to %s", loc.ApplicantBank, loc.NegotiatingBank))
+		if err != nil {
+			log.Println("error -> recordStatus -> AcknowledgePayment\n", err)
+			return nil, err
+		}
+		return &Event{Name: "PaymentAcknowledged"}, nil
+	})
 	if err != nil {
-		log.Println("error -> ctx.GetStub.SetEvent -> AcceptDocuments\n", err)
-		return nil, fmt.Errorf("failed to set event: %v", err)
+		log.Println("error -> c.updateLoC -> AcknowledgePayment\n", err)
+		return nil, err
 	}
 	return loc, nil
 }
 
 // -------------------------------------------------------------------------------------------------------------------------------------
-// ConfirmPayment just updates in ledger that payment to negotiating bank for given LoC {id} has been done, TODO S3
-func (c *LocContract) ConfirmPayment(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
-	// only applicant bank can do it- check
-	// Get LoC if exists
-	loc, err := c.GetLoCById(ctx, id)
-	if err != nil {
-		log.Println("error -> c.GetLoCById -> ConfirmPayment\n", err)
-		return nil, fmt.Errorf("LoC with Id@%s does not exist", id)
-	}
-	// current status
-	loc.CurrentStatus = "PAYMENT_DONE_FROM_APPLICANT_BANK_TO_NEGOTIATING_BANK"
-	// status log
-	current_time := GetTodaysDateTimeFormatted()
-	status := fmt.Sprintf("Payment confirmed from %s to %s on %s", loc.ApplicantBank, loc.NegotiatingBank, current_time)
-	loc.StatusLog = append(loc.StatusLog, status)
-	// Marshal loc
-	locJSON, err := json.Marshal(loc)
-	if err != nil {
-		log.Println("error -> json.Marshal -> ConfirmPayment\n", err)
-		return nil, fmt.Errorf("failed to marshal into Json: %v", err)
-	}
-	// Put on ledger
-	err = ctx.GetStub().PutState(loc.ID, locJSON)
-	if err != nil {
-		log.Println("error -> ctx.GetStub.PutState -> ConfirmPayment\n", err)
-		return nil, fmt.Errorf("failed to put on ledger: %v", err)
-	}
-	// Emit the PaymentConfirmed event
-	err = ctx.GetStub().SetEvent("PaymentConfirmed", locJSON)
+// CloseLoC closes the LoC with given {id}
+func (c *LocContract) CloseLoC(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+	// Get LoC if exists, update it & put it back
+	loc, err := c.updateLoC(ctx, id, func(loc *LoC) (*Event, error) {
+		// only applicant bank can do it
+		err := c.authorize(ctx, loc, ActionCloseLoC)
+		if err != nil {
+			return nil, err
+		}
+		// check the LoC can move to the next status
+		transition, err := checkTransition(ctx, loc, ActionCloseLoC)
+		if err != nil {
+			return nil, err
+		}
+		// current status & status log
+		err = recordStatus(ctx, loc, transition.To, fmt.Sprintf("LoC closed by %s", loc.ApplicantBank))
+		if err != nil {
+			log.Println("error -> recordStatus -> CloseLoC\n", err)
+			return nil, err
+		}
+		// mark as inactive
+		loc.IsActive = false
+		return &Event{Name: "LoCClosed"}, nil
+	})
 	if err != nil {
-		log.Println("error -> ctx.GetStub.SetEvent -> ConfirmPayment\n", err)
-		return nil, fmt.Errorf("failed to set event: %v", err)
+		log.Println("error -> c.updateLoC -> CloseLoC\n", err)
+		return nil, err
 	}
 	return loc, nil
 }
 
 // -------------------------------------------------------------------------------------------------------------------------------------
-// AcknowledgePayment is done after payment_receive is checked by negotiating bank for given LoC, it updates status
-func (c *LocContract) AcknowledgePayment(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
-	// only negotiating bank can do it- check
-	// Get LoC if exists
-	loc, err := c.GetLoCById(ctx, id)
-	if err != nil {
-		log.Println("error -> c.GetLoCById -> AcknowledgePayment\n", err)
-		return nil, fmt.Errorf("LoC with Id@%s does not exist", id)
-	}
-	// current status
-	loc.CurrentStatus = "PAYMENT_ACKNOWLEDGED_FROM_APPLICANT_BANK_TO_NEGOTIATING_BANK"
-	// status log
-	current_time := GetTodaysDateTimeFormatted()
-	status := fmt.Sprintf("Payment acknowledged from %s to %s on %s", loc.ApplicantBank, loc.NegotiatingBank, current_time)
-	loc.StatusLog = append(loc.StatusLog, status)
-	// Marshal loc
-	locJSON, err := json.Marshal(loc)
-	if err != nil {
-		log.Println("error -> json.Marshal -> AcknowledgePayment\n", err)
-		return nil, fmt.Errorf("failed to marshal into Json: %v", err)
-	}
-	// Put on ledger
-	err = ctx.GetStub().PutState(loc.ID, locJSON)
-	if err != nil {
-		log.Println("error -> ctx.GetStub.PutState -> AcknowledgePayment\n", err)
-		return nil, fmt.Errorf("failed to put on ledger: %v", err)
+// GetLoCById returns the LoC stored in the channel with given {id}, if the invoking client may read it
+func (c *LocContract) GetLoCById(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+	// Get LoC if exists, others than parties to the LoC & regulators can not tell it apart from a missing LoC
+	loc, err := c.locs().Get(ctx, id)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		log.Println("error -> c.locs.Get -> GetLoCById\n", err)
+		return nil, fmt.Errorf("%v or access is forbidden", err)
 	}
-	// Emit the PaymentAcknowledged event
-	err = ctx.GetStub().SetEvent("PaymentAcknowledged", locJSON)
 	if err != nil {
-		log.Println("error -> ctx.GetStub.SetEvent -> AcknowledgePayment\n", err)
-		return nil, fmt.Errorf("failed to set event: %v", err)
+		log.Println("error -> c.locs.Get -> GetLoCById\n", err)
+		return nil, err
 	}
 	return loc, nil
 }
 
+// locs is the repository of LoCs, it only finds the LoCs the invoking client may read, with their private details if it
+// shares them, & puts private details in the private data collection, see putLoC. Its events are envelopes added to
+// the events of the transaction, see addEvents
+func (c *LocContract) locs() *Repository[LoC] {
+	describe := func(id string) string {
+		return fmt.Sprintf("the LoC with Id@%s", id)
+	}
+	return &Repository[LoC]{
+		Describe: describe,
+		Load: func(ctx contractapi.TransactionContextInterface, id string, loc *LoC) error {
+			ok, err := c.canRead(ctx, loc)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				log.Printf("the LoC with Id@%s is not readable by invoking client\n", id)
+				return &NotFoundError{Name: describe(id)}
+			}
+			return loadPrivateDetails(ctx, loc)
+		},
+		Store: func(ctx contractapi.TransactionContextInterface, id string, loc *LoC) error {
+			return putLoC(ctx, loc)
+		},
+		Emit: func(ctx contractapi.TransactionContextInterface, event *Event) error {
+			envelopes, ok := event.Payload.([]events.Envelope)
+			if !ok {
+				return fmt.Errorf("the %s event of an LoC must carry its envelopes, see updateLoC", event.Name)
+			}
+			return addEvents(ctx, envelopes...)
+		},
+	}
+}
+
 // -------------------------------------------------------------------------------------------------------------------------------------
-// CloseLoC closes the LoC with given {id}
-func (c *LocContract) CloseLoC(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
-	// only applicant bank can do it- check
-	// Get LoC Json if exists
+// GetLoCTimeline returns the status changes of the LoC with given {id}, oldest first
+func (c *LocContract) GetLoCTimeline(ctx contractapi.TransactionContextInterface, id string) ([]StatusEvent, error) {
+	// Get LoC if exists
 	loc, err := c.GetLoCById(ctx, id)
 	if err != nil {
-		log.Println("error -> c.GetLoCById -> CloseLoC\n", err)
-		return nil, fmt.Errorf("LoC with Id@%s does not exist", id)
-	}
-	// current status
-	loc.CurrentStatus = "CLOSED_BY_APPLICANT_BANK"
-	// status log
-	current_time := GetTodaysDateTimeFormatted()
-	status := fmt.Sprintf("LoC closed by %s on %s", loc.ApplicantBank, current_time)
-	loc.StatusLog = append(loc.StatusLog, status)
-	// mark as inactive
-	loc.IsActive = false
-	// Marshal loc
-	locJSON, err := json.Marshal(loc)
-	if err != nil {
-		log.Println("error -> json.Marshal -> CloseLoC\n", err)
-		return nil, fmt.Errorf("failed to marshal into Json: %v", err)
-	}
-	// Put on ledger
-	err = ctx.GetStub().PutState(loc.ID, locJSON)
-	if err != nil {
-		log.Println("error -> ctx.GetStub.PutState -> CloseLoC\n", err)
-		return nil, fmt.Errorf("failed to put on ledger: %v", err)
+		log.Println("error -> c.GetLoCById -> GetLoCTimeline\n", err)
+		return nil, err
 	}
-	// Emit the LoCClosed event
-	err = ctx.GetStub().SetEvent("LoCClosed", locJSON)
-	if err != nil {
-		log.Println("error -> ctx.GetStub.SetEvent -> CloseLoC\n", err)
-		return nil, fmt.Errorf("failed to set event: %v", err)
-	}
-	return loc, nil
+	return loc.StatusLog, nil
 }
 
 // -------------------------------------------------------------------------------------------------------------------------------------
-// GetLoCById returns the LoC stored in the channel with given {id}
-func (c *LocContract) GetLoCById(ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
-	// Get LoC Json if exists
-	locJSON, err := ctx.GetStub().GetState(id)
+// GetAllowedActions returns the actions that can be taken next on the LoC with given {id}
+func (c *LocContract) GetAllowedActions(ctx contractapi.TransactionContextInterface, id string) ([]Action, error) {
+	// Get LoC if exists
+	loc, err := c.GetLoCById(ctx, id)
 	if err != nil {
-		log.Println("error -> ctx.GetStub.GetState -> GetLoCById\n", err)
-		return nil, fmt.Errorf("failed to read from world state: %v", err)
-	}
-	if locJSON == nil {
-		log.Printf("the LoC with Id@%s does not exist\n", id)
-		return nil, fmt.Errorf("the LoC with Id@%s does not exist", id)
+		log.Println("error -> c.GetLoCById -> GetAllowedActions\n", err)
+		return nil, err
 	}
-	// Unmarshal and return LoC
-	var loc LoC
-	err = json.Unmarshal(locJSON, &loc)
+	expired, err := pastExpiry(ctx, loc)
 	if err != nil {
-		log.Println("error -> json.Unmarshal -> GetLoCById\n", err)
-		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+		log.Println("error -> pastExpiry -> GetAllowedActions\n", err)
+		return nil, err
 	}
-	return &loc, nil
+	// only actions the invoking client is authorized to take
+	actions := make([]Action, 0)
+	for _, action := range allowedActions(loc, expired) {
+		ok, err := c.permits(ctx, loc, action)
+		if err != nil {
+			log.Println("error -> c.permits -> GetAllowedActions\n", err)
+			return nil, err
+		}
+		if ok {
+			actions = append(actions, action)
+		}
+	}
+	return actions, nil
 }
 
 // -------------------------------------------------------------------------------------------------------------------------------------
 // GetIssuedLoCs returns issued LCs for org of invoking client
 func (c *LocContract) GetIssuedLoCs(ctx contractapi.TransactionContextInterface) ([]*LoC, error) {
 	org, _ := getOrgName(ctx)
-	// log.Println("org", org)
 	// Query string
-	// queryString := fmt.Sprintf(`{"selector":{"docType":"asset","owner":"%s"}}`, owner)
-	queryString := fmt.Sprintf(`{"selector":{"doc_type":"LoC","applicant_bank":"%s"}}`, org)
-	log.Println("queryString", queryString)
-	// Get result iterator
-	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	queryString, err := couchdb.NewQuery(couchdb.Eq("doc_type", "LoC"), couchdb.Eq("applicant_bank", org)).UseIndex("_design/indexLoCApplicantBankDoc", "indexLoCApplicantBank").Build()
 	if err != nil {
-		log.Println("error -> ctx.GetStub.GetQueryResult -> GetIssuedLoCs\n", err)
-		return nil, fmt.Errorf("failed to get query result: %v", err)
+		log.Println("error -> Build -> GetIssuedLoCs\n", err)
+		return nil, err
 	}
-	defer resultsIterator.Close()
-	var locs []*LoC
-	// Iterate the results, unmarshal & append to locs & return
-	for resultsIterator.HasNext() {
-		queryResult, err := resultsIterator.Next()
-		if err != nil {
-			log.Println("error -> resultsIterator.Next -> GetIssuedLoCs\n", err)
-			return nil, fmt.Errorf("failed to read from result iterator: %v", err)
-		}
-		var loc LoC
-		err = json.Unmarshal(queryResult.Value, &loc)
-		if err != nil {
-			log.Println("error -> json.Unmarshal -> GetIssuedLoCs\n", err)
-			return nil, fmt.Errorf("failed to unmarshal query result: %v", err)
-		}
-		locs = append(locs, &loc)
-	}
-	return locs, nil
+	log.Println("queryString", queryString)
+	return c.queryLoCs(ctx, queryString)
 }
 
 // -------------------------------------------------------------------------------------------------------------------------------------
@@ -492,31 +416,13 @@ func (c *LocContract) GetIssuedLoCs(ctx contractapi.TransactionContextInterface)
 func (c *LocContract) GetAdvisingLoCs(ctx contractapi.TransactionContextInterface) ([]*LoC, error) {
 	org, _ := getOrgName(ctx)
 	// Query string
-	queryString := fmt.Sprintf(`{"selector":{"doc_type":"LoC","advise_through_bank":"%s"}}`, org)
-	// Get result iterator
-	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	queryString, err := couchdb.NewQuery(couchdb.Eq("doc_type", "LoC"), couchdb.Eq("advise_through_bank", org)).UseIndex("_design/indexLoCAdvisingBankDoc", "indexLoCAdvisingBank").Build()
 	if err != nil {
-		log.Println("error -> ctx.GetStub.GetQueryResult -> GetAdvisingLoCs\n", err)
-		return nil, fmt.Errorf("failed to get query result: %v", err)
-	}
-	defer resultsIterator.Close()
-	var locs []*LoC
-	// Iterate the results, unmarshal & append to locs & return
-	for resultsIterator.HasNext() {
-		queryResult, err := resultsIterator.Next()
-		if err != nil {
-			log.Println("error -> resultsIterator.Next -> GetAdvisingLoCs\n", err)
-			return nil, fmt.Errorf("failed to read from result iterator: %v", err)
-		}
-		var loc LoC
-		err = json.Unmarshal(queryResult.Value, &loc)
-		if err != nil {
-			log.Println("error -> json.Unmarshal -> GetAdvisingLoCs\n", err)
-			return nil, fmt.Errorf("failed to unmarshal query result: %v", err)
-		}
-		locs = append(locs, &loc)
+		log.Println("error -> Build -> GetAdvisingLoCs\n", err)
+		return nil, err
 	}
-	return locs, nil
+	log.Println("queryString", queryString)
+	return c.queryLoCs(ctx, queryString)
 }
 
 // -------------------------------------------------------------------------------------------------------------------------------------
@@ -524,46 +430,57 @@ func (c *LocContract) GetAdvisingLoCs(ctx contractapi.TransactionContextInterfac
 func (c *LocContract) GetNegotiatingLoCs(ctx contractapi.TransactionContextInterface) ([]*LoC, error) {
 	org, _ := getOrgName(ctx)
 	// Query string
-	queryString := fmt.Sprintf(`{"selector":{"doc_type":"LoC","negotiating_bank":"%s"}}`, org)
-	// Get result iterator
-	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	queryString, err := couchdb.NewQuery(couchdb.Eq("doc_type", "LoC"), couchdb.Eq("negotiating_bank", org)).UseIndex("_design/indexLoCNegotiatingBankDoc", "indexLoCNegotiatingBank").Build()
 	if err != nil {
-		log.Println("error -> ctx.GetStub.GetQueryResult -> GetNegotiatingLoCs\n", err)
-		return nil, fmt.Errorf("failed to get query result: %v", err)
+		log.Println("error -> Build -> GetNegotiatingLoCs\n", err)
+		return nil, err
 	}
-	defer resultsIterator.Close()
-	var locs []*LoC
-	// Iterate the results, unmarshal & append to locs & return
-	for resultsIterator.HasNext() {
-		queryResult, err := resultsIterator.Next()
-		if err != nil {
-			log.Println("error -> resultsIterator.Next -> GetNegotiatingLoCs\n", err)
-			return nil, fmt.Errorf("failed to read from result iterator: %v", err)
-		}
-		var loc LoC
-		err = json.Unmarshal(queryResult.Value, &loc)
-		if err != nil {
-			log.Println("error -> json.Unmarshal -> GetNegotiatingLoCs\n", err)
-			return nil, fmt.Errorf("failed to unmarshal query result: %v", err)
-		}
-		locs = append(locs, &loc)
+	log.Println("queryString", queryString)
+	return c.queryLoCs(ctx, queryString)
+}
+
+// -------------------------------------------------------------------------------------------------------------------------------------
+// GetAllLoCs returns every LC the invoking client may read, all of them for a regulator
+func (c *LocContract) GetAllLoCs(ctx contractapi.TransactionContextInterface) ([]*LoC, error) {
+	regulator, err := c.isRegulator(ctx)
+	if err != nil {
+		log.Println("error -> c.isRegulator -> GetAllLoCs\n", err)
+		return nil, err
 	}
-	return locs, nil
+	// Query string
+	query := couchdb.NewQuery(couchdb.Eq("doc_type", "LoC"))
+	if !regulator {
+		org, _ := getOrgName(ctx)
+		query.Where(couchdb.Or(couchdb.Eq("applicant_bank", org), couchdb.Eq("advise_through_bank", org), couchdb.Eq("negotiating_bank", org), couchdb.Eq("reimbursing_bank", org)))
+	}
+	queryString, err := query.Build()
+	if err != nil {
+		log.Println("error -> Build -> GetAllLoCs\n", err)
+		return nil, err
+	}
+	return c.queryLoCs(ctx, queryString)
+}
+
+// queryLoCs runs a rich query & returns the resulting LCs the invoking client may read, with their private details if it shares them
+func (c *LocContract) queryLoCs(ctx contractapi.TransactionContextInterface, queryString string) ([]*LoC, error) {
+	entries, err := c.locs().Query(ctx, queryString)
+	if err != nil {
+		log.Println("error -> c.locs.Query -> queryLoCs\n", err)
+		return nil, err
+	}
+	return values(entries), nil
 }
 
 // -------------------------------------------------------------------------------------------------------------------------------------
 // InitLedger
 func (c *LocContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
 	// creating hard-coded first LC- test
-	locs := []*LoC{{ID: "INLCU0100220001", DocType: "LoC", DocumentaryCreditNumber: "INLCU0100220001", FormOfDocumentaryCredit: "IRREVOCABLE", DateOfIssue: "20220105", DateOfExpiry: "20220221", PlaceOfExpiry: "NEGOTIATION BANK COUNTER", ApplicantBank: "Org1", Applicant: "AMBER ENTERPRISES INDIA LTD, C-3, SITE-IV, UPSIDC IND. AREA, KASNA ROAD, GREATER NOIDA-201305, U.P, INDIA", Beneficiary: "POSCO INDIA PROCESSING CENTER PVT", CurrencyCode: "INR", Amount: 11436300, AvailableWithBy: "ANY BANK IN INDIA BY NEGOTIATION", DraftsAt: "90 DAYS FROM THE DATE OF BILL OF EXCHANGE", LoadingFrom: "ANYWHERE IN INDIA", TransportationTo: "ANYWHERE IN INDIA", DescriptionOfGoodsAndServices: "100 MT OF GI SHEET AS PER PI NO. POSCO-IHPL/PI/AEPL/JAN2022/01 DTD 04.01.2022, HS CODE:72104900, CIP, ANY WHERE IN INDIA, INCOTERMS 2020", DocumentsRequired: "1: BILL OF EXCHANGE WILL BE PRESENTED AFTER DEDUCTION OF TDS AT 0.1 PCT ON BASIC VALUE OF THE INVOICE. 2: TAX INVOICE IN ONE ORIGINAL. 3: ORIGINAL LORRY RECEIPT ISSUED BY NON IBA APPROVED TRANSPORTER CONSIGNED TO RBL BANK LTD NOTIFY APPLICANT AND MARKED FREIGHT PREPAID. 4.INSURANCE POLICY/CERTIFICATE IN THE CURRENCY OF THE CREDIT AND BLANK ENDORSED FOR CIP VALUE OF GOODS PLUS 10 PCT SHOWING CLAIMS PAYABLE IN INDIA IRRESPECTIVE OF PERCENTAGE. 5: INSURANCE TO COVER ALL RISKS FROM SUPPLIER WAREHOUSE TO APPLICANT WAREHOUSE.", Charges: "APPLICANT BANK CHARGES TO APPLICANT ACCOUNT AND BENEFICIARY ACCOUNT INCLUDING DISCREPANCY CHARGES TO BENEFICIARY ACCOUNT", PeriodForPresentation: "WITHIN 21 DAYS FROM THE DATE OF SHIPMENT BUT WITHIN THE VALIDITY OF THE LC.", ReimbursingBank: "Org1", InstructionsToThePayingOrAcceptingOrNegotiatingBank: "UPON SUBMISSION OF CREDIT COMPLIANT DOCUMENTS, WE WILL REIMBURSE YOU ON DUE DATE AS PER YOUR INSTRUCTIONS", AdviseThroughBank: "Org2", NegotiatingBank: "Org2", IsActive: true, CurrentStatus: "ISSUED_BY_APPLICANT_BANK", StatusLog: []string{"LoC issued by Org1 on Apr 11, 2022 at 11:46 AM"}, DocsUrls: []string{"https://bafybeidbwaneqilaaytdvwspd6f4mvashv6wbguqxsbawbp23sbz4ypjcy.ipfs.infura-ipfs.io"}}}
+	locs := []*LoC{{ID: "INLCU0100220001", DocType: "LoC", DocumentaryCreditNumber: "INLCU0100220001", FormOfDocumentaryCredit: "IRREVOCABLE", DateOfIssue: "20220105", DateOfExpiry: "20220221", PlaceOfExpiry: "NEGOTIATION BANK COUNTER", ApplicantBank: "Org1", Applicant: "AMBER ENTERPRISES INDIA LTD, C-3, SITE-IV, UPSIDC IND. AREA, KASNA ROAD, GREATER NOIDA-201305, U.P, INDIA", Beneficiary: "POSCO INDIA PROCESSING CENTER PVT", CurrencyCode: "INR", Amount: money.Money{Amount: "11436300.00", Currency: "INR"}, AvailableWithBy: "ANY BANK IN INDIA BY NEGOTIATION", DraftsAt: "90 DAYS FROM THE DATE OF BILL OF EXCHANGE", LoadingFrom: "ANYWHERE IN INDIA", TransportationTo: "ANYWHERE IN INDIA", DescriptionOfGoodsAndServices: "100 MT OF GI SHEET AS PER PI NO. POSCO-IHPL/PI/AEPL/JAN2022/01 DTD 04.01.2022, HS CODE:72104900, CIP, ANY WHERE IN INDIA, INCOTERMS 2020", DocumentsRequired: "1: BILL OF EXCHANGE WILL BE PRESENTED AFTER DEDUCTION OF TDS AT 0.1 PCT ON BASIC VALUE OF THE INVOICE. 2: TAX INVOICE IN ONE ORIGINAL. 3: ORIGINAL LORRY RECEIPT ISSUED BY NON IBA APPROVED TRANSPORTER CONSIGNED TO RBL BANK LTD NOTIFY APPLICANT AND MARKED FREIGHT PREPAID. 4.INSURANCE POLICY/CERTIFICATE IN THE CURRENCY OF THE CREDIT AND BLANK ENDORSED FOR CIP VALUE OF GOODS PLUS 10 PCT SHOWING CLAIMS PAYABLE IN INDIA IRRESPECTIVE OF PERCENTAGE. 5: INSURANCE TO COVER ALL RISKS FROM SUPPLIER WAREHOUSE TO APPLICANT WAREHOUSE.", Charges: "APPLICANT BANK CHARGES TO APPLICANT ACCOUNT AND BENEFICIARY ACCOUNT INCLUDING DISCREPANCY CHARGES TO BENEFICIARY ACCOUNT", PeriodForPresentation: "WITHIN 21 DAYS FROM THE DATE OF SHIPMENT BUT WITHIN THE VALIDITY OF THE LC.", ReimbursingBank: "Org1", InstructionsToThePayingOrAcceptingOrNegotiatingBank: "UPON SUBMISSION OF CREDIT COMPLIANT DOCUMENTS, WE WILL REIMBURSE YOU ON DUE DATE AS PER YOUR INSTRUCTIONS", AdviseThroughBank: "Org2", NegotiatingBank: "Org2", IsActive: true, CurrentStatus: StatusIssued, StatusLog: StatusLog{{FromStatus: StatusNone, ToStatus: StatusIssued, ActorMSP: "Org1MSP", Timestamp: "2022-04-11T11:46:00+05:30", Comment: "LoC issued by Org1"}}, DocsUrls: []string{"https://bafybeidbwaneqilaaytdvwspd6f4mvashv6wbguqxsbawbp23sbz4ypjcy.ipfs.infura-ipfs.io"}}}
+	// private details go to the collection of Org1 & Org2, so InitLedger has to be invoked by one of them
 	for _, loc := range locs {
-		locJSON, err := json.Marshal(loc)
-		if err != nil {
-			log.Println("error -> json.Marshal -> InitLedger\n", err)
-		}
-		err = ctx.GetStub().PutState(loc.ID, locJSON)
+		err := c.locs().Put(ctx, loc.ID, loc, nil)
 		if err != nil {
-			return fmt.Errorf("failed to put to world state. %v", err)
+			return err
 		}
 	}
 	return nil
@@ -0,0 +1,640 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-contract-api-go/metadata"
+	"github.com/hyperledger/fabric-contract-api-go/serializer"
+	"sample.com/lc/events"
+	"sample.com/lc/fabrictest"
+)
+
+// applicant is the private applicant of test LoCs, it must never show in the world state or events
+const applicant = "ACME IMPORTS PVT LTD"
+
+// digest is a well formed sha256 for test documents
+var digest = strings.Repeat("ab", 32)
+
+// ledger is a channel of one in-memory peer, its orgs & the contract under test
+type ledger struct {
+	t        *testing.T
+	stub     *fabrictest.Stub
+	contract *LocContract
+	orgs     map[string]*TransactionContext
+}
+
+// newLedger returns a channel of Org1, Org2, Org3 & the regulator Reg
+func newLedger(t *testing.T) *ledger {
+	t.Helper()
+	stub := fabrictest.NewStub()
+	l := &ledger{
+		t:        t,
+		stub:     stub,
+		contract: &LocContract{RegulatorMSPs: []string{"RegMSP"}},
+		orgs:     make(map[string]*TransactionContext),
+	}
+	for _, org := range []string{"Org1", "Org2", "Org3", "Reg"} {
+		l.orgs[org] = &TransactionContext{TransactionContext: *fabrictest.NewTransactionContext(stub, fabrictest.NewClientIdentity(org+"MSP", "user1", nil))}
+	}
+	return l
+}
+
+// testLoC returns the public Json of LoC {id} issued by Org1, advised & negotiated through Org2, with fields overridden
+func testLoC(id string, fields map[string]interface{}) string {
+	loc := map[string]interface{}{
+		"ID":                        id,
+		"documentary_credit_number": id,
+		"applicant_bank":            "Org1",
+		"beneficiary":               "POSCO INDIA PROCESSING CENTER PVT",
+		"currency_code":             "INR",
+		"date_of_issue":             "20220105",
+		"date_of_expiry":            "20220221",
+		"advise_through_bank":       "Org2",
+		"negotiating_bank":          "Org2",
+		"reimbursing_bank":          "Org1",
+	}
+	for name, value := range fields {
+		if value == nil {
+			delete(loc, name)
+			continue
+		}
+		loc[name] = value
+	}
+	locJSON, _ := json.Marshal(loc)
+	return string(locJSON)
+}
+
+// privateDetails is the transient private details of test LoCs, INR 1000.00
+func privateDetails() map[string]string {
+	return map[string]string{privateDetailsTransientKey: `{"amount":1000,"applicant":"` + applicant + `"}`}
+}
+
+// documents returns the Json of one document of type docType
+func documents(docType string) string {
+	return `[{"url":"https://docs.example.com/` + strings.ToLower(docType) + `","sha256":"` + digest + `","type":"` + docType + `"}]`
+}
+
+// begin starts the next transaction, an hour after the previous one unless at is set, with transient data
+func (l *ledger) begin(at time.Time, transient map[string]string) {
+	if at.IsZero() {
+		at = l.stub.TxTime.Add(time.Hour)
+	}
+	l.stub.StartTransaction(at)
+	for _, ctx := range l.orgs {
+		ctx.events = nil
+	}
+	if len(transient) > 0 {
+		l.stub.Transient = make(map[string][]byte)
+		for key, value := range transient {
+			l.stub.Transient[key] = []byte(value)
+		}
+	}
+}
+
+// issue issues the LoC of testLoC as Org1, it fails the test if it can not
+func (l *ledger) issue(id string, fields map[string]interface{}) *LoC {
+	l.t.Helper()
+	l.begin(time.Time{}, privateDetails())
+	loc, err := l.contract.IssueLoC(l.orgs["Org1"], testLoC(id, fields))
+	if err != nil {
+		l.t.Fatalf("IssueLoC(%s): %v", id, err)
+	}
+	l.commit("Org1")
+	return loc
+}
+
+// commit emits the events the transaction of org collected, like contractapi once the transaction succeeds,
+// & returns them
+func (l *ledger) commit(org string) []events.Envelope {
+	l.t.Helper()
+	if err := flushEvents(l.orgs[org]); err != nil {
+		l.t.Fatalf("flushEvents: %v", err)
+	}
+	event, ok := l.stub.LastEvent()
+	if !ok || event.TxID != l.stub.TxID {
+		return nil
+	}
+	batch, err := events.DecodeBatch(event.Payload)
+	if err != nil || event.Name != events.BatchName || batch.TxID != l.stub.TxID {
+		l.t.Fatalf("event %s = %s, %v", event.Name, event.Payload, err)
+	}
+	return batch.Events
+}
+
+// run takes steps on LoC {id}, it fails the test at the first step that does not end as expected
+func (l *ledger) run(id string, steps []step) {
+	l.t.Helper()
+	for i, s := range steps {
+		l.begin(s.at, s.transient)
+		loc, err := s.invoke(l.contract, l.orgs[s.org], id)
+		if err != nil {
+			l.t.Fatalf("step %d, %s: %v", i+1, s.org, err)
+		}
+		if loc.CurrentStatus != s.want {
+			l.t.Fatalf("step %d, %s: status = %s, want %s", i+1, s.org, loc.CurrentStatus, s.want)
+		}
+		// the events end in the status of the LoC
+		envelopes := l.commit(s.org)
+		if len(envelopes) == 0 || envelopes[0].Type != s.event || envelopes[len(envelopes)-1].ToStatus != string(s.want) {
+			l.t.Fatalf("step %d, %s: events = %+v, want %s ending in %s", i+1, s.org, envelopes, s.event, s.want)
+		}
+		if event, _ := l.stub.LastEvent(); strings.Contains(string(event.Payload), applicant) {
+			l.t.Fatalf("step %d, %s: event %s carries private details\n%s", i+1, s.org, event.Name, event.Payload)
+		}
+	}
+	if strings.Contains(string(l.stub.State()[id]), applicant) {
+		l.t.Fatalf("world state carries private details\n%s", l.stub.State()[id])
+	}
+}
+
+// invoke is a contract transaction on an LoC
+type invoke func(c *LocContract, ctx contractapi.TransactionContextInterface, id string) (*LoC, error)
+
+// withArg binds the second argument of a contract transaction
+func withArg(method func(*LocContract, contractapi.TransactionContextInterface, string, string) (*LoC, error), arg string) invoke {
+	return func(c *LocContract, ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+		return method(c, ctx, id, arg)
+	}
+}
+
+// expire runs ExpireLoCs & returns LoC {id} as it is afterwards
+func expire(c *LocContract, ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+	if _, err := c.ExpireLoCs(ctx, `["`+id+`"]`); err != nil {
+		return nil, err
+	}
+	return c.GetLoCById(ctx, id)
+}
+
+// step is one transaction of a flow, taken by org with transient data, the LoC must end in status want with event
+type step struct {
+	org       string
+	invoke    invoke
+	transient map[string]string
+	at        time.Time
+	want      LoCStatus
+	event     string
+}
+
+var (
+	acknowledgeIssuance  = invoke((*LocContract).AcknowledgeLoCIssuance)
+	amendAmount          = invoke((*LocContract).AmendLoCAmount)
+	acknowledgeAmendment = invoke((*LocContract).AcknowledgeLoCAmendment)
+	acceptDocuments      = invoke((*LocContract).AcceptDocuments)
+	requestWaiver        = invoke((*LocContract).RequestDiscrepancyWaiver)
+	waiveDiscrepancies   = invoke((*LocContract).WaiveDiscrepancies)
+	honourClaim          = invoke((*LocContract).HonourClaim)
+	confirmPayment       = invoke((*LocContract).ConfirmPayment)
+	acknowledgePayment   = invoke((*LocContract).AcknowledgePayment)
+	closeLoC             = invoke((*LocContract).CloseLoC)
+)
+
+// amountAmendment is the transient data of an AmendLoCAmount to amount
+func amountAmendment(amount string) map[string]string {
+	return map[string]string{amendmentTransientKey: `{"amount":"` + amount + `"}`}
+}
+
+// drawing is the transient data of a ConfirmPayment of amount
+func drawing(amount string) map[string]string {
+	return map[string]string{drawingTransientKey: `{"amount":"` + amount + `"}`}
+}
+
+func TestFlows(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]interface{}
+		steps  []step
+	}{
+		{
+			name: "documentary credit",
+			steps: []step{
+				{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+				{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+				{org: "Org1", invoke: acceptDocuments, want: StatusDocumentsAccepted, event: "DocumentsAccepted"},
+				{org: "Org1", invoke: confirmPayment, want: StatusPaymentDone, event: "PaymentConfirmed"},
+				{org: "Org2", invoke: acknowledgePayment, want: StatusPaymentAcknowledged, event: "PaymentAcknowledged"},
+				{org: "Org1", invoke: closeLoC, want: StatusClosed, event: "LoCClosed"},
+			},
+		},
+		{
+			name: "amendment acknowledged",
+			steps: []step{
+				{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+				{org: "Org1", invoke: withArg((*LocContract).ProposeLoCAmendment, `{"date_of_expiry":"20220301"}`), transient: map[string]string{amendmentTransientKey: `{"amount":"2000"}`}, want: StatusAmended, event: "LoCAmendmentProposed"},
+				{org: "Org2", invoke: acknowledgeAmendment, want: StatusAwaitingDocuments, event: "LoCAmendmentAcknowledged"},
+				{org: "Org1", invoke: amendAmount, transient: amountAmendment("3000"), want: StatusAmended, event: "LoCAmendmentProposed"},
+				{org: "Org2", invoke: withArg((*LocContract).RejectLoCAmendment, "beneficiary declines"), want: StatusAwaitingDocuments, event: "LoCAmendmentRejected"},
+				{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+			},
+		},
+		{
+			name: "discrepancies represented",
+			steps: []step{
+				{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+				{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+				{org: "Org1", invoke: withArg((*LocContract).RaiseDiscrepancies, `[{"code":"DOCUMENT_MISSING","description":"no bill of lading"}]`), want: StatusDiscrepanciesRaised, event: "DiscrepanciesRaised"},
+				{org: "Org2", invoke: withArg((*LocContract).RepresentDocuments, documents("BILL_OF_LADING")), want: StatusDocumentsSubmitted, event: "DocumentsRepresented"},
+				{org: "Org1", invoke: acceptDocuments, want: StatusDocumentsAccepted, event: "DocumentsAccepted"},
+			},
+		},
+		{
+			name: "discrepancies waived",
+			steps: []step{
+				{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+				{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+				{org: "Org1", invoke: withArg((*LocContract).RaiseDiscrepancies, `[{"code":"LATE_SHIPMENT","description":"shipped on 20220210"}]`), want: StatusDiscrepanciesRaised, event: "DiscrepanciesRaised"},
+				{org: "Org2", invoke: requestWaiver, want: StatusWaiverRequested, event: "DiscrepancyWaiverRequested"},
+				{org: "Org1", invoke: waiveDiscrepancies, want: StatusDocumentsAccepted, event: "DiscrepanciesWaived"},
+				{org: "Org1", invoke: confirmPayment, want: StatusPaymentDone, event: "PaymentConfirmed"},
+			},
+		},
+		{
+			name: "documents refused",
+			steps: []step{
+				{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+				{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+				{org: "Org1", invoke: withArg((*LocContract).RaiseDiscrepancies, `[{"code":"OTHER","description":"unsigned"}]`), want: StatusDiscrepanciesRaised, event: "DiscrepanciesRaised"},
+				{org: "Org2", invoke: requestWaiver, want: StatusWaiverRequested, event: "DiscrepancyWaiverRequested"},
+				{org: "Org1", invoke: withArg((*LocContract).RefuseDocuments, "applicant does not waive"), want: StatusAwaitingDocuments, event: "DocumentsRefused"},
+				{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+			},
+		},
+		{
+			name:   "partial drawings",
+			fields: map[string]interface{}{"tolerance_plus_percent": 10},
+			steps: []step{
+				{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+				{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+				{org: "Org1", invoke: acceptDocuments, want: StatusDocumentsAccepted, event: "DocumentsAccepted"},
+				{org: "Org1", invoke: confirmPayment, transient: drawing("600"), want: StatusPaymentDone, event: "PaymentConfirmed"},
+				{org: "Org2", invoke: acknowledgePayment, want: StatusPaymentAcknowledged, event: "PaymentAcknowledged"},
+				{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+				{org: "Org1", invoke: acceptDocuments, want: StatusDocumentsAccepted, event: "DocumentsAccepted"},
+				{org: "Org1", invoke: confirmPayment, transient: drawing("500"), want: StatusPaymentDone, event: "PaymentConfirmed"},
+				{org: "Org2", invoke: acknowledgePayment, want: StatusPaymentAcknowledged, event: "PaymentAcknowledged"},
+				{org: "Org1", invoke: closeLoC, want: StatusClosed, event: "LoCClosed"},
+			},
+		},
+		{
+			name:   "standby claim",
+			fields: map[string]interface{}{"form_of_documentary_credit": "IRREVOCABLE STANDBY"},
+			steps: []step{
+				{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+				{org: "Org2", invoke: withArg((*LocContract).SubmitClaim, documents(demandDocumentType)), want: StatusClaimSubmitted, event: "DocumentsSubmitted"},
+				{org: "Org1", invoke: withArg((*LocContract).RejectClaim, "demand not signed"), want: StatusAwaitingClaim, event: "DocumentsRefused"},
+				{org: "Org2", invoke: withArg((*LocContract).SubmitClaim, documents(demandDocumentType)), want: StatusClaimSubmitted, event: "DocumentsSubmitted"},
+				{org: "Org1", invoke: honourClaim, want: StatusClaimHonoured, event: "DocumentsAccepted"},
+				{org: "Org1", invoke: confirmPayment, want: StatusPaymentDone, event: "PaymentConfirmed"},
+				{org: "Org2", invoke: acknowledgePayment, want: StatusPaymentAcknowledged, event: "PaymentAcknowledged"},
+				{org: "Org1", invoke: closeLoC, want: StatusClosed, event: "LoCClosed"},
+			},
+		},
+		{
+			name:   "guarantee amended",
+			fields: map[string]interface{}{"instrument_type": InstrumentGuarantee},
+			steps: []step{
+				{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+				{org: "Org1", invoke: withArg((*LocContract).ProposeLoCAmendment, `{"date_of_expiry":"20220331"}`), want: StatusAmended, event: "LoCAmendmentProposed"},
+				{org: "Org2", invoke: acknowledgeAmendment, want: StatusAwaitingClaim, event: "LoCAmendmentAcknowledged"},
+				{org: "Org2", invoke: withArg((*LocContract).SubmitClaim, documents(demandDocumentType)), want: StatusClaimSubmitted, event: "DocumentsSubmitted"},
+				{org: "Org1", invoke: honourClaim, want: StatusClaimHonoured, event: "DocumentsAccepted"},
+				{org: "Org1", invoke: confirmPayment, want: StatusPaymentDone, event: "PaymentConfirmed"},
+			},
+		},
+		{
+			name: "expired",
+			steps: []step{
+				{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+				// the 22nd in Asia/Kolkata
+				{org: "Org2", invoke: expire, at: time.Date(2022, time.February, 21, 20, 0, 0, 0, time.UTC), want: StatusExpired, event: "LoCExpired"},
+				{org: "Org1", invoke: closeLoC, want: StatusClosed, event: "LoCClosed"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLedger(t)
+			l.issue("LC1", tt.fields)
+			l.run("LC1", tt.steps)
+		})
+	}
+}
+
+func TestIssueLoC(t *testing.T) {
+	l := newLedger(t)
+	loc := l.issue("LC1", map[string]interface{}{"date_of_issue": "2022-01-05"})
+	if loc.CurrentStatus != StatusIssued || !loc.IsActive || loc.DocType != "LoC" || loc.InstrumentType != InstrumentDocumentaryCredit {
+		t.Errorf("IssueLoC = %+v", loc)
+	}
+	if loc.DateOfIssue != "20220105" || loc.Amount.String() != "INR 1000.00" || loc.Applicant != applicant {
+		t.Errorf("IssueLoC did not normalize the LoC, date_of_issue %s, amount %s, applicant %s", loc.DateOfIssue, loc.Amount, loc.Applicant)
+	}
+	if len(loc.StatusLog) != 1 || loc.StatusLog[0].ToStatus != StatusIssued || loc.StatusLog[0].TxID != l.stub.TxID {
+		t.Errorf("status log = %+v", loc.StatusLog)
+	}
+	event, _ := l.stub.LastEvent()
+	batch, err := events.DecodeBatch(event.Payload)
+	if err != nil || len(batch.Events) != 1 {
+		t.Fatalf("event %s = %s, %v", event.Name, event.Payload, err)
+	}
+	issued := batch.Events[0]
+	if issued.Type != "LoCIssued" || issued.LoCID != "LC1" || issued.FromStatus != "" || issued.ToStatus != string(StatusIssued) {
+		t.Errorf("envelope %+v", issued)
+	}
+	if issued.ActorOrg != "Org1" || issued.TxID != l.stub.TxID || issued.SchemaVersion != events.SchemaVersion {
+		t.Errorf("envelope actor %s, tx %s, schema version %d", issued.ActorOrg, issued.TxID, issued.SchemaVersion)
+	}
+	// set fields are changed from null, private ones, unset ones & the status log are left out
+	if change := issued.Change("beneficiary"); change == nil || string(change.From) != "null" || string(change.To) != `"POSCO INDIA PROCESSING CENTER PVT"` {
+		t.Errorf("change of beneficiary = %+v", change)
+	}
+	for _, field := range []string{"applicant", "amount", "status_log", "current_status", "drafts_at"} {
+		if change := issued.Change(field); change != nil {
+			t.Errorf("envelope changes %s: %s -> %s", field, change.From, change.To)
+		}
+	}
+}
+
+func TestIssueLoCOwnFields(t *testing.T) {
+	// status, status log, counts & documents are the chaincode's, whatever the client sends
+	l := newLedger(t)
+	loc := l.issue("LC1", map[string]interface{}{
+		"doc_type": "Amendment", "current_status": StatusClosed, "is_active": false, "amendment_count": 3, "presentation_count": 2, "drawing_count": 1,
+		"status_log": []map[string]string{{"to_status": string(StatusPaymentDone)}}, "docs_urls": []string{"https://docs.example.com/forged"},
+	})
+	if loc.DocType != "LoC" || loc.CurrentStatus != StatusIssued || !loc.IsActive || len(loc.StatusLog) != 1 {
+		t.Errorf("IssueLoC kept doc_type %s, status %s, is_active %t, status log %+v", loc.DocType, loc.CurrentStatus, loc.IsActive, loc.StatusLog)
+	}
+	if loc.AmendmentCount != 0 || loc.PresentationCount != 0 || loc.DrawingCount != 0 || len(loc.DocsUrls) != 0 {
+		t.Errorf("IssueLoC kept counts %d, %d, %d & docs %v", loc.AmendmentCount, loc.PresentationCount, loc.DrawingCount, loc.DocsUrls)
+	}
+	// an Id is taken even for orgs that can not read the LoC under it
+	l.begin(time.Time{}, privateDetails())
+	_, err := l.contract.IssueLoC(l.orgs["Org3"], testLoC("LC1", map[string]interface{}{"applicant_bank": "Org3", "advise_through_bank": "Org1"}))
+	if err == nil || !strings.Contains(err.Error(), "the LoC with Id@LC1 already exists") {
+		t.Errorf("IssueLoC of a taken Id error = %v", err)
+	}
+	if stored, _ := l.contract.GetLoCById(l.orgs["Org1"], "LC1"); stored == nil || stored.ApplicantBank != "Org1" {
+		t.Errorf("LoC under a taken Id = %+v", stored)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    map[string]interface{} // of the LoC
+		setup     []step
+		at        time.Time // of the failing transaction, an hour after setup if zero
+		org       string
+		invoke    invoke
+		transient map[string]string
+		want      string // in the error
+		as        interface{}
+	}{
+		{
+			name: "unknown LoC",
+			org:  "Org2",
+			invoke: func(c *LocContract, ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+				return c.GetLoCById(ctx, "LC9")
+			},
+			want: "the LoC with Id@LC9 does not exist or access is forbidden",
+		},
+		{
+			name: "LoC of other orgs",
+			org:  "Org3",
+			invoke: func(c *LocContract, ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+				return c.GetLoCById(ctx, id)
+			},
+			want: "the LoC with Id@LC1 does not exist or access is forbidden",
+		},
+		{
+			name: "duplicate LoC",
+			org:  "Org1",
+			invoke: func(c *LocContract, ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+				return c.IssueLoC(ctx, testLoC(id, nil))
+			},
+			transient: privateDetails(),
+			want:      "the LoC with Id@LC1 already exists",
+		},
+		{
+			name: "issued by another bank",
+			org:  "Org2",
+			invoke: func(c *LocContract, ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+				return c.IssueLoC(ctx, testLoC("LC2", nil))
+			},
+			transient: privateDetails(),
+			want:      "client of org Org2 is not authorized to IssueLoC LoC with Id@LC2: requires APPLICANT_BANK Org1",
+		},
+		{
+			name: "private details in jsonLoC",
+			org:  "Org1",
+			invoke: func(c *LocContract, ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+				return c.IssueLoC(ctx, testLoC("LC2", map[string]interface{}{"amount": 1000}))
+			},
+			want: "pass them in transient field loc_private",
+		},
+		{
+			name: "unknown field",
+			org:  "Org1",
+			invoke: func(c *LocContract, ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+				return c.IssueLoC(ctx, testLoC("LC2", map[string]interface{}{"amout": 1000}))
+			},
+			transient: privateDetails(),
+			want:      `unknown field "amout"`,
+		},
+		{
+			name: "invalid LoC",
+			org:  "Org1",
+			invoke: func(c *LocContract, ctx contractapi.TransactionContextInterface, id string) (*LoC, error) {
+				return c.IssueLoC(ctx, testLoC("LC2", map[string]interface{}{"beneficiary": nil, "currency_code": "XYZ"}))
+			},
+			transient: privateDetails(),
+			want:      `beneficiary is required; currency_code "XYZ" is not an ISO 4217 currency code`,
+			as:        new(*ValidationError),
+		},
+		{
+			name:   "acknowledged by the applicant bank",
+			org:    "Org1",
+			invoke: acknowledgeIssuance,
+			want:   "client of org Org1 is not authorized to AcknowledgeLoCIssuance LoC with Id@LC1: requires ADVISING_BANK Org2",
+		},
+		{
+			name:   "closed before payment",
+			org:    "Org1",
+			invoke: closeLoC,
+			want:   `CloseLoC is not allowed from status "ISSUED_BY_APPLICANT_BANK"`,
+			as:     new(*TransitionError),
+		},
+		{
+			name:   "acknowledged twice",
+			setup:  []step{{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"}},
+			org:    "Org2",
+			invoke: acknowledgeIssuance,
+			as:     new(*TransitionError),
+		},
+		{
+			name:   "acknowledged after expiry",
+			at:     time.Date(2022, time.February, 22, 10, 0, 0, 0, time.UTC),
+			org:    "Org2",
+			invoke: acknowledgeIssuance,
+			want:   "LoC with Id@LC1 expired on 20220221: AcknowledgeLoCIssuance is not allowed, it can only be closed",
+			as:     new(*ExpiredError),
+		},
+		{
+			name:   "claim on a documentary credit",
+			setup:  []step{{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"}},
+			org:    "Org2",
+			invoke: withArg((*LocContract).SubmitClaim, documents(demandDocumentType)),
+			want:   "SubmitClaim does not apply to LoC with Id@LC1, it is a DOCUMENTARY_CREDIT",
+			as:     new(*InstrumentError),
+		},
+		{
+			name:   "document without sha256",
+			setup:  []step{{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"}},
+			org:    "Org2",
+			invoke: withArg((*LocContract).SubmitDocuments, `[{"url":"https://docs.example.com/invoice","sha256":"zz"}]`),
+			want:   "document 0: sha256 must be a hex encoded SHA-256 digest",
+			as:     new(*ValidationError),
+		},
+		{
+			name: "unknown discrepancy code",
+			setup: []step{
+				{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+				{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+			},
+			org:    "Org1",
+			invoke: withArg((*LocContract).RaiseDiscrepancies, `[{"code":"BAD_WEATHER"}]`),
+			want:   `discrepancy 0: code "BAD_WEATHER" is not one of`,
+		},
+		{
+			name:   "amendment before acknowledgement",
+			org:    "Org1",
+			invoke: withArg((*LocContract).ProposeLoCAmendment, `{"place_of_expiry":"MUMBAI"}`),
+			want:   `ProposeLoCAmendment is not allowed from status "ISSUED_BY_APPLICANT_BANK"`,
+			as:     new(*TransitionError),
+		},
+		{
+			name:   "private amendment in jsonChanges",
+			setup:  []step{{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"}},
+			org:    "Org1",
+			invoke: withArg((*LocContract).ProposeLoCAmendment, `{"amount":"2000"}`),
+			want:   "amount is private, pass its change in transient field loc_amendment",
+		},
+		{
+			name:      "amount amendment of other fields",
+			setup:     []step{{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"}},
+			org:       "Org1",
+			invoke:    amendAmount,
+			transient: map[string]string{amendmentTransientKey: `{"amount":"2000","place_of_expiry":"MUMBAI"}`},
+			want:      "loc_amendment must only hold the amount",
+		},
+		{
+			name:   "amendment of an unknown field",
+			setup:  []step{{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"}},
+			org:    "Org1",
+			invoke: withArg((*LocContract).ProposeLoCAmendment, `{"beneficiary":"OTHER"}`),
+			want:   "beneficiary can not be amended",
+			as:     new(*ValidationError),
+		},
+		{
+			name:   "amendment without change",
+			setup:  []step{{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"}},
+			org:    "Org1",
+			invoke: withArg((*LocContract).ProposeLoCAmendment, `{"date_of_expiry":"20220221"}`),
+			want:   `date_of_expiry is already "20220221"`,
+		},
+		{
+			name: "overdrawn",
+			setup: []step{
+				{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+				{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+				{org: "Org1", invoke: acceptDocuments, want: StatusDocumentsAccepted, event: "DocumentsAccepted"},
+			},
+			org:       "Org1",
+			invoke:    confirmPayment,
+			transient: drawing("1000.01"),
+			want:      "drawing of INR 1000.01 exceeds the available balance of INR 1000.00 of LoC with Id@LC1",
+		},
+		{
+			name:   "claim without demand",
+			fields: map[string]interface{}{"instrument_type": InstrumentStandby},
+			setup: []step{
+				{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+			},
+			org:    "Org2",
+			invoke: withArg((*LocContract).SubmitClaim, documents("STATEMENT_OF_DEFAULT")),
+			want:   "a claim must carry a document of type DEMAND",
+			as:     new(*ValidationError),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLedger(t)
+			l.issue("LC1", tt.fields)
+			l.run("LC1", tt.setup)
+			l.begin(tt.at, tt.transient)
+			before := l.stub.State()["LC1"]
+			_, err := tt.invoke(l.contract, l.orgs[tt.org], "LC1")
+			if err == nil {
+				t.Fatal("want an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+			if tt.as != nil && !errors.As(err, tt.as) {
+				t.Errorf("error = %T, want %T", err, tt.as)
+			}
+			if string(l.stub.State()["LC1"]) != string(before) {
+				t.Errorf("a failed transaction changed the LoC")
+			}
+		})
+	}
+}
+
+func TestNewChaincode(t *testing.T) {
+	if _, err := contractapi.NewChaincode(&LocContract{}); err != nil {
+		t.Fatalf("NewChaincode: %v", err)
+	}
+}
+
+func TestReturnsMatchMetadata(t *testing.T) {
+	// the contract api checks what transactions return against the schema of their type, optional fields included
+	l := newLedger(t)
+	l.issue("LC1", map[string]interface{}{"date_of_expiry": "20220106"})
+	l.run("LC1", []step{
+		{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+		{org: "Org2", invoke: withArg((*LocContract).SubmitDocuments, documents("INVOICE")), want: StatusDocumentsSubmitted, event: "DocumentsSubmitted"},
+	})
+	loc, _ := l.contract.GetLoCById(l.orgs["Org1"], "LC1")
+	balance, _ := l.contract.GetLoCBalance(l.orgs["Org1"], "LC1")
+	history, _ := l.contract.GetLoCHistory(l.orgs["Org1"], "LC1")
+	presentations, _ := l.contract.GetLoCPresentations(l.orgs["Org1"], "LC1")
+	l.begin(time.Date(2022, time.January, 7, 12, 0, 0, 0, time.UTC), nil)
+	expired, _ := l.contract.ExpireLoCs(l.orgs["Org1"], `["LC1"]`)
+	if len(expired) != 1 {
+		t.Fatalf("ExpireLoCs = %v", expired)
+	}
+	for _, value := range []interface{}{loc, publicLoC(loc), balance, history, presentations, expired} {
+		components := &metadata.ComponentMetadata{}
+		schema, err := metadata.GetSchema(reflect.TypeOf(value), components)
+		if err != nil {
+			t.Fatalf("GetSchema(%T): %v", value, err)
+		}
+		chaincode := metadata.ContractChaincodeMetadata{
+			Components: *components,
+			Contracts:  map[string]metadata.ContractMetadata{"LocContract": {Transactions: []metadata.TransactionMetadata{{Name: "Get", Returns: metadata.ReturnMetadata{Schema: schema}}}}},
+		}
+		if err := chaincode.CompileSchemas(); err != nil {
+			t.Fatalf("CompileSchemas(%T): %v", value, err)
+		}
+		returns := chaincode.Contracts["LocContract"].Transactions[0].Returns
+		if _, err := (&serializer.JSONSerializer{}).ToString(reflect.ValueOf(value), reflect.TypeOf(value), &returns, components); err != nil {
+			t.Errorf("%T does not match its metadata: %v", value, err)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package chaincode
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// LoCStatus is the lifecycle state of an LoC, stored in current_status
+type LoCStatus string
+
+const (
+	StatusNone                  LoCStatus = ""
+	StatusIssued                LoCStatus = "ISSUED_BY_APPLICANT_BANK"
+	StatusIssuanceAcknowledged  LoCStatus = "ISSUANCE_ACKNOWLEDGED_BY_ADVISING_BANK"
+	StatusAmended               LoCStatus = "AMENDED_BY_APPLICANT_BANK"
+	StatusAmendmentAcknowledged LoCStatus = "AMENDMENT_ACKNOWLEDGED_BY_ADVISING_BANK"
+	StatusAmendmentRejected     LoCStatus = "AMENDMENT_REJECTED_BY_ADVISING_BANK"
+	StatusAwaitingDocuments     LoCStatus = "AWAITING_DOCUMENTS"
+	StatusDocumentsSubmitted    LoCStatus = "DOCUMENTS_SUBMITTED_BY_NEGOTIATING_BANK"
+	StatusDocumentsAccepted     LoCStatus = "DOCUMENTS_ACCEPTED_BY_APPLICANT_BANK"
+	StatusDiscrepanciesRaised   LoCStatus = "DISCREPANCIES_RAISED_BY_APPLICANT_BANK"
+	StatusWaiverRequested       LoCStatus = "DISCREPANCY_WAIVER_REQUESTED_BY_NEGOTIATING_BANK"
+	StatusDiscrepanciesWaived   LoCStatus = "DISCREPANCIES_WAIVED_BY_APPLICANT_BANK"
+	StatusDocumentsRefused      LoCStatus = "DOCUMENTS_REFUSED_BY_APPLICANT_BANK"
+	StatusAwaitingClaim         LoCStatus = "AWAITING_CLAIM"
+	StatusClaimSubmitted        LoCStatus = "CLAIM_SUBMITTED_BY_NEGOTIATING_BANK"
+	StatusClaimHonoured         LoCStatus = "CLAIM_HONOURED_BY_APPLICANT_BANK"
+	StatusClaimRejected         LoCStatus = "CLAIM_REJECTED_BY_APPLICANT_BANK"
+	StatusPaymentDone           LoCStatus = "PAYMENT_DONE_FROM_APPLICANT_BANK_TO_NEGOTIATING_BANK"
+	StatusPaymentAcknowledged   LoCStatus = "PAYMENT_ACKNOWLEDGED_FROM_APPLICANT_BANK_TO_NEGOTIATING_BANK"
+	StatusClosed                LoCStatus = "CLOSED_BY_APPLICANT_BANK"
+	StatusExpired               LoCStatus = "EXPIRED"
+)
+
+// Action names a contract transaction that moves an LoC from one status to another
+type Action string
+
+const (
+	ActionIssueLoC                Action = "IssueLoC"
+	ActionAcknowledgeLoCIssuance  Action = "AcknowledgeLoCIssuance"
+	ActionProposeLoCAmendment     Action = "ProposeLoCAmendment"
+	ActionAmendLoCAmount          Action = "AmendLoCAmount"
+	ActionAcknowledgeLoCAmendment Action = "AcknowledgeLoCAmendment"
+	ActionRejectLoCAmendment      Action = "RejectLoCAmendment"
+	ActionSubmitDocuments         Action = "SubmitDocuments"
+	ActionAcceptDocuments         Action = "AcceptDocuments"
+	ActionRaiseDiscrepancies      Action = "RaiseDiscrepancies"
+	ActionRepresentDocuments      Action = "RepresentDocuments"
+	ActionRequestWaiver           Action = "RequestDiscrepancyWaiver"
+	ActionWaiveDiscrepancies      Action = "WaiveDiscrepancies"
+	ActionRefuseDocuments         Action = "RefuseDocuments"
+	ActionSubmitClaim             Action = "SubmitClaim"
+	ActionHonourClaim             Action = "HonourClaim"
+	ActionRejectClaim             Action = "RejectClaim"
+	ActionConfirmPayment          Action = "ConfirmPayment"
+	ActionAcknowledgePayment      Action = "AcknowledgePayment"
+	ActionCloseLoC                Action = "CloseLoC"
+	ActionExpireLoCs              Action = "ExpireLoCs"
+)
+
+// Transition describes who may take an action, the statuses it may be taken from and the status it leaves the LoC in.
+// Then is set for actions that pass through To and immediately settle in a follow-up status.
+type Transition struct {
+	Role Role
+	From []LoCStatus
+	To   LoCStatus
+	Then LoCStatus
+}
+
+// locTransitions is the state machine of documentary credits, see HAPPY FLOW in smartcontract.go
+var locTransitions = map[Action]Transition{
+	ActionIssueLoC: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusNone},
+		To:   StatusIssued,
+	},
+	ActionAcknowledgeLoCIssuance: {
+		Role: RoleAdvisingBank,
+		From: []LoCStatus{StatusIssued},
+		To:   StatusIssuanceAcknowledged,
+	},
+	ActionProposeLoCAmendment: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusIssuanceAcknowledged, StatusAwaitingDocuments},
+		To:   StatusAmended,
+	},
+	ActionAmendLoCAmount: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusIssuanceAcknowledged, StatusAwaitingDocuments},
+		To:   StatusAmended,
+	},
+	ActionAcknowledgeLoCAmendment: {
+		Role: RoleAdvisingBank,
+		From: []LoCStatus{StatusAmended},
+		To:   StatusAmendmentAcknowledged,
+		Then: StatusAwaitingDocuments,
+	},
+	ActionRejectLoCAmendment: {
+		Role: RoleAdvisingBank,
+		From: []LoCStatus{StatusAmended},
+		To:   StatusAmendmentRejected,
+		Then: StatusAwaitingDocuments,
+	},
+	ActionSubmitDocuments: {
+		Role: RoleNegotiatingBank,
+		// a paid presentation can be followed by the next one, for partial shipments
+		From: []LoCStatus{StatusIssuanceAcknowledged, StatusAwaitingDocuments, StatusPaymentAcknowledged},
+		To:   StatusDocumentsSubmitted,
+	},
+	ActionAcceptDocuments: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusDocumentsSubmitted},
+		To:   StatusDocumentsAccepted,
+	},
+	ActionRaiseDiscrepancies: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusDocumentsSubmitted},
+		To:   StatusDiscrepanciesRaised,
+	},
+	ActionRepresentDocuments: {
+		Role: RoleNegotiatingBank,
+		From: []LoCStatus{StatusDiscrepanciesRaised},
+		To:   StatusDocumentsSubmitted,
+	},
+	ActionRequestWaiver: {
+		Role: RoleNegotiatingBank,
+		From: []LoCStatus{StatusDiscrepanciesRaised},
+		To:   StatusWaiverRequested,
+	},
+	ActionWaiveDiscrepancies: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusWaiverRequested},
+		To:   StatusDiscrepanciesWaived,
+		Then: StatusDocumentsAccepted,
+	},
+	ActionRefuseDocuments: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusDiscrepanciesRaised, StatusWaiverRequested},
+		To:   StatusDocumentsRefused,
+		Then: StatusAwaitingDocuments,
+	},
+	ActionConfirmPayment: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusDocumentsAccepted},
+		To:   StatusPaymentDone,
+	},
+	ActionAcknowledgePayment: {
+		Role: RoleNegotiatingBank,
+		From: []LoCStatus{StatusPaymentDone},
+		To:   StatusPaymentAcknowledged,
+	},
+	ActionCloseLoC: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusPaymentAcknowledged, StatusExpired},
+		To:   StatusClosed,
+	},
+	ActionExpireLoCs: {
+		// any party to the LoC can sweep, see ExpireLoCs
+		From: []LoCStatus{
+			StatusIssued, StatusIssuanceAcknowledged, StatusAmended, StatusAmendmentAcknowledged, StatusAmendmentRejected,
+			StatusAwaitingDocuments, StatusDocumentsSubmitted, StatusDocumentsAccepted, StatusDiscrepanciesRaised,
+			StatusWaiverRequested, StatusDiscrepanciesWaived, StatusDocumentsRefused, StatusPaymentDone, StatusPaymentAcknowledged,
+		},
+		To: StatusExpired,
+	},
+}
+
+// claimTransitions is the state machine of standbys & guarantees, they are paid against a claim instead of documents, see claim.go
+var claimTransitions = map[Action]Transition{
+	ActionIssueLoC:               locTransitions[ActionIssueLoC],
+	ActionAcknowledgeLoCIssuance: locTransitions[ActionAcknowledgeLoCIssuance],
+	ActionProposeLoCAmendment: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusIssuanceAcknowledged, StatusAwaitingClaim},
+		To:   StatusAmended,
+	},
+	ActionAmendLoCAmount: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusIssuanceAcknowledged, StatusAwaitingClaim},
+		To:   StatusAmended,
+	},
+	ActionAcknowledgeLoCAmendment: {
+		Role: RoleAdvisingBank,
+		From: []LoCStatus{StatusAmended},
+		To:   StatusAmendmentAcknowledged,
+		Then: StatusAwaitingClaim,
+	},
+	ActionRejectLoCAmendment: {
+		Role: RoleAdvisingBank,
+		From: []LoCStatus{StatusAmended},
+		To:   StatusAmendmentRejected,
+		Then: StatusAwaitingClaim,
+	},
+	ActionSubmitClaim: {
+		Role: RoleNegotiatingBank,
+		// a paid claim can be followed by the next one, for partial drawings
+		From: []LoCStatus{StatusIssuanceAcknowledged, StatusAwaitingClaim, StatusPaymentAcknowledged},
+		To:   StatusClaimSubmitted,
+	},
+	ActionHonourClaim: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusClaimSubmitted},
+		To:   StatusClaimHonoured,
+	},
+	ActionRejectClaim: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusClaimSubmitted},
+		To:   StatusClaimRejected,
+		Then: StatusAwaitingClaim,
+	},
+	ActionConfirmPayment: {
+		Role: RoleApplicantBank,
+		From: []LoCStatus{StatusClaimHonoured},
+		To:   StatusPaymentDone,
+	},
+	ActionAcknowledgePayment: locTransitions[ActionAcknowledgePayment],
+	ActionCloseLoC:           locTransitions[ActionCloseLoC],
+	ActionExpireLoCs: {
+		From: []LoCStatus{
+			StatusIssued, StatusIssuanceAcknowledged, StatusAmended, StatusAmendmentAcknowledged, StatusAmendmentRejected,
+			StatusAwaitingClaim, StatusClaimSubmitted, StatusClaimHonoured, StatusClaimRejected, StatusPaymentDone, StatusPaymentAcknowledged,
+		},
+		To: StatusExpired,
+	},
+}
+
+// actionOrder fixes the order in which actions are reported, map iteration is not deterministic
+var actionOrder = []Action{
+	ActionIssueLoC,
+	ActionAcknowledgeLoCIssuance,
+	ActionProposeLoCAmendment,
+	ActionAmendLoCAmount,
+	ActionAcknowledgeLoCAmendment,
+	ActionRejectLoCAmendment,
+	ActionSubmitDocuments,
+	ActionAcceptDocuments,
+	ActionRaiseDiscrepancies,
+	ActionRepresentDocuments,
+	ActionRequestWaiver,
+	ActionWaiveDiscrepancies,
+	ActionRefuseDocuments,
+	ActionSubmitClaim,
+	ActionHonourClaim,
+	ActionRejectClaim,
+	ActionConfirmPayment,
+	ActionAcknowledgePayment,
+	ActionCloseLoC,
+	ActionExpireLoCs,
+}
+
+// TransitionError is returned when an action is attempted from a status that does not allow it
+type TransitionError struct {
+	ID        string
+	Action    Action
+	Current   LoCStatus
+	Attempted LoCStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("invalid transition for LoC with Id@%s: %s is not allowed from status %q to status %q", e.ID, e.Action, e.Current, e.Attempted)
+}
+
+// allows reports whether the transition can be taken from status
+func (t Transition) allows(status LoCStatus) bool {
+	for _, from := range t.From {
+		if from == status {
+			return true
+		}
+	}
+	return false
+}
+
+// survivesExpiry tells if the transition can still be taken once the LoC is past its date of expiry
+func (t Transition) survivesExpiry() bool {
+	return t.To == StatusClosed || t.To == StatusExpired
+}
+
+// checkTransition returns the transition for action if the instrument type of loc has it, it is allowed from the
+// current status of loc and, past the date of expiry of loc, only closure & expiry are
+func checkTransition(ctx contractapi.TransactionContextInterface, loc *LoC, action Action) (Transition, error) {
+	transition, ok := transitionsOf(loc)[action]
+	if !ok && isKnownAction(action) {
+		err := &InstrumentError{ID: loc.ID, Action: action, InstrumentType: instrumentOf(loc)}
+		log.Println("error -> checkTransition\n", err)
+		return Transition{}, err
+	}
+	if !ok {
+		return Transition{}, fmt.Errorf("unknown action %s", action)
+	}
+	if !transition.allows(loc.CurrentStatus) {
+		err := &TransitionError{ID: loc.ID, Action: action, Current: loc.CurrentStatus, Attempted: transition.To}
+		log.Println("error -> checkTransition\n", err)
+		return Transition{}, err
+	}
+	if transition.survivesExpiry() {
+		return transition, nil
+	}
+	// the LoC may not be swept yet, see ExpireLoCs
+	expired, err := pastExpiry(ctx, loc)
+	if err != nil {
+		return Transition{}, err
+	}
+	if expired {
+		err = &ExpiredError{ID: loc.ID, Action: action, DateOfExpiry: loc.DateOfExpiry}
+		log.Println("error -> checkTransition\n", err)
+		return Transition{}, err
+	}
+	return transition, nil
+}
+
+// allowedActions returns the actions of the instrument type of loc that can be taken from its current status,
+// closure & expiry only if expired
+func allowedActions(loc *LoC, expired bool) []Action {
+	actions := make([]Action, 0)
+	transitions := transitionsOf(loc)
+	for _, action := range actionOrder {
+		transition, ok := transitions[action]
+		if ok && transition.allows(loc.CurrentStatus) && (!expired || transition.survivesExpiry()) {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
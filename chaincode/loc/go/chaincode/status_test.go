@@ -0,0 +1,128 @@
+package chaincode
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"sample.com/lc/fabrictest"
+)
+
+// statuses are every status an LoC can be in
+var statuses = []LoCStatus{
+	StatusNone, StatusIssued, StatusIssuanceAcknowledged, StatusAmended, StatusAmendmentAcknowledged, StatusAmendmentRejected,
+	StatusAwaitingDocuments, StatusDocumentsSubmitted, StatusDocumentsAccepted, StatusDiscrepanciesRaised, StatusWaiverRequested,
+	StatusDiscrepanciesWaived, StatusDocumentsRefused, StatusAwaitingClaim, StatusClaimSubmitted, StatusClaimHonoured,
+	StatusClaimRejected, StatusPaymentDone, StatusPaymentAcknowledged, StatusClosed, StatusExpired,
+}
+
+func TestCheckTransition(t *testing.T) {
+	stub := fabrictest.NewStub()
+	ctx := fabrictest.NewTransactionContext(stub, fabrictest.NewClientIdentity("Org1MSP", "user1", nil))
+	for instrumentType, instrument := range instruments {
+		for _, action := range actionOrder {
+			transition, known := instrument.transitions[action]
+			for _, status := range statuses {
+				loc := &LoC{ID: "LC1", InstrumentType: instrumentType, CurrentStatus: status, DateOfExpiry: "20220221"}
+				name := string(instrumentType) + "/" + string(action) + "/" + string(status)
+				// before expiry
+				stub.StartTransaction(time.Date(2022, time.February, 21, 10, 0, 0, 0, time.UTC))
+				got, err := checkTransition(ctx, loc, action)
+				switch {
+				case !known:
+					var ierr *InstrumentError
+					if !errors.As(err, &ierr) || ierr.InstrumentType != instrumentType {
+						t.Errorf("%s: error = %v, want InstrumentError", name, err)
+					}
+				case transition.allows(status):
+					if err != nil || !reflect.DeepEqual(got, transition) {
+						t.Errorf("%s: checkTransition = %+v, %v, want %+v", name, got, err, transition)
+					}
+				default:
+					var terr *TransitionError
+					if !errors.As(err, &terr) || terr.Current != status || terr.Attempted != transition.To {
+						t.Errorf("%s: error = %v, want TransitionError", name, err)
+					}
+				}
+				if !known || !transition.allows(status) {
+					continue
+				}
+				// the 22nd in Asia/Kolkata, past expiry
+				stub.StartTransaction(time.Date(2022, time.February, 21, 20, 0, 0, 0, time.UTC))
+				_, err = checkTransition(ctx, loc, action)
+				var eerr *ExpiredError
+				if transition.survivesExpiry() && err != nil {
+					t.Errorf("%s: past expiry error = %v, want none", name, err)
+				} else if !transition.survivesExpiry() && !errors.As(err, &eerr) {
+					t.Errorf("%s: past expiry error = %v, want ExpiredError", name, err)
+				}
+			}
+		}
+	}
+	if _, err := checkTransition(ctx, &LoC{ID: "LC1"}, Action("DeleteLoC")); err == nil || err.Error() != "unknown action DeleteLoC" {
+		t.Errorf("checkTransition(DeleteLoC) error = %v", err)
+	}
+}
+
+func TestTransitionsSettle(t *testing.T) {
+	// every status an action leaves an LoC in allows another action, but closure
+	for instrumentType, instrument := range instruments {
+		for action, transition := range instrument.transitions {
+			settled := transition.To
+			if transition.Then != StatusNone {
+				settled = transition.Then
+			}
+			loc := &LoC{InstrumentType: instrumentType, CurrentStatus: settled}
+			if settled != StatusClosed && len(allowedActions(loc, false)) == 0 {
+				t.Errorf("%s/%s: no action is allowed from %s", instrumentType, action, settled)
+			}
+		}
+	}
+}
+
+func TestAllowedActions(t *testing.T) {
+	tests := []struct {
+		instrumentType InstrumentType
+		status         LoCStatus
+		expired        bool
+		want           []Action
+	}{
+		{"", StatusIssued, false, []Action{ActionAcknowledgeLoCIssuance, ActionExpireLoCs}},
+		{InstrumentDocumentaryCredit, StatusIssuanceAcknowledged, false, []Action{ActionProposeLoCAmendment, ActionAmendLoCAmount, ActionSubmitDocuments, ActionExpireLoCs}},
+		{InstrumentDocumentaryCredit, StatusDiscrepanciesRaised, false, []Action{ActionRepresentDocuments, ActionRequestWaiver, ActionRefuseDocuments, ActionExpireLoCs}},
+		{InstrumentDocumentaryCredit, StatusPaymentAcknowledged, false, []Action{ActionSubmitDocuments, ActionCloseLoC, ActionExpireLoCs}},
+		{InstrumentDocumentaryCredit, StatusPaymentAcknowledged, true, []Action{ActionCloseLoC, ActionExpireLoCs}},
+		{InstrumentDocumentaryCredit, StatusExpired, true, []Action{ActionCloseLoC}},
+		{InstrumentStandby, StatusIssuanceAcknowledged, false, []Action{ActionProposeLoCAmendment, ActionAmendLoCAmount, ActionSubmitClaim, ActionExpireLoCs}},
+		{InstrumentGuarantee, StatusClaimSubmitted, false, []Action{ActionHonourClaim, ActionRejectClaim, ActionExpireLoCs}},
+		{InstrumentGuarantee, StatusClosed, false, []Action{}},
+	}
+	for _, tt := range tests {
+		loc := &LoC{InstrumentType: tt.instrumentType, CurrentStatus: tt.status}
+		if got := allowedActions(loc, tt.expired); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("allowedActions(%s %s, expired %t) = %v, want %v", tt.instrumentType, tt.status, tt.expired, got, tt.want)
+		}
+	}
+}
+
+func TestGetAllowedActions(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", nil)
+	tests := []struct {
+		org  string
+		want []Action
+	}{
+		{"Org1", []Action{}},
+		{"Org2", []Action{ActionAcknowledgeLoCIssuance}},
+	}
+	for _, tt := range tests {
+		got, err := l.contract.GetAllowedActions(l.orgs[tt.org], "LC1")
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetAllowedActions as %s = %v, %v, want %v", tt.org, got, err, tt.want)
+		}
+	}
+	if _, err := l.contract.GetAllowedActions(l.orgs["Org3"], "LC1"); err == nil {
+		t.Errorf("GetAllowedActions as Org3 reads an LoC Org3 is not a party to")
+	}
+}
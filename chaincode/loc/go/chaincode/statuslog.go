@@ -0,0 +1,100 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// StatusEvent records one status change of an LoC, who made it & in which transaction
+type StatusEvent struct {
+	FromStatus LoCStatus `json:"from_status"`
+	ToStatus   LoCStatus `json:"to_status"`
+	ActorMSP   string    `json:"actor_msp"`
+	ClientID   string    `json:"client_id"`
+	TxID       string    `json:"tx_id"`
+	Timestamp  string    `json:"timestamp"` // transaction timestamp, RFC 3339
+	Comment    string    `json:"comment,omitempty" metadata:",optional"`
+}
+
+// StatusLog is the timeline of an LoC, oldest event first
+type StatusLog []StatusEvent
+
+// UnmarshalJSON reads both StatusEvent records and the plain sentences LoCs were logged with before,
+// eg. "LoC issued by Org1 on Apr 11, 2022 at 11:46 AM", which are kept as the comment of an otherwise empty event
+func (l *StatusLog) UnmarshalJSON(data []byte) error {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	events := make(StatusLog, 0, len(entries))
+	for _, entry := range entries {
+		var event StatusEvent
+		if trimmed := bytes.TrimSpace(entry); len(trimmed) > 0 && trimmed[0] == '"' {
+			if err := json.Unmarshal(trimmed, &event.Comment); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal(entry, &event); err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+	*l = events
+	return nil
+}
+
+// actorStamp identifies who made a change & in which transaction
+type actorStamp struct {
+	mspID     string
+	clientID  string
+	txID      string
+	timestamp string
+}
+
+// stampActor returns the stamp of the submitting client in the current transaction
+func stampActor(ctx contractapi.TransactionContextInterface) (actorStamp, error) {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return actorStamp{}, fmt.Errorf("failed to get verified MSPID: %v", err)
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return actorStamp{}, fmt.Errorf("failed to get client identity: %v", err)
+	}
+	txTime, err := GetTxTime(ctx)
+	if err != nil {
+		return actorStamp{}, err
+	}
+	return actorStamp{mspID: clientMSPID, clientID: clientID, txID: ctx.GetStub().GetTxID(), timestamp: txTime.Format(time.RFC3339)}, nil
+}
+
+// newStatusEvent builds the event for a status change made by the submitting client in the current transaction
+func newStatusEvent(ctx contractapi.TransactionContextInterface, from LoCStatus, to LoCStatus, comment string) (StatusEvent, error) {
+	stamp, err := stampActor(ctx)
+	if err != nil {
+		return StatusEvent{}, err
+	}
+	return StatusEvent{
+		FromStatus: from,
+		ToStatus:   to,
+		ActorMSP:   stamp.mspID,
+		ClientID:   stamp.clientID,
+		TxID:       stamp.txID,
+		Timestamp:  stamp.timestamp,
+		Comment:    comment,
+	}, nil
+}
+
+// recordStatus moves loc to status & appends the change to its status log
+func recordStatus(ctx contractapi.TransactionContextInterface, loc *LoC, status LoCStatus, comment string) error {
+	event, err := newStatusEvent(ctx, loc.CurrentStatus, status, comment)
+	if err != nil {
+		return err
+	}
+	loc.StatusLog = append(loc.StatusLog, event)
+	loc.CurrentStatus = status
+	return nil
+}
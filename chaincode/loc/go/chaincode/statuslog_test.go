@@ -0,0 +1,65 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStatusLogUnmarshalJSON(t *testing.T) {
+	// LoCs logged plain sentences before status events
+	data := `["LoC issued by Org1 on Apr 11, 2022 at 11:46 AM",` +
+		`{"from_status":"ISSUED_BY_APPLICANT_BANK","to_status":"ISSUANCE_ACKNOWLEDGED_BY_ADVISING_BANK","actor_msp":"Org2MSP","tx_id":"tx2"}]`
+	var statusLog StatusLog
+	if err := json.Unmarshal([]byte(data), &statusLog); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := StatusLog{
+		{Comment: "LoC issued by Org1 on Apr 11, 2022 at 11:46 AM"},
+		{FromStatus: StatusIssued, ToStatus: StatusIssuanceAcknowledged, ActorMSP: "Org2MSP", TxID: "tx2"},
+	}
+	if !reflect.DeepEqual(statusLog, want) {
+		t.Errorf("Unmarshal = %+v, want %+v", statusLog, want)
+	}
+	for _, data := range []string{`{"to_status":"EXPIRED"}`, `[1]`} {
+		if err := json.Unmarshal([]byte(data), &statusLog); err == nil {
+			t.Errorf("Unmarshal(%s) = %+v, want an error", data, statusLog)
+		}
+	}
+}
+
+func TestGetLoCTimeline(t *testing.T) {
+	l := newLedger(t)
+	l.issue("LC1", nil)
+	l.run("LC1", []step{
+		{org: "Org2", invoke: acknowledgeIssuance, want: StatusIssuanceAcknowledged, event: "LoCIssuanceAcknowledged"},
+		{org: "Org1", invoke: withArg((*LocContract).ProposeLoCAmendment, `{"date_of_expiry":"20220301"}`), want: StatusAmended, event: "LoCAmendmentProposed"},
+		{org: "Org2", invoke: acknowledgeAmendment, want: StatusAwaitingDocuments, event: "LoCAmendmentAcknowledged"},
+	})
+	timeline, err := l.contract.GetLoCTimeline(l.orgs["Org2"], "LC1")
+	if err != nil {
+		t.Fatalf("GetLoCTimeline: %v", err)
+	}
+	// an acknowledged amendment passes through AMENDMENT_ACKNOWLEDGED in the same transaction
+	want := []struct {
+		from, to LoCStatus
+		actor    string
+	}{
+		{StatusNone, StatusIssued, "Org1MSP"},
+		{StatusIssued, StatusIssuanceAcknowledged, "Org2MSP"},
+		{StatusIssuanceAcknowledged, StatusAmended, "Org1MSP"},
+		{StatusAmended, StatusAmendmentAcknowledged, "Org2MSP"},
+		{StatusAmendmentAcknowledged, StatusAwaitingDocuments, "Org2MSP"},
+	}
+	if len(timeline) != len(want) {
+		t.Fatalf("GetLoCTimeline = %+v, want %d events", timeline, len(want))
+	}
+	for i, event := range timeline {
+		if event.FromStatus != want[i].from || event.ToStatus != want[i].to || event.ActorMSP != want[i].actor || event.ClientID == "" || event.TxID == "" {
+			t.Errorf("event %d = %+v, want %s -> %s by %s", i, event, want[i].from, want[i].to, want[i].actor)
+		}
+	}
+	if timeline[3].TxID != timeline[4].TxID || timeline[3].TxID != l.stub.TxID || timeline[2].TxID == l.stub.TxID {
+		t.Errorf("tx ids %s, %s, %s, want the last two of transaction %s", timeline[2].TxID, timeline[3].TxID, timeline[4].TxID, l.stub.TxID)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
 	"time"
+	_ "time/tzdata" // chaincode images may ship without a timezone database
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
@@ -32,32 +34,66 @@ func GetSHA256HashBase64String(data interface{}) string {
 	return base64.URLEncoding.EncodeToString(dataHash[:])
 }
 
-// Get current TimeStamp -> local
-func GetTimeStamp() string {
-	loc, _ := time.LoadLocation("Asia/Kolkata")
-	current_time := time.Now().In(loc)
-	return current_time.Format("20060102150405")
+// GetSHA256HexOfBytes returns the hex of the sha256 hash of data as is, unlike GetSHA256HashHexString which hashes its %v rendering
+func GetSHA256HexOfBytes(data []byte) string {
+	dataHash := sha256.Sum256(data)
+	return hex.EncodeToString(dataHash[:])
+}
+
+// timeLocation is the timezone LoC timestamps are rendered in, see SetTimeZone
+var timeLocation, _ = time.LoadLocation("Asia/Kolkata")
+
+// SetTimeZone changes the timezone LoC timestamps are rendered in, eg. "Asia/Kolkata" or "UTC".
+// It has to be the same on every endorsing peer, else endorsements will not match.
+func SetTimeZone(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("failed to load timezone %s: %v", name, err)
+	}
+	timeLocation = loc
+	return nil
+}
+
+// GetTxTime returns the timestamp of the transaction in the configured timezone.
+// Unlike wall-clock time it is set by the submitting client, so every endorsing peer sees the same value.
+func GetTxTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	if txTimestamp == nil {
+		return time.Time{}, fmt.Errorf("failed to get transaction timestamp: not set")
+	}
+	return time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).In(timeLocation), nil
+}
+
+// formatTxTime renders the transaction timestamp with given layout
+func formatTxTime(ctx contractapi.TransactionContextInterface, layout string) (string, error) {
+	txTime, err := GetTxTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	return txTime.Format(layout), nil
 }
 
-// Get Today's Date -> local
-func GetTodaysDate() string {
-	loc, _ := time.LoadLocation("Asia/Kolkata")
-	current_time := time.Now().In(loc)
-	return current_time.Format("2006-01-02")
+// Get TimeStamp of transaction -> local
+func GetTimeStamp(ctx contractapi.TransactionContextInterface) (string, error) {
+	return formatTxTime(ctx, "20060102150405")
 }
 
-// Get Today's Date & Time -> local
-func GetTodaysDateTime() string {
-	loc, _ := time.LoadLocation("Asia/Kolkata")
-	current_time := time.Now().In(loc)
-	return current_time.Format("2006-01-02 15:04:05")
+// Get Date of transaction -> local
+func GetTodaysDate(ctx contractapi.TransactionContextInterface) (string, error) {
+	return formatTxTime(ctx, "2006-01-02")
 }
 
-// Get Today's Date & Time Formatted -> local
-func GetTodaysDateTimeFormatted() string {
-	loc, _ := time.LoadLocation("Asia/Kolkata")
-	current_time := time.Now().In(loc)
-	return current_time.Format("Jan 2, 2006 at 3:04 PM")
+// Get Date & Time of transaction -> local
+func GetTodaysDateTime(ctx contractapi.TransactionContextInterface) (string, error) {
+	return formatTxTime(ctx, "2006-01-02 15:04:05")
+}
+
+// Get Date & Time of transaction Formatted -> local
+func GetTodaysDateTimeFormatted(ctx contractapi.TransactionContextInterface) (string, error) {
+	return formatTxTime(ctx, "Jan 2, 2006 at 3:04 PM")
 }
 
 // getOrgName is an internal helper function to get bank/org name from submitting client identity.
@@ -74,3 +110,29 @@ func getOrgName(ctx contractapi.TransactionContextInterface) (string, error) {
 	// org := slice[0]
 	return org, nil
 }
+
+// putJSON marshals value & puts it on the ledger under key, returns the Json put
+func putJSON(ctx contractapi.TransactionContextInterface, key string, value interface{}) ([]byte, error) {
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal into Json: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, valueJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to put on ledger: %v", err)
+	}
+	return valueJSON, nil
+}
+
+// setJSONEvent marshals payload & emits it as the event of the transaction
+func setJSONEvent(ctx contractapi.TransactionContextInterface, name string, payload interface{}) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal into Json: %v", err)
+	}
+	err = ctx.GetStub().SetEvent(name, payloadJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set event: %v", err)
+	}
+	return nil
+}
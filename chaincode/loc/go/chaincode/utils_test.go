@@ -0,0 +1,51 @@
+package chaincode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/fabrictest"
+)
+
+func TestTxTime(t *testing.T) {
+	stub := fabrictest.NewStub()
+	ctx := fabrictest.NewTransactionContext(stub, fabrictest.NewClientIdentity("Org1MSP", "user1", nil))
+	stub.StartTransaction(time.Date(2022, time.April, 11, 6, 16, 0, 0, time.UTC))
+	// timestamps are the transaction's, rendered in Asia/Kolkata, whatever the clock of the peer says
+	tests := []struct {
+		name   string
+		format func(ctx contractapi.TransactionContextInterface) (string, error)
+		want   string
+	}{
+		{"GetTimeStamp", GetTimeStamp, "20220411114600"},
+		{"GetTodaysDate", GetTodaysDate, "2022-04-11"},
+		{"GetTodaysDateTime", GetTodaysDateTime, "2022-04-11 11:46:00"},
+		{"GetTodaysDateTimeFormatted", GetTodaysDateTimeFormatted, "Apr 11, 2022 at 11:46 AM"},
+	}
+	for _, tt := range tests {
+		if got, err := tt.format(ctx); err != nil || got != tt.want {
+			t.Errorf("%s = %s, %v, want %s", tt.name, got, err, tt.want)
+		}
+	}
+	txTime, err := GetTxTime(ctx)
+	if err != nil || !txTime.Equal(stub.TxTime) || txTime.Location().String() != "Asia/Kolkata" {
+		t.Errorf("GetTxTime = %v, %v", txTime, err)
+	}
+	// the status log records the transaction timestamp too
+	loc := &LoC{CurrentStatus: StatusNone}
+	if err := recordStatus(ctx, loc, StatusIssued, ""); err != nil || loc.StatusLog[0].Timestamp != "2022-04-11T11:46:00+05:30" {
+		t.Errorf("recordStatus = %+v, %v", loc.StatusLog, err)
+	}
+
+	if err := SetTimeZone("Mars/Olympus_Mons"); err == nil {
+		t.Errorf("SetTimeZone of an unknown timezone")
+	}
+	defer SetTimeZone("Asia/Kolkata")
+	if err := SetTimeZone("UTC"); err != nil {
+		t.Fatalf("SetTimeZone: %v", err)
+	}
+	if got, err := GetTodaysDateTime(ctx); err != nil || got != "2022-04-11 06:16:00" {
+		t.Errorf("GetTodaysDateTime in UTC = %s, %v", got, err)
+	}
+}
@@ -0,0 +1,143 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoCDateLayouts are the accepted formats for LoC dates, the first one is the format dates are stored in.
+// SWIFT style YYMMDD is widened to YYYYMMDD.
+var LoCDateLayouts = []string{"20060102", "2006-01-02"}
+
+// ValidationError aggregates every violation found while validating an LoC
+type ValidationError struct {
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid LoC: %s", strings.Join(e.Violations, "; "))
+}
+
+// add records a violation
+func (e *ValidationError) add(format string, args ...interface{}) {
+	e.Violations = append(e.Violations, fmt.Sprintf(format, args...))
+}
+
+// orNil returns e if any violation was recorded, nil otherwise
+func (e *ValidationError) orNil() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
+
+// parseLoCDate parses an LoC date in any of the accepted layouts
+func parseLoCDate(value string) (time.Time, error) {
+	for _, layout := range LoCDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date in format YYYYMMDD or YYYY-MM-DD", value)
+}
+
+// normalizeLoCDates rewrites the dates of a validated LoC as YYYYMMDD, so date ranges can be queried on stored strings
+func normalizeLoCDates(loc *LoC) {
+	for _, field := range []*string{&loc.DateOfIssue, &loc.DateOfExpiry, &loc.LatestDateOfShipment} {
+		if date, err := parseLoCDate(*field); err == nil {
+			*field = date.Format(LoCDateLayouts[0])
+		}
+	}
+}
+
+// normalizeLoCAmount gives a bare amount, as LoCs were stored with before amounts carried their currency,
+// the currency of loc. Amounts were whole units of the currency then, eg. 11436300 for INR 11436300.00.
+func normalizeLoCAmount(loc *LoC) {
+	if amount, err := loc.Amount.WithCurrency(loc.CurrencyCode); err == nil {
+		loc.Amount = amount
+	}
+}
+
+// decodeStrict un-marshals jsonData into v, rejecting unknown fields & trailing data
+func decodeStrict(jsonData string, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(jsonData)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("unexpected data after JSON value")
+	}
+	return nil
+}
+
+// validateLoC checks the fields an LoC must carry to be issued & returns all violations at once
+func validateLoC(loc *LoC) error {
+	verr := &ValidationError{}
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"ID", loc.ID},
+		{"documentary_credit_number", loc.DocumentaryCreditNumber},
+		{"applicant_bank", loc.ApplicantBank},
+		{"beneficiary", loc.Beneficiary},
+		{"currency_code", loc.CurrencyCode},
+		{"date_of_issue", loc.DateOfIssue},
+		{"date_of_expiry", loc.DateOfExpiry},
+	}
+	for _, field := range required {
+		if strings.TrimSpace(field.value) == "" {
+			verr.add("%s is required", field.name)
+		}
+	}
+	if loc.CurrencyCode != "" && !isISO4217Currency(loc.CurrencyCode) {
+		verr.add("currency_code %q is not an ISO 4217 currency code", loc.CurrencyCode)
+	}
+	if loc.Amount.IsEmpty() {
+		verr.add("amount is required")
+	} else if amount, err := loc.Amount.WithCurrency(loc.CurrencyCode); err != nil {
+		verr.add("amount %v", err)
+	} else if sign, _ := amount.Sign(); sign <= 0 {
+		verr.add("amount must be positive, got %s", amount)
+	}
+	var issued, expires time.Time
+	var err error
+	if loc.DateOfIssue != "" {
+		if issued, err = parseLoCDate(loc.DateOfIssue); err != nil {
+			verr.add("date_of_issue %v", err)
+		}
+	}
+	if loc.DateOfExpiry != "" {
+		if expires, err = parseLoCDate(loc.DateOfExpiry); err != nil {
+			verr.add("date_of_expiry %v", err)
+		}
+	}
+	if !issued.IsZero() && !expires.IsZero() && !expires.After(issued) {
+		verr.add("date_of_expiry %s must be after date_of_issue %s", loc.DateOfExpiry, loc.DateOfIssue)
+	}
+	if loc.LatestDateOfShipment != "" {
+		shipment, err := parseLoCDate(loc.LatestDateOfShipment)
+		if err != nil {
+			verr.add("latest_date_of_shipment %v", err)
+		} else if !expires.IsZero() && shipment.After(expires) {
+			verr.add("latest_date_of_shipment %s must not be after date_of_expiry %s", loc.LatestDateOfShipment, loc.DateOfExpiry)
+		}
+	}
+	if loc.TolerancePlusPercent < 0 || loc.TolerancePlusPercent > 100 {
+		verr.add("tolerance_plus_percent must be between 0 and 100, got %d", loc.TolerancePlusPercent)
+	}
+	if loc.ToleranceMinusPercent < 0 || loc.ToleranceMinusPercent > 100 {
+		verr.add("tolerance_minus_percent must be between 0 and 100, got %d", loc.ToleranceMinusPercent)
+	}
+	switch loc.PartialShipments {
+	case "", PartialShipmentsAllowed, PartialShipmentsNotAllowed, PartialShipmentsConditional:
+	default:
+		verr.add("partial_shipments must be one of %s, %s, %s, got %q", PartialShipmentsAllowed, PartialShipmentsNotAllowed, PartialShipmentsConditional, loc.PartialShipments)
+	}
+	validateInstrument(loc, verr)
+	return verr.orNil()
+}
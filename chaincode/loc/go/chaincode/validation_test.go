@@ -0,0 +1,125 @@
+package chaincode
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"sample.com/lc/money"
+)
+
+// validLoC returns an LoC with every field issuance requires
+func validLoC() *LoC {
+	return &LoC{
+		ID:                      "LC1",
+		DocumentaryCreditNumber: "LC1",
+		ApplicantBank:           "Org1",
+		Beneficiary:             "POSCO INDIA PROCESSING CENTER PVT",
+		CurrencyCode:            "INR",
+		Amount:                  money.Money{Amount: "1000"},
+		DateOfIssue:             "20220105",
+		DateOfExpiry:            "2022-02-21",
+	}
+}
+
+func TestValidateLoC(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(loc *LoC)
+		want   []string
+	}{
+		{"valid", func(loc *LoC) {}, nil},
+		{"standby terms", func(loc *LoC) { loc.InstrumentType = InstrumentStandby; loc.AvailableWithBy = "ANY BANK BY PAYMENT" }, nil},
+		{"required", func(loc *LoC) { *loc = LoC{} }, []string{
+			"ID is required", "documentary_credit_number is required", "applicant_bank is required", "beneficiary is required",
+			"currency_code is required", "date_of_issue is required", "date_of_expiry is required", "amount is required",
+		}},
+		{"currency", func(loc *LoC) { loc.CurrencyCode = "RS" }, []string{`currency_code "RS" is not an ISO 4217 currency code`, `amount "RS" is not an ISO 4217 currency code`}},
+		{"negative amount", func(loc *LoC) { loc.Amount = money.Money{Amount: "-1"} }, []string{"amount must be positive, got INR -1.00"}},
+		{"amount in other currency", func(loc *LoC) { loc.Amount = money.Money{Amount: "1", Currency: "USD"} }, []string{"amount USD 1 is not an amount in INR"}},
+		{"bad date", func(loc *LoC) { loc.DateOfIssue = "05/01/2022" }, []string{`date_of_issue "05/01/2022" is not a date in format YYYYMMDD or YYYY-MM-DD`}},
+		{"expiry before issue", func(loc *LoC) { loc.DateOfExpiry = "20220105" }, []string{"date_of_expiry 20220105 must be after date_of_issue 20220105"}},
+		{"late shipment", func(loc *LoC) { loc.LatestDateOfShipment = "20220222" }, []string{"latest_date_of_shipment 20220222 must not be after date_of_expiry 2022-02-21"}},
+		{"tolerances", func(loc *LoC) { loc.TolerancePlusPercent = 101; loc.ToleranceMinusPercent = -1 }, []string{
+			"tolerance_plus_percent must be between 0 and 100, got 101", "tolerance_minus_percent must be between 0 and 100, got -1",
+		}},
+		{"partial shipments", func(loc *LoC) { loc.PartialShipments = "YES" }, []string{`partial_shipments must be one of ALLOWED, NOT ALLOWED, CONDITIONAL, got "YES"`}},
+		{"instrument type", func(loc *LoC) { loc.InstrumentType = "BOND" }, []string{`instrument_type must be one of DOCUMENTARY_CREDIT, STANDBY, GUARANTEE, got "BOND"`}},
+		{"guarantee terms", func(loc *LoC) {
+			loc.InstrumentType = InstrumentGuarantee
+			loc.DraftsAt = "SIGHT"
+			loc.LoadingFrom = "CHENNAI"
+		}, []string{
+			"drafts_at does not apply to a GUARANTEE", "loading_from does not apply to a GUARANTEE",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := validLoC()
+			tt.modify(loc)
+			err := validateLoC(loc)
+			var verr *ValidationError
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("validateLoC: %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &verr) || !reflect.DeepEqual(verr.Violations, tt.want) {
+				t.Errorf("validateLoC error = %v\nwant violations %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeLoC(t *testing.T) {
+	loc := validLoC()
+	loc.LatestDateOfShipment = "2022-01-31"
+	normalizeLoCDates(loc)
+	normalizeLoCAmount(loc)
+	if loc.DateOfIssue != "20220105" || loc.DateOfExpiry != "20220221" || loc.LatestDateOfShipment != "20220131" {
+		t.Errorf("normalizeLoCDates = %s, %s, %s", loc.DateOfIssue, loc.DateOfExpiry, loc.LatestDateOfShipment)
+	}
+	if loc.Amount != (money.Money{Amount: "1000.00", Currency: "INR"}) {
+		t.Errorf("normalizeLoCAmount = %+v", loc.Amount)
+	}
+}
+
+func TestNormalizeInstrumentType(t *testing.T) {
+	tests := []struct {
+		instrumentType InstrumentType
+		form           string
+		want           InstrumentType
+	}{
+		{"", "IRREVOCABLE", InstrumentDocumentaryCredit},
+		{"", "", InstrumentDocumentaryCredit},
+		{"", "irrevocable standby", InstrumentStandby},
+		{InstrumentGuarantee, "IRREVOCABLE STANDBY", InstrumentGuarantee},
+	}
+	for _, tt := range tests {
+		loc := &LoC{InstrumentType: tt.instrumentType, FormOfDocumentaryCredit: tt.form}
+		normalizeInstrumentType(loc)
+		if loc.InstrumentType != tt.want {
+			t.Errorf("normalizeInstrumentType(%q, %q) = %s, want %s", tt.instrumentType, tt.form, loc.InstrumentType, tt.want)
+		}
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	tests := []struct {
+		json string
+		ok   bool
+	}{
+		{`{"ID":"LC1"}`, true},
+		{`{"ID":"LC1","id":"LC2"}`, true}, // field names match case-insensitively, like encoding/json
+		{`{"ID":"LC1","foo":1}`, false},
+		{`{"ID":"LC1"} {"ID":"LC2"}`, false},
+		{`["LC1"]`, false},
+	}
+	for _, tt := range tests {
+		var loc LoC
+		if err := decodeStrict(tt.json, &loc); (err == nil) != tt.ok {
+			t.Errorf("decodeStrict(%s) error = %v, want ok %t", tt.json, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Command locswift converts LoCs from & to SWIFT MT700 messages, to onboard LCs banks send over SWIFT, & renders
+// the MT messages for chaincode events
+//
+//	locswift parse [-issue] [-applicant-bank Org1] [-advise-through-bank Org2] [-negotiating-bank Org2] [mt700.txt]
+//	locswift format [loc.json]
+//	locswift event -loc loc.json [-amendments amendments.json] [-presentation presentation.json] [payload.json]
+//	locswift export -format mt700 [loc.json]
+//	locswift import -format mt700 [mt700.txt]
+//
+// parse prints the LoC read from an MT700 as Json. With -issue it prints the arguments of IssueLoC instead,
+// {"jsonLoC": the public envelope, "transient": {"loc_private": the private details}}. Banks on the ledger are
+// orgs, not the BICs of the MT700, the -...-bank flags set them. format prints an LoC, eg. as returned by
+// GetLoCById, as the text block of an MT700. event prints the MT messages for the events of the payload of an
+// LoCEvents chaincode event, see swift.EventMessages. Payloads only tell what changed, the LoC, its amendments &
+// the presentation are read from files of what GetLoCById, GetLoCAmendments & GetLoCPresentation return to the
+// applicant bank. export prints an LoC in a format of package export, eg. as an MT700, import
+// prints the LoC read from one as Json. All read from standard input without a file.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"sample.com/lc/chaincode"
+	"sample.com/lc/events"
+	"sample.com/lc/export"
+	"sample.com/lc/swift"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("locswift: ")
+	if len(os.Args) < 2 {
+		log.Fatal("usage: locswift parse|format|event|export|import [flags] [file]")
+	}
+	var err error
+	switch os.Args[1] {
+	case "parse":
+		err = parse(os.Args[2:])
+	case "format":
+		err = format(os.Args[2:])
+	case "event":
+		err = event(os.Args[2:])
+	case "export":
+		err = exportLoC(os.Args[2:])
+	case "import":
+		err = importLoC(os.Args[2:])
+	default:
+		err = fmt.Errorf("unknown command %q, usage: locswift parse|format|event|export|import [flags] [file]", os.Args[1])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parse prints the LoC of an MT700
+func parse(args []string) error {
+	flags := flag.NewFlagSet("parse", flag.ExitOnError)
+	issue := flags.Bool("issue", false, "print the arguments of IssueLoC, the public envelope & the transient private details")
+	applicantBank := flags.String("applicant-bank", "", "org of the applicant bank, 51a of the MT700 if empty")
+	adviseThroughBank := flags.String("advise-through-bank", "", "org of the advise through bank, 57a of the MT700 if empty")
+	negotiatingBank := flags.String("negotiating-bank", "", "org of the negotiating bank")
+	flags.Parse(args)
+	text, err := readInput(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	loc, err := swift.ParseMT700(string(text))
+	if err != nil {
+		return err
+	}
+	for _, bank := range []struct {
+		flag  string
+		field *string
+	}{
+		{*applicantBank, &loc.ApplicantBank},
+		{*adviseThroughBank, &loc.AdviseThroughBank},
+		{*negotiatingBank, &loc.NegotiatingBank},
+	} {
+		if bank.flag != "" {
+			*bank.field = bank.flag
+		}
+	}
+	if !*issue {
+		return printJSON(loc)
+	}
+	envelope, details := chaincode.SplitLoC(loc)
+	return printJSON(map[string]interface{}{
+		"jsonLoC":   envelope,
+		"transient": map[string]interface{}{"loc_private": details},
+	})
+}
+
+// format prints an LoC as an MT700
+func format(args []string) error {
+	flags := flag.NewFlagSet("format", flag.ExitOnError)
+	flags.Parse(args)
+	locJSON, err := readInput(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var loc chaincode.LoC
+	err = json.Unmarshal(locJSON, &loc)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal LoC from Json: %v", err)
+	}
+	text, err := swift.FormatMT700(&loc)
+	if err != nil {
+		return err
+	}
+	fmt.Println(text)
+	return nil
+}
+
+// event prints the MT messages for the payload of an LoCEvents chaincode event
+func event(args []string) error {
+	flags := flag.NewFlagSet("event", flag.ExitOnError)
+	locFile := flags.String("loc", "", "file of the LoC of the event as returned by GetLoCById, required")
+	amendmentsFile := flags.String("amendments", "", "file of its amendments as returned by GetLoCAmendments, for amendments of private fields")
+	presentationFile := flags.String("presentation", "", "file of the presentation of the event as returned by GetLoCPresentation, for the amount claimed of MT734 & MT752")
+	flags.Parse(args)
+	payload, err := readInput(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *locFile == "" {
+		return fmt.Errorf("-loc is required, events only tell what changed on the LoC")
+	}
+	contract := files{"GetLoCById": *locFile, "GetLoCAmendments": *amendmentsFile, "GetLoCPresentation": *presentationFile}
+	messages, err := swift.FormatEvents(events.BatchName, payload, contract)
+	if err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return swift.ErrNoMessage
+	}
+	fmt.Println(strings.Join(messages, "\n"))
+	return nil
+}
+
+// exportLoC prints an LoC in an export format
+func exportLoC(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	name := flags.String("format", "json", "export format, one of "+strings.Join(export.Formats(), ", "))
+	flags.Parse(args)
+	locJSON, err := readInput(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	loc, err := export.Decode("json", locJSON)
+	if err != nil {
+		return err
+	}
+	data, err := export.Encode(*name, loc)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// importLoC prints the LoC read from an export format
+func importLoC(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	name := flags.String("format", "json", "export format, one of "+strings.Join(export.Formats(), ", "))
+	flags.Parse(args)
+	data, err := readInput(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	loc, err := export.Decode(*name, data)
+	if err != nil {
+		return err
+	}
+	return printJSON(loc)
+}
+
+// files evaluates transactions by reading what they returned from files, by transaction name
+type files map[string]string
+
+func (f files) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	if f[name] == "" {
+		return nil, fmt.Errorf("no file of what %s returns", name)
+	}
+	return os.ReadFile(f[name])
+}
+
+// readInput reads file, standard input if it is empty
+func readInput(file string) ([]byte, error) {
+	if file == "" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(file)
+}
+
+// printJSON prints v as indented Json
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal into Json: %v", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,3 @@
+module sample.com/lc/couchdb
+
+go 1.18
@@ -0,0 +1,144 @@
+// Package couchdb builds the Json of CouchDB Mango queries for rich queries of chaincodes, eg.
+//
+//	couchdb.NewQuery(couchdb.Eq("docType", "marble"), couchdb.Eq("owner", owner)).UseIndex("_design/indexOwnerDoc", "indexOwner").Build()
+//
+// Values are only ever rendered by json.Marshal, so quotes & braces in them can not change the selector.
+// It has no dependency, so chaincodes of any Fabric version can share it.
+package couchdb
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Condition is one condition of a selector, an operator on a field or an $or of other conditions
+type Condition struct {
+	field    string // empty for $or
+	operator string
+	value    interface{}
+}
+
+// Eq, Gt, Gte, Lt & Lte compare field to value, values of other Json types never match
+func Eq(field string, value interface{}) Condition  { return Condition{field, "$eq", value} }
+func Gt(field string, value interface{}) Condition  { return Condition{field, "$gt", value} }
+func Gte(field string, value interface{}) Condition { return Condition{field, "$gte", value} }
+func Lt(field string, value interface{}) Condition  { return Condition{field, "$lt", value} }
+func Lte(field string, value interface{}) Condition { return Condition{field, "$lte", value} }
+
+// In matches field against any of values, none matches no document
+func In(field string, values ...interface{}) Condition {
+	return Condition{field, "$in", append(make([]interface{}, 0, len(values)), values...)}
+}
+
+// Or matches if any of conditions does, conditions that must all match go in one query
+func Or(conditions ...Condition) Condition {
+	return Condition{"", "$or", conditions}
+}
+
+// Query builds a Mango query, see NewQuery
+type Query struct {
+	conditions []Condition
+	sort       []map[string]string
+	index      []string
+}
+
+// NewQuery starts a query selecting documents matching all of conditions
+func NewQuery(conditions ...Condition) *Query {
+	return &Query{conditions: conditions}
+}
+
+// Where adds conditions the documents must also match
+func (q *Query) Where(conditions ...Condition) *Query {
+	q.conditions = append(q.conditions, conditions...)
+	return q
+}
+
+// SortBy sorts on field, ascending unless desc. CouchDB needs an index covering every sort field,
+// & the fields of the selector the index starts with, see UseIndex.
+func (q *Query) SortBy(field string, desc bool) *Query {
+	direction := "asc"
+	if desc {
+		direction = "desc"
+	}
+	q.sort = append(q.sort, map[string]string{field: direction})
+	return q
+}
+
+// UseIndex tells CouchDB which index to use, eg. UseIndex("_design/indexOwnerDoc", "indexOwner")
+func (q *Query) UseIndex(designDoc string, name string) *Query {
+	q.index = []string{designDoc, name}
+	return q
+}
+
+// render adds c to selector. Conditions on the same field share one operator object,
+// repeated operators & $or go to an $and so no condition is lost.
+func (c Condition) render(selector map[string]interface{}) error {
+	switch c.operator {
+	case "$or":
+		subs := make([]interface{}, 0)
+		for _, sub := range c.value.([]Condition) {
+			subSelector := map[string]interface{}{}
+			if err := sub.render(subSelector); err != nil {
+				return err
+			}
+			subs = append(subs, subSelector)
+		}
+		if _, taken := selector[c.operator]; !taken {
+			selector[c.operator] = subs
+			return nil
+		}
+		selector["$and"] = append(andOf(selector), map[string]interface{}{c.operator: subs})
+		return nil
+	}
+	if c.field == "" {
+		return fmt.Errorf("%s needs a field", c.operator)
+	}
+	operators, ok := selector[c.field].(map[string]interface{})
+	if !ok {
+		operators = map[string]interface{}{}
+		selector[c.field] = operators
+	}
+	if _, taken := operators[c.operator]; !taken {
+		operators[c.operator] = c.value
+		return nil
+	}
+	selector["$and"] = append(andOf(selector), map[string]interface{}{c.field: map[string]interface{}{c.operator: c.value}})
+	return nil
+}
+
+// andOf returns the conditions of the $and of selector, if any
+func andOf(selector map[string]interface{}) []interface{} {
+	conditions, _ := selector["$and"].([]interface{})
+	return conditions
+}
+
+// selector returns the Mango selector of the conditions of q
+func (q *Query) selector() (map[string]interface{}, error) {
+	selector := map[string]interface{}{}
+	for _, c := range q.conditions {
+		if err := c.render(selector); err != nil {
+			return nil, err
+		}
+	}
+	return selector, nil
+}
+
+// Build returns the query string
+func (q *Query) Build() (string, error) {
+	selector, err := q.selector()
+	if err != nil {
+		return "", err
+	}
+	query := map[string]interface{}{"selector": selector}
+	if len(q.sort) > 0 {
+		query["sort"] = q.sort
+	}
+	if len(q.index) > 0 {
+		query["use_index"] = q.index
+	}
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal query into Json: %v", err)
+	}
+	return string(queryJSON), nil
+}
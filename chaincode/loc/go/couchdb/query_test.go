@@ -0,0 +1,35 @@
+package couchdb
+
+import "testing"
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name  string
+		query *Query
+		want  string
+	}{
+		{"one condition", NewQuery(Eq("doc_type", "LoC")), `{"selector":{"doc_type":{"$eq":"LoC"}}}`},
+		{"range", NewQuery(Gte("date_of_issue", "20220101"), Lte("date_of_issue", "20220131")), `{"selector":{"date_of_issue":{"$gte":"20220101","$lte":"20220131"}}}`},
+		{"repeated operator", NewQuery(Gt("seq", 1), Gt("seq", 2)), `{"selector":{"$and":[{"seq":{"$gt":2}}],"seq":{"$gt":1}}}`},
+		{"in", NewQuery(In("current_status", "EXPIRED", "CLOSED_BY_APPLICANT_BANK")), `{"selector":{"current_status":{"$in":["EXPIRED","CLOSED_BY_APPLICANT_BANK"]}}}`},
+		{"in without values", NewQuery(In("current_status")), `{"selector":{"current_status":{"$in":[]}}}`},
+		{"or", NewQuery(Eq("is_active", true)).Where(Or(Eq("a", 1), Lt("b", 2))), `{"selector":{"$or":[{"a":{"$eq":1}},{"b":{"$lt":2}}],"is_active":{"$eq":true}}}`},
+		{"two ors", NewQuery(Or(Eq("a", 1)), Or(Eq("b", 2))), `{"selector":{"$and":[{"$or":[{"b":{"$eq":2}}]}],"$or":[{"a":{"$eq":1}}]}}`},
+		{"quoted value", NewQuery(Eq("applicant_bank", `Org1"},"$or":[{"x":1}],"a":{"b":"`)), `{"selector":{"applicant_bank":{"$eq":"Org1\"},\"$or\":[{\"x\":1}],\"a\":{\"b\":\""}}}`},
+		{"quoted values in", NewQuery(In("owner", `tom"]},"$or":[{"x":1}],"a":{"$in":["`)), `{"selector":{"owner":{"$in":["tom\"]},\"$or\":[{\"x\":1}],\"a\":{\"$in\":[\""]}}}`},
+		{
+			"sort & index",
+			NewQuery(Eq("doc_type", "LoC")).SortBy("date_of_expiry", false).SortBy("ID", true).UseIndex("_design/indexLoCActiveDoc", "indexLoCActive"),
+			`{"selector":{"doc_type":{"$eq":"LoC"}},"sort":[{"date_of_expiry":"asc"},{"ID":"desc"}],"use_index":["_design/indexLoCActiveDoc","indexLoCActive"]}`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.query.Build()
+		if err != nil || got != tt.want {
+			t.Errorf("%s: Build = %s, %v\nwant %s", tt.name, got, err, tt.want)
+		}
+	}
+	if _, err := NewQuery(Eq("", "LoC")).Build(); err == nil || err.Error() != "$eq needs a field" {
+		t.Errorf("Build without field error = %v", err)
+	}
+}
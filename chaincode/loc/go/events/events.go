@@ -0,0 +1,244 @@
+// Package events has the payloads of the events of the LoC chaincode, for client applications to decode what
+// they receive from the channel. Every event is an Envelope: what changed on one LoC in one transaction, never its
+// private details. Fabric emits one chaincode event per transaction, the chaincode emits the events of a transaction
+// together as one LoCEvents Batch, see Unpack. Envelopes are versioned, see SchemaVersion.
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// SchemaVersion is the version of the payloads of this package, raised when a change breaks their decoding
+const SchemaVersion = 1
+
+// Envelope is the payload of an event of one LoC, eg.
+//
+//	{"schema_version":1,"type":"LoCIssuanceAcknowledged","loc_id":"LC1","from_status":"ISSUED_BY_APPLICANT_BANK",
+//	 "to_status":"ISSUANCE_ACKNOWLEDGED_BY_ADVISING_BANK","actor_org":"Org2","tx_id":"...","timestamp":"...","changes":[]}
+type Envelope struct {
+	SchemaVersion int    `json:"schema_version"`
+	Type          string `json:"type"` // name of the event, eg. LoCIssued
+	LoCID         string `json:"loc_id"`
+	FromStatus    string `json:"from_status"` // status before the event, "" for LoCIssued
+	ToStatus      string `json:"to_status"`   // status after the event
+	ActorOrg      string `json:"actor_org"`   // org of the submitting client, eg. Org1
+	TxID          string `json:"tx_id"`
+	Timestamp     string `json:"timestamp"` // transaction timestamp, RFC 3339
+	// Changes are the public LoC fields the transaction changed, status & status log aside, by field name.
+	// Follow-up events, eg. DocumentsAwaited, have none, the event they follow has the changes & the same details.
+	Changes []Change `json:"changes"`
+	// Details are what the event is about besides the LoC, eg. the amendment of LoCAmendmentProposed, see DecodeDetails
+	Details json.RawMessage `json:"details,omitempty"`
+}
+
+// Change is one LoC field changed by a transaction, values are Json, null if the field was not set
+type Change struct {
+	Field string          `json:"field"` // Json name of the field, eg. date_of_expiry
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+// BatchName is the name of the chaincode event of a Batch
+const BatchName = "LoCEvents"
+
+// Types of the events of the LoC chaincode. Actions that pass through a status & settle in a follow-up status, eg.
+// AcknowledgeLoCAmendment, emit one event for each, eg. LoCAmendmentAcknowledged then DocumentsAwaited.
+const (
+	LoCIssued                  = "LoCIssued"
+	LoCIssuanceAcknowledged    = "LoCIssuanceAcknowledged"
+	LoCAmendmentProposed       = "LoCAmendmentProposed"
+	LoCAmendmentAcknowledged   = "LoCAmendmentAcknowledged"
+	LoCAmendmentRejected       = "LoCAmendmentRejected"
+	DocumentsSubmitted         = "DocumentsSubmitted"
+	DocumentsAccepted          = "DocumentsAccepted"
+	DiscrepanciesRaised        = "DiscrepanciesRaised"
+	DocumentsRepresented       = "DocumentsRepresented"
+	DiscrepancyWaiverRequested = "DiscrepancyWaiverRequested"
+	DiscrepanciesWaived        = "DiscrepanciesWaived"
+	DocumentsRefused           = "DocumentsRefused"
+	DocumentsAwaited           = "DocumentsAwaited" // follow-up of amendment decisions & refusals of documentary credits
+	ClaimAwaited               = "ClaimAwaited"     // follow-up of amendment decisions & rejected claims of standbys & guarantees
+	PaymentConfirmed           = "PaymentConfirmed"
+	PaymentAcknowledged        = "PaymentAcknowledged"
+	LoCClosed                  = "LoCClosed"
+	LoCExpired                 = "LoCExpired"
+)
+
+// Batch is the payload of the LoCEvents event, the events of one transaction in the order they happened
+type Batch struct {
+	SchemaVersion int        `json:"schema_version"`
+	TxID          string     `json:"tx_id"`
+	Events        []Envelope `json:"events"`
+}
+
+// Decode reads the envelope of an event payload, an error for payloads without schema version, eg. of chaincode
+// before versioned events, or of a newer version than SchemaVersion
+func Decode(payload []byte) (*Envelope, error) {
+	var envelope Envelope
+	err := json.Unmarshal(payload, &envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event from Json: %v", err)
+	}
+	err = checkVersion(envelope.SchemaVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &envelope, nil
+}
+
+// Unpack returns the envelopes of a chaincode event, with name & payload as set by SetEvent, in the order they
+// happened. The chaincode emits its events as LoCEvents batches, other events are not LoC events.
+func Unpack(name string, payload []byte) ([]Envelope, error) {
+	if name != BatchName {
+		return nil, fmt.Errorf("%s event is not an LoC event, they are emitted as %s batches", name, BatchName)
+	}
+	batch, err := DecodeBatch(payload)
+	if err != nil {
+		return nil, err
+	}
+	return batch.Events, nil
+}
+
+// DecodeBatch reads the payload of the LoCEvents event, like Decode
+func DecodeBatch(payload []byte) (*Batch, error) {
+	var batch Batch
+	err := json.Unmarshal(payload, &batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event from Json: %v", err)
+	}
+	err = checkVersion(batch.SchemaVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// checkVersion tells if payloads of schema version can be decoded
+func checkVersion(version int) error {
+	if version == 0 {
+		return fmt.Errorf("event has no schema_version, it is not a versioned LoC event")
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("event has schema_version %d, only %d or before can be decoded", version, SchemaVersion)
+	}
+	return nil
+}
+
+// DecodeDetails unmarshals the details of the event into v, eg. a chaincode.Amendment, false if it has none
+func (e *Envelope) DecodeDetails(v interface{}) (bool, error) {
+	if len(e.Details) == 0 || string(e.Details) == "null" {
+		return false, nil
+	}
+	err := json.Unmarshal(e.Details, v)
+	if err != nil {
+		return false, fmt.Errorf("failed to unmarshal details of %s event from Json: %v", e.Type, err)
+	}
+	return true, nil
+}
+
+// Change returns the change of field, nil if the transaction did not change it
+func (e *Envelope) Change(field string) *Change {
+	for i := range e.Changes {
+		if e.Changes[i].Field == field {
+			return &e.Changes[i]
+		}
+	}
+	return nil
+}
+
+// Diff returns the changes from before to after, both Json objects, sorted by field. Fields in ignored are left out.
+// before is nil for a new object, its fields then only change if they are set to something else than a zero value.
+func Diff(before []byte, after []byte, ignored ...string) ([]Change, error) {
+	from := make(map[string]json.RawMessage)
+	if before != nil {
+		if err := json.Unmarshal(before, &from); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+		}
+	}
+	to := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(after, &to); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	skip := make(map[string]bool, len(ignored))
+	for _, field := range ignored {
+		skip[field] = true
+	}
+	fields := make([]string, 0, len(to))
+	for field := range to {
+		fields = append(fields, field)
+	}
+	for field := range from {
+		if _, ok := to[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	changes := make([]Change, 0)
+	for _, field := range fields {
+		if skip[field] {
+			continue
+		}
+		old, wasSet := from[field]
+		value := to[field]
+		if !wasSet && before == nil && isZero(value) {
+			continue
+		}
+		if wasSet && value != nil && bytes.Equal(compact(old), compact(value)) {
+			continue
+		}
+		changes = append(changes, Change{Field: field, From: orNull(old), To: orNull(value)})
+	}
+	return changes, nil
+}
+
+// compact strips insignificant spaces of a Json value
+func compact(value json.RawMessage) []byte {
+	var buffer bytes.Buffer
+	if err := json.Compact(&buffer, value); err != nil {
+		return value
+	}
+	return buffer.Bytes()
+}
+
+// orNull returns value, null if it is not set
+func orNull(value json.RawMessage) json.RawMessage {
+	if value == nil {
+		return json.RawMessage("null")
+	}
+	return value
+}
+
+// isZero tells if a Json value is null, false, 0, "", empty or only holds zero values, eg. {"amount":"","currency":""}
+func isZero(value json.RawMessage) bool {
+	var v interface{}
+	if err := json.Unmarshal(value, &v); err != nil {
+		return false
+	}
+	return isZeroValue(v)
+}
+
+func isZeroValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		for _, field := range v {
+			if !isZeroValue(field) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          []Change
+	}{
+		{"new object", "", `{"id":"LC1","amount":{"amount":"","currency":""},"tags":[],"active":true,"count":0,"status_log":[1]}`, []Change{
+			{Field: "active", From: json.RawMessage("null"), To: json.RawMessage("true")},
+			{Field: "id", From: json.RawMessage("null"), To: json.RawMessage(`"LC1"`)},
+		}},
+		{"changed", `{"id":"LC1","active":true,"tags":["a"]}`, `{"id":"LC1", "active":false, "tags":["a"]}`, []Change{
+			{Field: "active", From: json.RawMessage("true"), To: json.RawMessage("false")},
+		}},
+		{"set & unset", `{"id":"LC1","place":"CHENNAI"}`, `{"id":"LC1","count":0}`, []Change{
+			{Field: "count", From: json.RawMessage("null"), To: json.RawMessage("0")},
+			{Field: "place", From: json.RawMessage(`"CHENNAI"`), To: json.RawMessage("null")},
+		}},
+		{"unchanged", `{"id":"LC1","status_log":[1]}`, `{"id":"LC1","status_log":[1,2]}`, []Change{}},
+	}
+	for _, tt := range tests {
+		var before []byte
+		if tt.before != "" {
+			before = []byte(tt.before)
+		}
+		got, err := Diff(before, []byte(tt.after), "status_log")
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Diff = %s, %v\nwant %s", tt.name, mustJSON(t, got), err, mustJSON(t, tt.want))
+		}
+	}
+	if _, err := Diff([]byte(`["LC1"]`), []byte(`{}`)); err == nil {
+		t.Errorf("Diff of an array")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	payload := `{"schema_version":1,"type":"LoCAmendmentProposed","loc_id":"LC1","from_status":"ISSUANCE_ACKNOWLEDGED_BY_ADVISING_BANK",` +
+		`"to_status":"AMENDED_BY_APPLICANT_BANK","actor_org":"Org1","tx_id":"tx1","timestamp":"2022-04-11T11:46:00+05:30",` +
+		`"changes":[{"field":"amendment_count","from":0,"to":1}],"details":{"seq":1}}`
+	envelope, err := Decode([]byte(payload))
+	if err != nil || envelope.LoCID != "LC1" || envelope.ActorOrg != "Org1" || envelope.Change("amendment_count") == nil || envelope.Change("amount") != nil {
+		t.Fatalf("Decode = %+v, %v", envelope, err)
+	}
+	var details struct {
+		Seq int `json:"seq"`
+	}
+	if ok, err := envelope.DecodeDetails(&details); !ok || err != nil || details.Seq != 1 {
+		t.Errorf("DecodeDetails = %t, %v, %+v", ok, err, details)
+	}
+	if ok, err := (&Envelope{Type: "LoCClosed"}).DecodeDetails(&details); ok || err != nil {
+		t.Errorf("DecodeDetails without details = %t, %v", ok, err)
+	}
+	tests := []struct {
+		payload string
+		err     string
+	}{
+		{`{"ID":"LC1","current_status":"ISSUED_BY_APPLICANT_BANK"}`, "no schema_version"},
+		{`{"schema_version":2,"type":"LoCIssued"}`, "schema_version 2"},
+		{`[]`, "failed to unmarshal event"},
+	}
+	for _, tt := range tests {
+		if _, err := Decode([]byte(tt.payload)); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Decode(%s) error = %v, want it to contain %q", tt.payload, err, tt.err)
+		}
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    []string // types of the envelopes, nil if it is not unpacked
+	}{
+		{"LoCEvents", `{"schema_version":1,"tx_id":"tx1","events":[{"type":"LoCAmendmentAcknowledged"},{"type":"DocumentsAwaited"}]}`, []string{LoCAmendmentAcknowledged, DocumentsAwaited}},
+		{"LoCEvents", `{"schema_version":1,"tx_id":"tx1","events":[{"type":"LoCExpired"},{"type":"LoCExpired"}]}`, []string{LoCExpired, LoCExpired}},
+		{"LoCClosed", `{"schema_version":1,"type":"LoCClosed","loc_id":"LC1"}`, nil},
+		{"LoCExpired", `{"schema_version":1,"events":[{"type":"LoCExpired"}]}`, nil},
+	}
+	for _, tt := range tests {
+		envelopes, err := Unpack(tt.name, []byte(tt.payload))
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("Unpack(%s) = %+v, want an error", tt.name, envelopes)
+			}
+			continue
+		}
+		types := make([]string, 0, len(envelopes))
+		for _, envelope := range envelopes {
+			types = append(types, envelope.Type)
+		}
+		if err != nil || !reflect.DeepEqual(types, tt.want) {
+			t.Errorf("Unpack(%s) = %v, %v, want %v", tt.name, types, err, tt.want)
+		}
+	}
+	if _, err := Unpack(BatchName, []byte(`{"events":[]}`)); err == nil {
+		t.Errorf("Unpack of a batch without schema version")
+	}
+}
+
+// mustJSON marshals v
+func mustJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
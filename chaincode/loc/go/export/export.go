@@ -0,0 +1,105 @@
+// Package export renders LoCs in the formats they are exchanged in with systems off the ledger & reads them back,
+// eg. to emit the same LoC as Json for an API & as an MT700 for a bank.
+// The formats are looked up by name, see Formats.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sample.com/lc/chaincode"
+	"sample.com/lc/swift"
+)
+
+// Format is a format LoCs are exported in
+type Format struct {
+	Name      string // eg. "mt700"
+	MediaType string // eg. "text/plain"
+	Encode    func(loc *chaincode.LoC) ([]byte, error)
+	Decode    func(data []byte) (*chaincode.LoC, error)
+}
+
+var formats = map[string]Format{
+	"json": {
+		Name:      "json",
+		MediaType: "application/json",
+		Encode:    encodeJSON,
+		Decode:    decodeJSON,
+	},
+	"mt700": {
+		Name:      "mt700",
+		MediaType: "text/plain",
+		Encode: func(loc *chaincode.LoC) ([]byte, error) {
+			text, err := swift.FormatMT700(loc)
+			return []byte(text), err
+		},
+		Decode: func(data []byte) (*chaincode.LoC, error) {
+			return swift.ParseMT700(string(data))
+		},
+	},
+}
+
+// Formats returns the names of the formats, sorted
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns format name
+func Lookup(name string) (Format, error) {
+	format, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return Format{}, fmt.Errorf("unknown export format %q, one of %s", name, strings.Join(Formats(), ", "))
+	}
+	return format, nil
+}
+
+// Encode renders loc in format name
+func Encode(name string, loc *chaincode.LoC) ([]byte, error) {
+	format, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return format.Encode(loc)
+}
+
+// Decode reads an LoC in format name
+func Decode(name string, data []byte) (*chaincode.LoC, error) {
+	format, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return format.Decode(data)
+}
+
+// encodeJSON renders loc as indented Json, the amount with its currency even if it was stored as a bare amount
+func encodeJSON(loc *chaincode.LoC) ([]byte, error) {
+	exported := *loc
+	if amount, err := loc.Amount.WithCurrency(loc.CurrencyCode); err == nil {
+		exported.Amount = amount
+	}
+	data, err := json.MarshalIndent(&exported, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal into Json: %v", err)
+	}
+	return data, nil
+}
+
+// decodeJSON reads an LoC from Json, eg. as returned by GetLoCById
+func decodeJSON(data []byte) (*chaincode.LoC, error) {
+	var loc chaincode.LoC
+	err := json.Unmarshal(data, &loc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal LoC from Json: %v", err)
+	}
+	if amount, err := loc.Amount.WithCurrency(loc.CurrencyCode); err == nil {
+		loc.Amount = amount
+	}
+	return &loc, nil
+}
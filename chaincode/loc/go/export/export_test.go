@@ -0,0 +1,64 @@
+package export
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"sample.com/lc/chaincode"
+	"sample.com/lc/money"
+)
+
+func TestRoundTrip(t *testing.T) {
+	loc := &chaincode.LoC{ID: "JP-22-7", DocType: "LoC", DocumentaryCreditNumber: "JP-22-7", FormOfDocumentaryCredit: "IRREVOCABLE", DateOfIssue: "20221201",
+		DateOfExpiry: "20230301", PlaceOfExpiry: "TOKYO", ApplicantBank: "BOTKJPJTXXX", Applicant: "NIPPON PARTS KK\nOSAKA", Beneficiary: "SHENZHEN MOTORS CO",
+		CurrencyCode: "JPY", Amount: money.Money{Amount: "98000000", Currency: "JPY"}, ToleranceMinusPercent: 5, AvailableWithBy: "BOTKJPJT BY ACCEPTANCE",
+		DraftsAt: "60 DAYS AFTER SIGHT", PartialShipments: "CONDITIONAL", LatestDateOfShipment: "20230131", ShipmentPeriod: "JANUARY 2023",
+		ReimbursingBank: "BOTKJPJT", AdviseThroughBank: "BKCHCNBJ45A"}
+	for _, name := range Formats() {
+		t.Run(name, func(t *testing.T) {
+			data, err := Encode(name, loc)
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			decoded, err := Decode(name, data)
+			if err != nil {
+				t.Fatalf("Decode: %v\n%s", err, data)
+			}
+			if len(decoded.StatusLog) == 0 {
+				decoded.StatusLog = nil // Json reads a missing status log as empty
+			}
+			if !reflect.DeepEqual(decoded, loc) {
+				t.Errorf("Decode(Encode(loc)) =\n%+v\nwant\n%+v", decoded, loc)
+			}
+		})
+	}
+}
+
+func TestEncodeJSONBareAmount(t *testing.T) {
+	loc := &chaincode.LoC{ID: "LC1", CurrencyCode: "INR", Amount: money.Money{Amount: "11436300"}}
+	data, err := Encode("json", loc)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if want := `"amount": {
+    "amount": "11436300.00",
+    "currency": "INR"
+  }`; !strings.Contains(string(data), want) {
+		t.Errorf("Encode(json) =\n%s\nwant it to contain\n%s", data, want)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if got := strings.Join(Formats(), ","); got != "json,mt700" {
+		t.Errorf("Formats() = %s", got)
+	}
+	format, err := Lookup("MT700")
+	if err != nil || format.MediaType != "text/plain" {
+		t.Errorf("Lookup(MT700) = %+v, %v", format, err)
+	}
+	_, err = Encode("pdf", &chaincode.LoC{})
+	if err == nil || !strings.Contains(err.Error(), `unknown export format "pdf", one of json, mt700`) {
+		t.Errorf("Encode(pdf) error = %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package fabrictest
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ClientIdentity is a cid.ClientIdentity with fixed values
+type ClientIdentity struct {
+	MSPID      string
+	ID         string
+	Attributes map[string]string
+}
+
+var _ cid.ClientIdentity = (*ClientIdentity)(nil)
+
+// NewClientIdentity returns a client of mspID, its ID is derived from mspID & name
+func NewClientIdentity(mspID string, name string, attributes map[string]string) *ClientIdentity {
+	return &ClientIdentity{
+		MSPID:      mspID,
+		ID:         fmt.Sprintf("x509::CN=%s,OU=client::CN=ca.%s", name, mspID),
+		Attributes: attributes,
+	}
+}
+
+func (ci *ClientIdentity) GetID() (string, error) {
+	return ci.ID, nil
+}
+
+func (ci *ClientIdentity) GetMSPID() (string, error) {
+	return ci.MSPID, nil
+}
+
+func (ci *ClientIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	value, found := ci.Attributes[attrName]
+	return value, found, nil
+}
+
+func (ci *ClientIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	value, found, _ := ci.GetAttributeValue(attrName)
+	if !found {
+		return fmt.Errorf("attribute '%s' was not found", attrName)
+	}
+	if value != attrValue {
+		return fmt.Errorf("attribute '%s' equals '%s', not '%s'", attrName, value, attrValue)
+	}
+	return nil
+}
+
+func (ci *ClientIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return nil, nil
+}
+
+// NewTransactionContext returns a contractapi transaction context for stub & client identity
+func NewTransactionContext(stub shim.ChaincodeStubInterface, clientIdentity cid.ClientIdentity) *contractapi.TransactionContext {
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(clientIdentity)
+	return ctx
+}
@@ -0,0 +1,59 @@
+package fabrictest
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// StateIterator iterates over a snapshot of query results
+type StateIterator struct {
+	results []*queryresult.KV
+	closed  bool
+}
+
+func newStateIterator(results []*queryresult.KV) *StateIterator {
+	return &StateIterator{results: results}
+}
+
+func (it *StateIterator) HasNext() bool {
+	return !it.closed && len(it.results) > 0
+}
+
+func (it *StateIterator) Next() (*queryresult.KV, error) {
+	if !it.HasNext() {
+		return nil, fmt.Errorf("no more results")
+	}
+	next := it.results[0]
+	it.results = it.results[1:]
+	return next, nil
+}
+
+func (it *StateIterator) Close() error {
+	it.closed = true
+	return nil
+}
+
+// HistoryIterator iterates over a snapshot of the modifications of a key
+type HistoryIterator struct {
+	modifications []*queryresult.KeyModification
+	closed        bool
+}
+
+func (it *HistoryIterator) HasNext() bool {
+	return !it.closed && len(it.modifications) > 0
+}
+
+func (it *HistoryIterator) Next() (*queryresult.KeyModification, error) {
+	if !it.HasNext() {
+		return nil, fmt.Errorf("no more results")
+	}
+	next := it.modifications[0]
+	it.modifications = it.modifications[1:]
+	return next, nil
+}
+
+func (it *HistoryIterator) Close() error {
+	it.closed = true
+	return nil
+}
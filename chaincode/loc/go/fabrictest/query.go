@@ -0,0 +1,317 @@
+package fabrictest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// mangoQuery is the subset of a CouchDB Mango query the stub evaluates, use_index is accepted & ignored
+type mangoQuery struct {
+	Selector map[string]interface{} `json:"selector"`
+	Sort     []interface{}          `json:"sort"`
+	Limit    int                    `json:"limit"`
+	Skip     int                    `json:"skip"`
+	UseIndex interface{}            `json:"use_index"`
+	Fields   []string               `json:"fields"`
+}
+
+// sortField is one field of a Mango sort, descending if desc
+type sortField struct {
+	field string
+	desc  bool
+}
+
+// runQuery returns the keys of state whose Json values match query, in the order the query asks for
+func runQuery(state map[string][]byte, query string) ([]string, error) {
+	var q mangoQuery
+	if err := json.Unmarshal([]byte(query), &q); err != nil {
+		return nil, fmt.Errorf("invalid query %s: %v", query, err)
+	}
+	if q.Selector == nil {
+		return nil, fmt.Errorf("invalid query %s: selector is required", query)
+	}
+	sortFields, err := parseSort(q.Sort)
+	if err != nil {
+		return nil, err
+	}
+	docs := make(map[string]interface{})
+	keys := sortedKeys(state, func(key string) bool {
+		if strings.HasPrefix(key, compositeKeyNamespace) {
+			return false
+		}
+		var doc interface{}
+		if json.Unmarshal(state[key], &doc) != nil {
+			return false
+		}
+		docs[key] = doc
+		return true
+	})
+	matched := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ok, err := matches(docs[key], q.Selector)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, key)
+		}
+	}
+	if len(sortFields) > 0 {
+		sort.SliceStable(matched, func(i, j int) bool {
+			for _, sf := range sortFields {
+				a, _ := lookup(docs[matched[i]], sf.field)
+				b, _ := lookup(docs[matched[j]], sf.field)
+				c, ok := compare(a, b)
+				if !ok || c == 0 {
+					continue
+				}
+				if sf.desc {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+	}
+	if q.Skip > 0 {
+		if q.Skip >= len(matched) {
+			return []string{}, nil
+		}
+		matched = matched[q.Skip:]
+	}
+	if q.Limit > 0 && q.Limit < len(matched) {
+		matched = matched[:q.Limit]
+	}
+	return matched, nil
+}
+
+func parseSort(spec []interface{}) ([]sortField, error) {
+	fields := make([]sortField, 0, len(spec))
+	for _, entry := range spec {
+		switch e := entry.(type) {
+		case string:
+			fields = append(fields, sortField{field: e})
+		case map[string]interface{}:
+			for field, direction := range e {
+				fields = append(fields, sortField{field: field, desc: direction == "desc"})
+			}
+		default:
+			return nil, fmt.Errorf("invalid sort %v", entry)
+		}
+	}
+	return fields, nil
+}
+
+// paginate returns the page of keys after bookmark, the bookmark is the offset of the next page
+func paginate(keys []string, pageSize int32, bookmark string) ([]string, *pb.QueryResponseMetadata, error) {
+	offset := 0
+	if bookmark != "" {
+		var err error
+		if offset, err = strconv.Atoi(bookmark); err != nil || offset < 0 {
+			return nil, nil, fmt.Errorf("invalid bookmark %q", bookmark)
+		}
+	}
+	if offset > len(keys) {
+		offset = len(keys)
+	}
+	end := len(keys)
+	if pageSize > 0 && offset+int(pageSize) < end {
+		end = offset + int(pageSize)
+	}
+	page := keys[offset:end]
+	return page, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(page)), Bookmark: strconv.Itoa(end)}, nil
+}
+
+// lookup returns the value at a dotted field path of doc
+func lookup(doc interface{}, field string) (interface{}, bool) {
+	value := doc
+	for _, part := range strings.Split(field, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// compare orders two Json values of the same kind, ok is false if they can not be ordered
+func compare(a, b interface{}) (int, bool) {
+	switch av := a.(type) {
+	case float64:
+		bv, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case av < bv:
+			return -1, true
+		case av > bv:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(av, bv), true
+	case bool:
+		bv, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case av == bv:
+			return 0, true
+		case !av:
+			return -1, true
+		}
+		return 1, true
+	}
+	return 0, false
+}
+
+// matches evaluates a Mango selector against doc
+func matches(doc interface{}, selector map[string]interface{}) (bool, error) {
+	for field, condition := range selector {
+		var ok bool
+		var err error
+		switch field {
+		case "$and", "$or", "$nor":
+			ok, err = combine(doc, field, condition)
+		case "$not":
+			sub, isObject := condition.(map[string]interface{})
+			if !isObject {
+				return false, fmt.Errorf("$not expects a selector")
+			}
+			ok, err = matches(doc, sub)
+			ok = !ok
+		default:
+			value, found := lookup(doc, field)
+			ok, err = matchesCondition(doc, field, value, found, condition)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func combine(doc interface{}, operator string, condition interface{}) (bool, error) {
+	selectors, ok := condition.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("%s expects an array of selectors", operator)
+	}
+	matchedAny := false
+	for _, s := range selectors {
+		sub, ok := s.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s expects an array of selectors", operator)
+		}
+		matched, err := matches(doc, sub)
+		if err != nil {
+			return false, err
+		}
+		if operator == "$and" && !matched {
+			return false, nil
+		}
+		matchedAny = matchedAny || matched
+	}
+	switch operator {
+	case "$or":
+		return matchedAny, nil
+	case "$nor":
+		return !matchedAny, nil
+	}
+	return true, nil
+}
+
+// matchesCondition evaluates the condition on one field, an object of operators or an implicit $eq
+func matchesCondition(doc interface{}, field string, value interface{}, found bool, condition interface{}) (bool, error) {
+	operators, isObject := condition.(map[string]interface{})
+	if !isObject || len(operators) == 0 {
+		return found && reflect.DeepEqual(value, condition), nil
+	}
+	for operator, operand := range operators {
+		if !strings.HasPrefix(operator, "$") {
+			// sub-field selector, eg. {"amount": {"value": 1}}
+			return matches(doc, map[string]interface{}{field + "." + operator: operand})
+		}
+		ok, err := applyOperator(operator, value, found, operand)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func applyOperator(operator string, value interface{}, found bool, operand interface{}) (bool, error) {
+	switch operator {
+	case "$exists":
+		want, _ := operand.(bool)
+		return found == want, nil
+	case "$eq":
+		return found && reflect.DeepEqual(value, operand), nil
+	case "$ne":
+		return !found || !reflect.DeepEqual(value, operand), nil
+	case "$gt", "$gte", "$lt", "$lte":
+		if !found {
+			return false, nil
+		}
+		c, ok := compare(value, operand)
+		if !ok {
+			return false, nil
+		}
+		switch operator {
+		case "$gt":
+			return c > 0, nil
+		case "$gte":
+			return c >= 0, nil
+		case "$lt":
+			return c < 0, nil
+		}
+		return c <= 0, nil
+	case "$in", "$nin":
+		candidates, ok := operand.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s expects an array", operator)
+		}
+		in := false
+		for _, candidate := range candidates {
+			if found && reflect.DeepEqual(value, candidate) {
+				in = true
+				break
+			}
+		}
+		if operator == "$in" {
+			return in, nil
+		}
+		return !in, nil
+	case "$regex":
+		pattern, ok := operand.(string)
+		if !ok {
+			return false, fmt.Errorf("$regex expects a string")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid $regex %q: %v", pattern, err)
+		}
+		text, ok := value.(string)
+		return found && ok && re.MatchString(text), nil
+	case "$size":
+		size, ok := operand.(float64)
+		array, isArray := value.([]interface{})
+		return ok && isArray && float64(len(array)) == size, nil
+	}
+	return false, fmt.Errorf("unsupported operator %s", operator)
+}
@@ -0,0 +1,103 @@
+package fabrictest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// docs are the documents of the query tests, by key
+var docs = map[string]string{
+	"LC1":  `{"doc_type":"LoC","bank":"Org1","amount":100,"active":true,"tags":["a","b"],"terms":{"days":30}}`,
+	"LC2":  `{"doc_type":"LoC","bank":"Org2","amount":250,"active":false,"tags":["a"],"terms":{"days":60}}`,
+	"LC3":  `{"doc_type":"LoC","bank":"Org1","amount":50,"active":true}`,
+	"DOC1": `{"doc_type":"Presentation","bank":"Org1"}`,
+	"junk": `not json`,
+}
+
+func TestGetQueryResult(t *testing.T) {
+	stub := NewStub()
+	for key, doc := range docs {
+		_ = stub.PutState(key, []byte(doc))
+	}
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"implicit $eq", `{"selector":{"doc_type":"LoC"}}`, []string{"LC1", "LC2", "LC3"}},
+		{"$eq & $ne", `{"selector":{"doc_type":{"$eq":"LoC"},"bank":{"$ne":"Org1"}}}`, []string{"LC2"}},
+		{"range", `{"selector":{"amount":{"$gt":50,"$lte":250}}}`, []string{"LC1", "LC2"}},
+		{"ranges of other types", `{"selector":{"amount":{"$gte":"0"}}}`, []string{}},
+		{"$in", `{"selector":{"bank":{"$in":["Org2","Org3"]}}}`, []string{"LC2"}},
+		{"$nin", `{"selector":{"doc_type":"LoC","bank":{"$nin":["Org2"]}}}`, []string{"LC1", "LC3"}},
+		{"$exists", `{"selector":{"tags":{"$exists":false},"doc_type":"LoC"}}`, []string{"LC3"}},
+		{"$size", `{"selector":{"tags":{"$size":2}}}`, []string{"LC1"}},
+		{"$regex", `{"selector":{"bank":{"$regex":"^Org[2-9]$"}}}`, []string{"LC2"}},
+		{"$or", `{"selector":{"$or":[{"amount":50},{"active":false}]}}`, []string{"LC2", "LC3"}},
+		{"$and", `{"selector":{"$and":[{"bank":"Org1"},{"doc_type":"LoC"}],"active":true}}`, []string{"LC1", "LC3"}},
+		{"$nor", `{"selector":{"doc_type":"LoC","$nor":[{"bank":"Org2"},{"amount":50}]}}`, []string{"LC1"}},
+		{"$not", `{"selector":{"doc_type":"LoC","$not":{"bank":"Org1"}}}`, []string{"LC2"}},
+		{"dotted field", `{"selector":{"terms.days":{"$gt":30}}}`, []string{"LC2"}},
+		{"sub-field", `{"selector":{"terms":{"days":30}}}`, []string{"LC1"}},
+		{"sort", `{"selector":{"doc_type":"LoC"},"sort":[{"amount":"desc"}]}`, []string{"LC2", "LC1", "LC3"}},
+		{"sort on two fields", `{"selector":{"doc_type":"LoC"},"sort":["bank",{"amount":"asc"}],"use_index":["_design/x","x"]}`, []string{"LC3", "LC1", "LC2"}},
+		{"skip & limit", `{"selector":{"doc_type":"LoC"},"skip":1,"limit":1}`, []string{"LC2"}},
+	}
+	for _, tt := range tests {
+		it, err := stub.GetQueryResult(tt.query)
+		if err != nil {
+			t.Errorf("%s: GetQueryResult: %v", tt.name, err)
+			continue
+		}
+		if got := keys(t, it); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: GetQueryResult(%s) = %v, want %v", tt.name, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestGetQueryResultErrors(t *testing.T) {
+	stub := NewStub()
+	_ = stub.PutState("LC1", []byte(docs["LC1"]))
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{`{"selector":`, "invalid query"},
+		{`{"sort":["bank"]}`, "selector is required"},
+		{`{"selector":{"bank":{"$like":"Org%"}}}`, "unsupported operator $like"},
+		{`{"selector":{"bank":{"$in":"Org1"}}}`, "$in expects an array"},
+		{`{"selector":{"$or":{"bank":"Org1"}}}`, "$or expects an array of selectors"},
+		{`{"selector":{"bank":{"$regex":"("}}}`, "invalid $regex"},
+	}
+	for _, tt := range tests {
+		_, err := stub.GetQueryResult(tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("GetQueryResult(%s) error = %v, want it to contain %q", tt.query, err, tt.err)
+		}
+	}
+}
+
+func TestGetQueryResultWithPagination(t *testing.T) {
+	stub := NewStub()
+	for key, doc := range docs {
+		_ = stub.PutState(key, []byte(doc))
+	}
+	query := `{"selector":{"doc_type":"LoC"}}`
+	var got []string
+	bookmark := ""
+	for page := 0; page < 3; page++ {
+		it, metadata, err := stub.GetQueryResultWithPagination(query, 2, bookmark)
+		if err != nil {
+			t.Fatalf("page %d: %v", page+1, err)
+		}
+		got = append(got, keys(t, it)...)
+		bookmark = metadata.Bookmark
+	}
+	if !reflect.DeepEqual(got, []string{"LC1", "LC2", "LC3"}) {
+		t.Errorf("pages = %v", got)
+	}
+	if _, _, err := stub.GetQueryResultWithPagination(query, 2, "page2"); err == nil {
+		t.Errorf("GetQueryResultWithPagination with an invalid bookmark")
+	}
+}
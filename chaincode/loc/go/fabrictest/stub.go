@@ -0,0 +1,385 @@
+// Package fabrictest provides an in-memory ChaincodeStubInterface & transaction context for unit testing chaincode
+// without a running peer. World state, composite keys, history, events, transient data, private data and
+// CouchDB style Mango rich queries are supported. Unlike a peer, writes are visible to reads of the same transaction.
+package fabrictest
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// compositeKeyNamespace prefixes every composite key, same as the shim
+const compositeKeyNamespace = "\x00"
+
+// Event is a chaincode event set by a transaction
+type Event struct {
+	TxID    string
+	Name    string
+	Payload []byte
+}
+
+// Stub is an in-memory shim.ChaincodeStubInterface. Start each transaction with StartTransaction.
+type Stub struct {
+	ChannelID string
+	TxID      string
+	TxTime    time.Time
+	Args      [][]byte
+	Transient map[string][]byte
+	Creator   []byte
+
+	state       map[string][]byte
+	history     map[string][]*queryresult.KeyModification
+	privateData map[string]map[string][]byte
+	events      []Event
+	txCount     int
+}
+
+var _ shim.ChaincodeStubInterface = (*Stub)(nil)
+
+// NewStub returns an empty stub on channel "mychannel" with a first transaction started
+func NewStub() *Stub {
+	stub := &Stub{
+		ChannelID:   "mychannel",
+		state:       make(map[string][]byte),
+		history:     make(map[string][]*queryresult.KeyModification),
+		privateData: make(map[string]map[string][]byte),
+	}
+	stub.StartTransaction(time.Date(2022, time.January, 5, 10, 0, 0, 0, time.UTC))
+	return stub
+}
+
+// StartTransaction begins a new transaction at txTime with a fresh tx ID & no transient data
+func (s *Stub) StartTransaction(txTime time.Time) string {
+	s.txCount++
+	s.TxID = fmt.Sprintf("tx%04d", s.txCount)
+	s.TxTime = txTime
+	s.Transient = nil
+	return s.TxID
+}
+
+// Events returns every event set so far, one per transaction at most
+func (s *Stub) Events() []Event {
+	return append([]Event(nil), s.events...)
+}
+
+// LastEvent returns the most recent event, ok is false if none was set
+func (s *Stub) LastEvent() (event Event, ok bool) {
+	if len(s.events) == 0 {
+		return Event{}, false
+	}
+	return s.events[len(s.events)-1], true
+}
+
+// State returns a copy of the world state
+func (s *Stub) State() map[string][]byte {
+	state := make(map[string][]byte, len(s.state))
+	for key, value := range s.state {
+		state[key] = value
+	}
+	return state
+}
+
+func (s *Stub) GetArgs() [][]byte {
+	return s.Args
+}
+
+func (s *Stub) GetStringArgs() []string {
+	args := make([]string, 0, len(s.Args))
+	for _, arg := range s.Args {
+		args = append(args, string(arg))
+	}
+	return args
+}
+
+func (s *Stub) GetFunctionAndParameters() (string, []string) {
+	args := s.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+	return args[0], args[1:]
+}
+
+func (s *Stub) GetArgsSlice() ([]byte, error) {
+	var slice []byte
+	for _, arg := range s.Args {
+		slice = append(slice, arg...)
+	}
+	return slice, nil
+}
+
+func (s *Stub) GetTxID() string {
+	return s.TxID
+}
+
+func (s *Stub) GetChannelID() string {
+	return s.ChannelID
+}
+
+func (s *Stub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) pb.Response {
+	return shim.Error("fabrictest: InvokeChaincode is not supported")
+}
+
+func (s *Stub) GetState(key string) ([]byte, error) {
+	return s.state[key], nil
+}
+
+func (s *Stub) PutState(key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("key must not be an empty string")
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	s.state[key] = value
+	return s.recordHistory(key, value, false)
+}
+
+func (s *Stub) DelState(key string) error {
+	if _, ok := s.state[key]; !ok {
+		return nil
+	}
+	delete(s.state, key)
+	return s.recordHistory(key, nil, true)
+}
+
+func (s *Stub) recordHistory(key string, value []byte, isDelete bool) error {
+	ts, err := ptypes.TimestampProto(s.TxTime)
+	if err != nil {
+		return err
+	}
+	s.history[key] = append(s.history[key], &queryresult.KeyModification{TxId: s.TxID, Value: value, Timestamp: ts, IsDelete: isDelete})
+	return nil
+}
+
+func (s *Stub) SetStateValidationParameter(key string, ep []byte) error {
+	return nil
+}
+
+func (s *Stub) GetStateValidationParameter(key string) ([]byte, error) {
+	return nil, nil
+}
+
+// sortedKeys returns the keys of state accepted by keep, in key order
+func sortedKeys(state map[string][]byte, keep func(key string) bool) []string {
+	keys := make([]string, 0)
+	for key := range state {
+		if keep(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// kvs returns the entries of state under keys
+func kvs(state map[string][]byte, keys []string) []*queryresult.KV {
+	results := make([]*queryresult.KV, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, &queryresult.KV{Key: key, Value: state[key]})
+	}
+	return results
+}
+
+// rangeKeys returns the simple keys of state in [startKey, endKey), an empty endKey is unbounded
+func rangeKeys(state map[string][]byte, startKey, endKey string) []string {
+	return sortedKeys(state, func(key string) bool {
+		if strings.HasPrefix(key, compositeKeyNamespace) {
+			return false
+		}
+		return key >= startKey && (endKey == "" || key < endKey)
+	})
+}
+
+// partialCompositeKeys returns the composite keys of state starting with objectType & attributes
+func partialCompositeKeys(state map[string][]byte, objectType string, attributes []string) ([]string, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return sortedKeys(state, func(key string) bool { return strings.HasPrefix(key, prefix) }), nil
+}
+
+func (s *Stub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	return newStateIterator(kvs(s.state, rangeKeys(s.state, startKey, endKey))), nil
+}
+
+func (s *Stub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	page, metadata, err := paginate(rangeKeys(s.state, startKey, endKey), pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newStateIterator(kvs(s.state, page)), metadata, nil
+}
+
+func (s *Stub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	compositeKeys, err := partialCompositeKeys(s.state, objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(kvs(s.state, compositeKeys)), nil
+}
+
+func (s *Stub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	compositeKeys, err := partialCompositeKeys(s.state, objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	page, metadata, err := paginate(compositeKeys, pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newStateIterator(kvs(s.state, page)), metadata, nil
+}
+
+func (s *Stub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (s *Stub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	if !strings.HasPrefix(compositeKey, compositeKeyNamespace) || !strings.HasSuffix(compositeKey, "\x00") {
+		return "", nil, fmt.Errorf("invalid composite key %q", compositeKey)
+	}
+	components := strings.Split(compositeKey[1:len(compositeKey)-1], "\x00")
+	return components[0], components[1:], nil
+}
+
+func (s *Stub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	keys, err := runQuery(s.state, query)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(kvs(s.state, keys)), nil
+}
+
+func (s *Stub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	keys, err := runQuery(s.state, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	page, metadata, err := paginate(keys, pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newStateIterator(kvs(s.state, page)), metadata, nil
+}
+
+// GetHistoryForKey returns every write of key, newest first like Fabric v2
+func (s *Stub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	modifications := s.history[key]
+	newestFirst := make([]*queryresult.KeyModification, 0, len(modifications))
+	for i := len(modifications) - 1; i >= 0; i-- {
+		newestFirst = append(newestFirst, modifications[i])
+	}
+	return &HistoryIterator{modifications: newestFirst}, nil
+}
+
+func (s *Stub) collection(collection string) map[string][]byte {
+	if s.privateData[collection] == nil {
+		s.privateData[collection] = make(map[string][]byte)
+	}
+	return s.privateData[collection]
+}
+
+func (s *Stub) GetPrivateData(collection, key string) ([]byte, error) {
+	return s.collection(collection)[key], nil
+}
+
+func (s *Stub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	value, ok := s.collection(collection)[key]
+	if !ok {
+		return nil, nil
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+func (s *Stub) PutPrivateData(collection string, key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("key must not be an empty string")
+	}
+	s.collection(collection)[key] = value
+	return nil
+}
+
+func (s *Stub) DelPrivateData(collection, key string) error {
+	delete(s.collection(collection), key)
+	return nil
+}
+
+func (s *Stub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	return nil
+}
+
+func (s *Stub) GetPrivateDataValidationParameter(collection, key string) ([]byte, error) {
+	return nil, nil
+}
+
+func (s *Stub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	state := s.collection(collection)
+	return newStateIterator(kvs(state, rangeKeys(state, startKey, endKey))), nil
+}
+
+func (s *Stub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	state := s.collection(collection)
+	compositeKeys, err := partialCompositeKeys(state, objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(kvs(state, compositeKeys)), nil
+}
+
+func (s *Stub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	state := s.collection(collection)
+	keys, err := runQuery(state, query)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(kvs(state, keys)), nil
+}
+
+func (s *Stub) GetCreator() ([]byte, error) {
+	return s.Creator, nil
+}
+
+func (s *Stub) GetTransient() (map[string][]byte, error) {
+	return s.Transient, nil
+}
+
+func (s *Stub) GetBinding() ([]byte, error) {
+	return nil, nil
+}
+
+func (s *Stub) GetDecorations() map[string][]byte {
+	return nil
+}
+
+func (s *Stub) GetSignedProposal() (*pb.SignedProposal, error) {
+	return nil, nil
+}
+
+func (s *Stub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return ptypes.TimestampProto(s.TxTime)
+}
+
+// SetEvent sets the event of the current transaction, like a peer only the last event set in a transaction is kept
+func (s *Stub) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return fmt.Errorf("event name can not be empty string")
+	}
+	event := Event{TxID: s.TxID, Name: name, Payload: payload}
+	if last, ok := s.LastEvent(); ok && last.TxID == s.TxID {
+		s.events[len(s.events)-1] = event
+		return nil
+	}
+	s.events = append(s.events, event)
+	return nil
+}
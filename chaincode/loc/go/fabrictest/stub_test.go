@@ -0,0 +1,198 @@
+package fabrictest
+
+import (
+	"crypto/sha256"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// keys drains it & returns the keys it iterated over
+func keys(t *testing.T, it shim.StateQueryIteratorInterface) []string {
+	t.Helper()
+	defer it.Close()
+	keys := make([]string, 0)
+	for it.HasNext() {
+		kv, err := it.Next()
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		keys = append(keys, kv.Key)
+	}
+	return keys
+}
+
+func TestState(t *testing.T) {
+	stub := NewStub()
+	for _, key := range []string{"b", "a", "c"} {
+		if err := stub.PutState(key, []byte(key)); err != nil {
+			t.Fatalf("PutState: %v", err)
+		}
+	}
+	if err := stub.PutState("", []byte("x")); err == nil {
+		t.Errorf("PutState of an empty key")
+	}
+	if value, _ := stub.GetState("a"); string(value) != "a" {
+		t.Errorf("GetState(a) = %q", value)
+	}
+	if value, _ := stub.GetState("z"); value != nil {
+		t.Errorf("GetState(z) = %q, want nil", value)
+	}
+	if err := stub.DelState("b"); err != nil {
+		t.Fatalf("DelState: %v", err)
+	}
+	tests := []struct {
+		start, end string
+		want       []string
+	}{
+		{"", "", []string{"a", "c"}},
+		{"b", "", []string{"c"}},
+		{"a", "c", []string{"a"}},
+	}
+	for _, tt := range tests {
+		it, _ := stub.GetStateByRange(tt.start, tt.end)
+		if got := keys(t, it); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetStateByRange(%q, %q) = %v, want %v", tt.start, tt.end, got, tt.want)
+		}
+	}
+	it, metadata, err := stub.GetStateByRangeWithPagination("", "", 1, "1")
+	if got := keys(t, it); err != nil || !reflect.DeepEqual(got, []string{"c"}) || metadata.Bookmark != "2" || metadata.FetchedRecordsCount != 1 {
+		t.Errorf("GetStateByRangeWithPagination = %v, %+v, %v", got, metadata, err)
+	}
+}
+
+func TestCompositeKeys(t *testing.T) {
+	stub := NewStub()
+	for _, attributes := range [][]string{{"LC1", "000002"}, {"LC1", "000001"}, {"LC10", "000001"}, {"LC2", "000001"}} {
+		key, err := stub.CreateCompositeKey("loc~seq", attributes)
+		if err != nil {
+			t.Fatalf("CreateCompositeKey: %v", err)
+		}
+		_ = stub.PutState(key, []byte(attributes[1]))
+	}
+	_ = stub.PutState("LC1", []byte("{}"))
+	it, _ := stub.GetStateByPartialCompositeKey("loc~seq", []string{"LC1"})
+	got := keys(t, it)
+	if len(got) != 2 {
+		t.Fatalf("GetStateByPartialCompositeKey(LC1) = %q", got)
+	}
+	objectType, attributes, err := stub.SplitCompositeKey(got[0])
+	if err != nil || objectType != "loc~seq" || !reflect.DeepEqual(attributes, []string{"LC1", "000001"}) {
+		t.Errorf("SplitCompositeKey = %s, %v, %v", objectType, attributes, err)
+	}
+	if _, _, err := stub.SplitCompositeKey("LC1"); err == nil {
+		t.Errorf("SplitCompositeKey of a simple key")
+	}
+	// composite keys are not in ranges of simple keys
+	it, _ = stub.GetStateByRange("", "")
+	if got := keys(t, it); !reflect.DeepEqual(got, []string{"LC1"}) {
+		t.Errorf("GetStateByRange = %q", got)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	stub := NewStub()
+	start := stub.TxTime
+	_ = stub.PutState("LC1", []byte("v1"))
+	tx2 := stub.StartTransaction(start.Add(time.Hour))
+	_ = stub.PutState("LC1", []byte("v2"))
+	stub.StartTransaction(start.Add(2 * time.Hour))
+	_ = stub.DelState("LC1")
+	it, _ := stub.GetHistoryForKey("LC1")
+	defer it.Close()
+	var got []string
+	for it.HasNext() {
+		modification, _ := it.Next()
+		got = append(got, string(modification.Value))
+		if string(modification.Value) == "v2" && (modification.TxId != tx2 || modification.Timestamp.Seconds != start.Add(time.Hour).Unix()) {
+			t.Errorf("modification v2 = %+v", modification)
+		}
+		if modification.IsDelete != (len(got) == 1) {
+			t.Errorf("modification %d IsDelete = %t", len(got), modification.IsDelete)
+		}
+	}
+	// newest first
+	if !reflect.DeepEqual(got, []string{"", "v2", "v1"}) {
+		t.Errorf("GetHistoryForKey = %q", got)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	stub := NewStub()
+	if _, ok := stub.LastEvent(); ok {
+		t.Errorf("LastEvent of a new stub")
+	}
+	_ = stub.SetEvent("First", []byte("1"))
+	_ = stub.SetEvent("Second", []byte("2"))
+	tx2 := stub.StartTransaction(stub.TxTime.Add(time.Second))
+	_ = stub.SetEvent("Third", []byte("3"))
+	if err := stub.SetEvent("", nil); err == nil {
+		t.Errorf("SetEvent without name")
+	}
+	events := stub.Events()
+	if len(events) != 2 || events[0].Name != "Second" || events[1].Name != "Third" || events[1].TxID != tx2 {
+		t.Errorf("Events = %+v, want the last event of each transaction", events)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	stub := NewStub()
+	stub.Transient = map[string][]byte{"secret": []byte("x")}
+	txTime := time.Date(2022, time.April, 11, 6, 16, 0, 0, time.UTC)
+	txID := stub.StartTransaction(txTime)
+	if stub.GetTxID() != txID || txID == "tx0001" {
+		t.Errorf("StartTransaction = %s, tx ID %s", txID, stub.GetTxID())
+	}
+	if transient, _ := stub.GetTransient(); transient != nil {
+		t.Errorf("transient data %v outlived its transaction", transient)
+	}
+	timestamp, _ := stub.GetTxTimestamp()
+	if timestamp.Seconds != txTime.Unix() {
+		t.Errorf("GetTxTimestamp = %v, want %s", timestamp, txTime)
+	}
+	stub.Args = [][]byte{[]byte("IssueLoC"), []byte("{}")}
+	if function, params := stub.GetFunctionAndParameters(); function != "IssueLoC" || !reflect.DeepEqual(params, []string{"{}"}) {
+		t.Errorf("GetFunctionAndParameters = %s, %v", function, params)
+	}
+}
+
+func TestPrivateData(t *testing.T) {
+	stub := NewStub()
+	_ = stub.PutPrivateData("collectionLoCOrg1Org2", "LC1", []byte(`{"amount":"1000.00"}`))
+	_ = stub.PutPrivateData("collectionLoCOrg1Org2", "LC2", []byte(`{"amount":"5.00"}`))
+	if value, _ := stub.GetPrivateData("collectionLoCOrg1Org3", "LC1"); value != nil {
+		t.Errorf("GetPrivateData of another collection = %q", value)
+	}
+	if _, ok := stub.State()["LC1"]; ok {
+		t.Errorf("private data is on the world state")
+	}
+	hash, _ := stub.GetPrivateDataHash("collectionLoCOrg1Org2", "LC1")
+	if want := sha256.Sum256([]byte(`{"amount":"1000.00"}`)); !reflect.DeepEqual(hash, want[:]) {
+		t.Errorf("GetPrivateDataHash = %x, want %x", hash, want)
+	}
+	it, _ := stub.GetPrivateDataQueryResult("collectionLoCOrg1Org2", `{"selector":{"amount":{"$gt":"2"}}}`)
+	if got := keys(t, it); !reflect.DeepEqual(got, []string{"LC2"}) {
+		t.Errorf("GetPrivateDataQueryResult = %q", got)
+	}
+	_ = stub.DelPrivateData("collectionLoCOrg1Org2", "LC1")
+	if hash, _ := stub.GetPrivateDataHash("collectionLoCOrg1Org2", "LC1"); hash != nil {
+		t.Errorf("GetPrivateDataHash of deleted data = %x", hash)
+	}
+}
+
+func TestTransactionContext(t *testing.T) {
+	ctx := NewTransactionContext(NewStub(), NewClientIdentity("Org1MSP", "user1", map[string]string{"loc.role": "maker"}))
+	mspID, _ := ctx.GetClientIdentity().GetMSPID()
+	id, _ := ctx.GetClientIdentity().GetID()
+	if mspID != "Org1MSP" || id != "x509::CN=user1,OU=client::CN=ca.Org1MSP" {
+		t.Errorf("client identity %s, %s", mspID, id)
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue("loc.role", "maker"); err != nil {
+		t.Errorf("AssertAttributeValue: %v", err)
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue("loc.role", "checker"); err == nil {
+		t.Errorf("AssertAttributeValue of another value")
+	}
+}
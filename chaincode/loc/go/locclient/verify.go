@@ -0,0 +1,126 @@
+// Package locclient has helpers for client applications of the LoC chaincode
+package locclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Evaluator evaluates a transaction on the LoC chaincode & returns its result,
+// *client.Contract of github.com/hyperledger/fabric-gateway/pkg/client satisfies it
+type Evaluator interface {
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+}
+
+// Document is a document anchored on an LoC, see chaincode.Document
+type Document struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+	Type   string `json:"type"`
+}
+
+// Verification is the result of VerifyDocument, see chaincode.DocumentVerification
+type Verification struct {
+	LoCID    string   `json:"loc_id"`
+	DocIndex int      `json:"doc_index"`
+	Document Document `json:"document"` // as anchored on the ledger
+	SHA256   string   `json:"sha256"`   // digest of the downloaded file
+	Matches  bool     `json:"matches"`
+}
+
+// Verifier downloads documents presented under LoCs & checks them against the digests anchored on the ledger
+type Verifier struct {
+	Contract Evaluator
+	// HTTPClient downloads documents, http.DefaultClient if nil
+	HTTPClient *http.Client
+	// IPFSGateway resolves ipfs:// urls, eg. "https://ipfs.io/ipfs/"
+	IPFSGateway string
+}
+
+// NewVerifier returns a Verifier evaluating on contract, downloading with http.DefaultClient & resolving ipfs:// urls on ipfs.io
+func NewVerifier(contract Evaluator) *Verifier {
+	return &Verifier{Contract: contract, IPFSGateway: "https://ipfs.io/ipfs/"}
+}
+
+// HashFile returns the hex encoded SHA-256 digest of everything read from r
+func HashFile(r io.Reader) (string, error) {
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, r); err != nil {
+		return "", fmt.Errorf("failed to hash file: %v", err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// Document reads document {docIndex} of LoC {locID} from the ledger
+func (v *Verifier) Document(locID string, docIndex int) (*Document, error) {
+	locJSON, err := v.Contract.EvaluateTransaction("GetLoCById", locID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate GetLoCById: %v", err)
+	}
+	var loc struct {
+		DocsUrls  []string   `json:"docs_urls"`
+		Documents []Document `json:"documents"`
+	}
+	if err := json.Unmarshal(locJSON, &loc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	// documents presented before digests were anchored only have a url, they come first
+	offset := len(loc.DocsUrls) - len(loc.Documents)
+	if docIndex < offset || docIndex >= len(loc.DocsUrls) {
+		return nil, fmt.Errorf("document %d of LoC with Id@%s does not exist or has no digest", docIndex, locID)
+	}
+	return &loc.Documents[docIndex-offset], nil
+}
+
+// Download fetches the file behind url & returns its hex encoded SHA-256 digest
+func (v *Verifier) Download(ctx context.Context, url string) (string, error) {
+	if strings.HasPrefix(url, "ipfs://") {
+		url = v.IPFSGateway + strings.TrimPrefix(url, "ipfs://")
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request for %s: %v", url, err)
+	}
+	httpClient := v.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %v", url, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s", url, response.Status)
+	}
+	return HashFile(response.Body)
+}
+
+// VerifyDocument downloads document {docIndex} of LoC {locID}, hashes it locally & checks the digest
+// with the VerifyDocument query of the chaincode. Matches is false if the file changed since it was presented.
+func (v *Verifier) VerifyDocument(ctx context.Context, locID string, docIndex int) (*Verification, error) {
+	document, err := v.Document(locID, docIndex)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := v.Download(ctx, document.URL)
+	if err != nil {
+		return nil, err
+	}
+	verificationJSON, err := v.Contract.EvaluateTransaction("VerifyDocument", locID, strconv.Itoa(docIndex), digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate VerifyDocument: %v", err)
+	}
+	var verification Verification
+	if err := json.Unmarshal(verificationJSON, &verification); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal from Json: %v", err)
+	}
+	return &verification, nil
+}
@@ -0,0 +1,121 @@
+package locclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/chaincode"
+	"sample.com/lc/fabrictest"
+)
+
+// contract evaluates queries of the LoC chaincode on an in-memory ledger, like the gateway would
+type contract struct {
+	contract *chaincode.LocContract
+	ctx      contractapi.TransactionContextInterface
+}
+
+func (c contract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	var result interface{}
+	var err error
+	switch name {
+	case "GetLoCById":
+		result, err = c.contract.GetLoCById(c.ctx, args[0])
+	case "VerifyDocument":
+		docIndex, _ := strconv.Atoi(args[1])
+		result, err = c.contract.VerifyDocument(c.ctx, args[0], docIndex, args[2])
+	default:
+		return nil, fmt.Errorf("unexpected transaction %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// newContract issues LoC LC1 with documents {docs} presented by Org2
+func newContract(t *testing.T, docs string) contract {
+	t.Helper()
+	stub := fabrictest.NewStub()
+	org1 := &chaincode.TransactionContext{TransactionContext: *fabrictest.NewTransactionContext(stub, fabrictest.NewClientIdentity("Org1MSP", "user1", nil))}
+	org2 := &chaincode.TransactionContext{TransactionContext: *fabrictest.NewTransactionContext(stub, fabrictest.NewClientIdentity("Org2MSP", "user2", nil))}
+	c := &chaincode.LocContract{}
+	locJSON, _ := json.Marshal(map[string]interface{}{
+		"ID": "LC1", "documentary_credit_number": "LC1", "applicant_bank": "Org1", "beneficiary": "POSCO INDIA PROCESSING CENTER PVT",
+		"currency_code": "INR", "date_of_issue": "20220105", "date_of_expiry": "20220221",
+		"advise_through_bank": "Org2", "negotiating_bank": "Org2", "reimbursing_bank": "Org1",
+	})
+	stub.Transient = map[string][]byte{"loc_private": []byte(`{"applicant":"ACME IMPORTS PVT LTD","amount":"1000"}`)}
+	if _, err := c.IssueLoC(org1, string(locJSON)); err != nil {
+		t.Fatalf("IssueLoC: %v", err)
+	}
+	stub.StartTransaction(stub.TxTime.Add(time.Hour))
+	if _, err := c.AcknowledgeLoCIssuance(org2, "LC1"); err != nil {
+		t.Fatalf("AcknowledgeLoCIssuance: %v", err)
+	}
+	stub.StartTransaction(stub.TxTime.Add(time.Hour))
+	if _, err := c.SubmitDocuments(org2, "LC1", docs); err != nil {
+		t.Fatalf("SubmitDocuments: %v", err)
+	}
+	return contract{contract: c, ctx: org1}
+}
+
+func TestHashFile(t *testing.T) {
+	digest, err := HashFile(strings.NewReader("hello"))
+	if want := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"; err != nil || digest != want {
+		t.Errorf("HashFile = %s, %v, want %s", digest, err, want)
+	}
+}
+
+func TestVerifyDocument(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/invoice.pdf", "/ipfs/QmInvoice":
+			fmt.Fprint(w, "hello")
+		case "/bill-of-lading.pdf":
+			fmt.Fprint(w, "changed since it was presented")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	digest, _ := HashFile(strings.NewReader("hello"))
+	docs, _ := json.Marshal([]Document{
+		{URL: server.URL + "/invoice.pdf", SHA256: digest, Type: "INVOICE"},
+		{URL: server.URL + "/bill-of-lading.pdf", SHA256: digest, Type: "BILL_OF_LADING"},
+		{URL: "ipfs://QmInvoice", SHA256: digest, Type: "INVOICE"},
+		{URL: server.URL + "/missing.pdf", SHA256: digest, Type: "INSURANCE"},
+	})
+	verifier := NewVerifier(newContract(t, string(docs)))
+	verifier.IPFSGateway = server.URL + "/ipfs/"
+	tests := []struct {
+		docIndex int
+		matches  bool
+		err      string
+	}{
+		{0, true, ""},
+		{1, false, ""},
+		{2, true, ""},
+		{3, false, "404 Not Found"},
+		{4, false, "document 4 of LoC with Id@LC1 does not exist"},
+	}
+	for _, tt := range tests {
+		verification, err := verifier.VerifyDocument(context.Background(), "LC1", tt.docIndex)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("VerifyDocument(%d) error = %v, want it to contain %q", tt.docIndex, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || verification.Matches != tt.matches || verification.DocIndex != tt.docIndex || verification.LoCID != "LC1" {
+			t.Errorf("VerifyDocument(%d) = %+v, %v, want matches %t", tt.docIndex, verification, err, tt.matches)
+		}
+	}
+}
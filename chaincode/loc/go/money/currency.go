@@ -0,0 +1,55 @@
+package money
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//go:embed iso4217.csv
+var iso4217CSV string
+
+// Currency is an ISO 4217 currency
+type Currency struct {
+	Code       string // alphabetic code, eg. INR
+	MinorUnits int    // decimals of an amount, eg. 2 for INR, 0 for JPY, 3 for KWD
+}
+
+// currencies holds the currencies of the embedded ISO 4217 table, by code
+var currencies = loadCurrencies(iso4217CSV)
+
+// loadCurrencies parses the ISO 4217 table, the table is part of the binary so any error is a bug
+func loadCurrencies(table string) map[string]Currency {
+	reader := csv.NewReader(strings.NewReader(table))
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("invalid ISO 4217 table: %v", err))
+	}
+	loaded := make(map[string]Currency, len(records))
+	for _, record := range records[1:] {
+		minorUnits, err := strconv.Atoi(record[1])
+		if err != nil {
+			panic(fmt.Sprintf("invalid ISO 4217 table: minor units of %s: %v", record[0], err))
+		}
+		loaded[record[0]] = Currency{Code: record[0], MinorUnits: minorUnits}
+	}
+	return loaded
+}
+
+// LookupCurrency returns the ISO 4217 currency with alphabetic code
+func LookupCurrency(code string) (Currency, error) {
+	currency, ok := currencies[code]
+	if !ok {
+		return Currency{}, fmt.Errorf("%q is not an ISO 4217 currency code", code)
+	}
+	return currency, nil
+}
+
+// IsCurrency reports whether code is an active ISO 4217 currency code
+func IsCurrency(code string) bool {
+	_, ok := currencies[code]
+	return ok
+}
@@ -0,0 +1,296 @@
+// Package money has an exact amount of an ISO 4217 currency, with the number of decimals of its minor units
+// taken from the embedded ISO 4217 table & arithmetic that fails instead of overflowing
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount of a currency, eg. {"amount":"11436300.00","currency":"INR"}. Amount is an exact decimal in major
+// units with as many decimals as the currency has minor units, it never goes through a float. Build Money with Parse,
+// New or FromMajor, they check & normalize it. The zero Money has no amount at all, see IsEmpty.
+type Money struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// New returns units minor units of currency code, eg. New(1143630050, "INR") for INR 11436300.50
+func New(units int64, code string) (Money, error) {
+	currency, err := LookupCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: format(units, currency.MinorUnits), Currency: code}, nil
+}
+
+// FromMajor returns major whole units of currency code, eg. FromMajor(11436300, "INR") for INR 11436300.00
+func FromMajor(major int64, code string) (Money, error) {
+	currency, err := LookupCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+	units, err := mul(major, pow10(currency.MinorUnits))
+	if err != nil {
+		return Money{}, fmt.Errorf("%s %d: %v", code, major, err)
+	}
+	return Money{Amount: format(units, currency.MinorUnits), Currency: code}, nil
+}
+
+// Parse returns decimal amount of currency code, eg. Parse("11436300.5", "INR"). Amounts with more decimals than
+// the minor units of the currency are rejected unless the extra decimals are zeros.
+func Parse(amount string, code string) (Money, error) {
+	currency, err := LookupCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+	units, err := parseUnits(amount, currency.MinorUnits)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: format(units, currency.MinorUnits), Currency: code}, nil
+}
+
+// parseUnits returns decimal amount in minor units
+func parseUnits(amount string, minorUnits int) (int64, error) {
+	whole, fraction, hasPoint := strings.Cut(amount, ".")
+	digits := strings.TrimPrefix(whole, "-")
+	if !isDigits(digits) || (hasPoint && !isDigits(fraction)) {
+		return 0, fmt.Errorf("%q is not a decimal amount, eg. 11436300.50", amount)
+	}
+	if len(fraction) > minorUnits {
+		if strings.TrimRight(fraction[minorUnits:], "0") != "" {
+			return 0, fmt.Errorf("%q has more than %d decimals", amount, minorUnits)
+		}
+		fraction = fraction[:minorUnits]
+	}
+	fraction += strings.Repeat("0", minorUnits-len(fraction))
+	units, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is out of range", amount)
+	}
+	return units, nil
+}
+
+// isDigits reports whether s is a non empty run of decimal digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// format renders units minor units as a decimal with minorUnits decimals
+func format(units int64, minorUnits int) string {
+	digits := strconv.FormatInt(units, 10)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if minorUnits == 0 {
+		return sign + digits
+	}
+	if len(digits) <= minorUnits {
+		digits = strings.Repeat("0", minorUnits-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-minorUnits] + "." + digits[len(digits)-minorUnits:]
+}
+
+// pow10 returns 10^n for the small n of minor units
+func pow10(n int) int64 {
+	power := int64(1)
+	for i := 0; i < n; i++ {
+		power *= 10
+	}
+	return power
+}
+
+// add returns a + b, or an error if it overflows
+func add(a int64, b int64) (int64, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, fmt.Errorf("amount out of range")
+	}
+	return a + b, nil
+}
+
+// mul returns a * b, or an error if it overflows
+func mul(a int64, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, fmt.Errorf("amount out of range")
+	}
+	return product, nil
+}
+
+// IsEmpty reports whether m is the zero Money, which has no amount at all, not even 0
+func (m Money) IsEmpty() bool {
+	return m == Money{}
+}
+
+// MinorUnits returns the decimals of the currency of m
+func (m Money) MinorUnits() (int, error) {
+	currency, err := LookupCurrency(m.Currency)
+	if err != nil {
+		return 0, err
+	}
+	return currency.MinorUnits, nil
+}
+
+// Units returns m in minor units, eg. 1143630050 for INR 11436300.50
+func (m Money) Units() (int64, error) {
+	minorUnits, err := m.MinorUnits()
+	if err != nil {
+		return 0, err
+	}
+	return parseUnits(m.Amount, minorUnits)
+}
+
+// Validate checks m is an amount of an ISO 4217 currency with the decimals of the currency
+func (m Money) Validate() error {
+	_, err := m.Units()
+	return err
+}
+
+// WithCurrency returns m as an amount of currency code. A bare amount without currency, as amounts were
+// stored before they carried one, is taken in code. Money of another currency is not converted but rejected.
+func (m Money) WithCurrency(code string) (Money, error) {
+	if m.Currency != "" && m.Currency != code {
+		return Money{}, fmt.Errorf("%s is not an amount in %s", m, code)
+	}
+	return Parse(m.Amount, code)
+}
+
+// units returns the minor units of m & other, which must be of the same currency
+func (m Money) units(other Money) (int64, int64, int, error) {
+	if m.Currency != other.Currency {
+		return 0, 0, 0, fmt.Errorf("currency %s of %s differs from currency %s", other.Currency, other, m.Currency)
+	}
+	minorUnits, err := m.MinorUnits()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	a, err := parseUnits(m.Amount, minorUnits)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	b, err := parseUnits(other.Amount, minorUnits)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return a, b, minorUnits, nil
+}
+
+// Add returns m + other, both of the same currency
+func (m Money) Add(other Money) (Money, error) {
+	a, b, minorUnits, err := m.units(other)
+	if err != nil {
+		return Money{}, err
+	}
+	sum, err := add(a, b)
+	if err != nil {
+		return Money{}, fmt.Errorf("%s + %s: %v", m, other, err)
+	}
+	return Money{Amount: format(sum, minorUnits), Currency: m.Currency}, nil
+}
+
+// Sub returns m - other, both of the same currency
+func (m Money) Sub(other Money) (Money, error) {
+	a, b, minorUnits, err := m.units(other)
+	if err != nil {
+		return Money{}, err
+	}
+	if b == math.MinInt64 {
+		return Money{}, fmt.Errorf("%s - %s: amount out of range", m, other)
+	}
+	difference, err := add(a, -b)
+	if err != nil {
+		return Money{}, fmt.Errorf("%s - %s: %v", m, other, err)
+	}
+	return Money{Amount: format(difference, minorUnits), Currency: m.Currency}, nil
+}
+
+// Cmp compares m & other, both of the same currency, it returns -1 if m < other, 0 if equal & +1 if m > other
+func (m Money) Cmp(other Money) (int, error) {
+	a, b, _, err := m.units(other)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case a < b:
+		return -1, nil
+	case a > b:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Sign returns -1 if m is negative, 0 if it is zero & +1 if it is positive
+func (m Money) Sign() (int, error) {
+	units, err := m.Units()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case units < 0:
+		return -1, nil
+	case units > 0:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Percent returns percent % of m, rounded toward zero to the minor unit, eg. a tolerance of 10% on the amount of an LoC
+func (m Money) Percent(percent int) (Money, error) {
+	units, err := m.Units()
+	if err != nil {
+		return Money{}, err
+	}
+	product, err := mul(units, int64(percent))
+	if err != nil {
+		return Money{}, fmt.Errorf("%d%% of %s: %v", percent, m, err)
+	}
+	minorUnits, _ := m.MinorUnits()
+	return Money{Amount: format(product/100, minorUnits), Currency: m.Currency}, nil
+}
+
+// String renders m as currency & amount, eg. "INR 11436300.00"
+func (m Money) String() string {
+	return strings.TrimSpace(m.Currency + " " + m.Amount)
+}
+
+// UnmarshalJSON reads Money objects & the bare amounts stored before amounts carried their currency, eg. 11436300
+// or "11436300.50". A bare amount has no currency, its holder sets it with WithCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		type plain Money // without UnmarshalJSON
+		return json.Unmarshal(trimmed, (*plain)(m))
+	}
+	var amount json.Number
+	if len(trimmed) > 0 && trimmed[0] == '"' {
+		var text string
+		if err := json.Unmarshal(trimmed, &text); err != nil {
+			return err
+		}
+		amount = json.Number(text)
+	} else if err := json.Unmarshal(trimmed, &amount); err != nil {
+		return err
+	}
+	*m = Money{Amount: amount.String()}
+	return nil
+}
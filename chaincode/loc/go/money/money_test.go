@@ -0,0 +1,287 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+// errorString returns the message of err, empty without error
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount  string
+		code    string
+		want    string
+		wantErr string
+	}{
+		{"11436300.5", "INR", "11436300.50", ""},
+		{"11436300", "INR", "11436300.00", ""},
+		{"0.05", "INR", "0.05", ""},
+		{"-12.3", "INR", "-12.30", ""},
+		{"-0.05", "INR", "-0.05", ""},
+		{"1.500", "INR", "1.50", ""},
+		{"1.0", "JPY", "1", ""},
+		{"1.234", "KWD", "1.234", ""},
+		{"92233720368547758.07", "INR", "92233720368547758.07", ""},
+		{"1.505", "INR", "", `"1.505" has more than 2 decimals`},
+		{"1.5", "JPY", "", `"1.5" has more than 0 decimals`},
+		{"1.2345", "KWD", "", `"1.2345" has more than 3 decimals`},
+		{"1e6", "INR", "", `"1e6" is not a decimal amount, eg. 11436300.50`},
+		{".5", "INR", "", `".5" is not a decimal amount, eg. 11436300.50`},
+		{"1.", "INR", "", `"1." is not a decimal amount, eg. 11436300.50`},
+		{"-", "INR", "", `"-" is not a decimal amount, eg. 11436300.50`},
+		{"", "INR", "", `"" is not a decimal amount, eg. 11436300.50`},
+		{"1,000.00", "INR", "", `"1,000.00" is not a decimal amount, eg. 11436300.50`},
+		{"92233720368547758.08", "INR", "", `"92233720368547758.08" is out of range`},
+		{"1", "RS", "", `"RS" is not an ISO 4217 currency code`},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.amount, tt.code)
+		if errorString(err) != tt.wantErr || got.Amount != tt.want {
+			t.Errorf("Parse(%q, %s) = %+v, %v, want %q, %q", tt.amount, tt.code, got, err, tt.want, tt.wantErr)
+		}
+		if err == nil && got.Currency != tt.code {
+			t.Errorf("Parse(%q, %s) has currency %s", tt.amount, tt.code, got.Currency)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		units   int64
+		code    string
+		want    string
+		wantErr string
+	}{
+		{1143630050, "INR", "11436300.50", ""},
+		{-5, "INR", "-0.05", ""},
+		{0, "INR", "0.00", ""},
+		{7, "JPY", "7", ""},
+		{1, "KWD", "0.001", ""},
+		{math.MinInt64, "INR", "-92233720368547758.08", ""},
+		{1, "RS", "", `"RS" is not an ISO 4217 currency code`},
+	}
+	for _, tt := range tests {
+		got, err := New(tt.units, tt.code)
+		if errorString(err) != tt.wantErr || got.Amount != tt.want {
+			t.Errorf("New(%d, %s) = %+v, %v, want %q, %q", tt.units, tt.code, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFromMajor(t *testing.T) {
+	tests := []struct {
+		major   int64
+		code    string
+		want    string
+		wantErr string
+	}{
+		{11436300, "INR", "11436300.00", ""},
+		{-3, "KWD", "-3.000", ""},
+		{math.MaxInt64, "JPY", "9223372036854775807", ""},
+		{math.MaxInt64 / 10, "INR", "", "INR 922337203685477580: amount out of range"},
+		{math.MinInt64 / 10, "INR", "", "INR -922337203685477580: amount out of range"},
+		{1, "RS", "", `"RS" is not an ISO 4217 currency code`},
+	}
+	for _, tt := range tests {
+		got, err := FromMajor(tt.major, tt.code)
+		if errorString(err) != tt.wantErr || got.Amount != tt.want {
+			t.Errorf("FromMajor(%d, %s) = %+v, %v, want %q, %q", tt.major, tt.code, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func(a, b int64) (int64, error)
+		a, b int64
+		want int64
+		ok   bool
+	}{
+		{"add", add, 1, 2, 3, true},
+		{"add", add, math.MaxInt64, -1, math.MaxInt64 - 1, true},
+		{"add", add, math.MinInt64, math.MaxInt64, -1, true},
+		{"add", add, math.MaxInt64, 1, 0, false},
+		{"add", add, math.MinInt64, -1, 0, false},
+		{"mul", mul, 3, -4, -12, true},
+		{"mul", mul, 0, math.MinInt64, 0, true},
+		{"mul", mul, math.MinInt64, 1, math.MinInt64, true},
+		{"mul", mul, math.MaxInt64/2 + 1, 2, 0, false},
+		{"mul", mul, math.MaxInt64, -2, 0, false},
+		{"mul", mul, -1, math.MinInt64, 0, false},
+		{"mul", mul, math.MinInt64, -1, 0, false},
+	}
+	for _, tt := range tests {
+		got, err := tt.op(tt.a, tt.b)
+		if got != tt.want || (err == nil) != tt.ok || (err != nil && err.Error() != "amount out of range") {
+			t.Errorf("%s(%d, %d) = %d, %v, want %d, ok %t", tt.name, tt.a, tt.b, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+// inr returns amount of INR
+func inr(amount string) Money {
+	return Money{Amount: amount, Currency: "INR"}
+}
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      func(m, other Money) (Money, error)
+		m       Money
+		other   Money
+		want    Money
+		wantErr string
+	}{
+		{"add", Money.Add, inr("1.50"), inr("2.75"), inr("4.25"), ""},
+		{"add negative", Money.Add, inr("-1.00"), inr("0.50"), inr("-0.50"), ""},
+		{"add legacy decimals", Money.Add, inr("1000"), inr("0.5"), inr("1000.50"), ""},
+		{"add overflow", Money.Add, inr("92233720368547758.07"), inr("0.01"), Money{}, "INR 92233720368547758.07 + INR 0.01: amount out of range"},
+		{"add currencies", Money.Add, inr("1.00"), Money{Amount: "1.00", Currency: "USD"}, Money{}, "currency USD of USD 1.00 differs from currency INR"},
+		{"add invalid", Money.Add, inr("1.00"), inr("1.001"), Money{}, `"1.001" has more than 2 decimals`},
+		{"sub", Money.Sub, inr("1.00"), inr("2.50"), inr("-1.50"), ""},
+		{"sub overflow", Money.Sub, inr("-92233720368547758.08"), inr("0.01"), Money{}, "INR -92233720368547758.08 - INR 0.01: amount out of range"},
+		{"sub min", Money.Sub, inr("0.00"), inr("-92233720368547758.08"), Money{}, "INR 0.00 - INR -92233720368547758.08: amount out of range"},
+		{"sub currencies", Money.Sub, Money{Amount: "1", Currency: "JPY"}, inr("1.00"), Money{}, "currency INR of INR 1.00 differs from currency JPY"},
+	}
+	for _, tt := range tests {
+		got, err := tt.op(tt.m, tt.other)
+		if errorString(err) != tt.wantErr || got != tt.want {
+			t.Errorf("%s %s, %s = %+v, %v, want %+v, %q", tt.name, tt.m, tt.other, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCmp(t *testing.T) {
+	tests := []struct {
+		m       Money
+		other   Money
+		want    int
+		wantErr string
+	}{
+		{inr("1.00"), inr("1.0"), 0, ""},
+		{inr("1.00"), inr("2.00"), -1, ""},
+		{inr("2.00"), inr("1.99"), 1, ""},
+		{inr("-2.00"), inr("1.00"), -1, ""},
+		{inr("1.00"), Money{Amount: "1.00", Currency: "USD"}, 0, "currency USD of USD 1.00 differs from currency INR"},
+	}
+	for _, tt := range tests {
+		got, err := tt.m.Cmp(tt.other)
+		if errorString(err) != tt.wantErr || got != tt.want {
+			t.Errorf("%s.Cmp(%s) = %d, %v, want %d, %q", tt.m, tt.other, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		m       Money
+		percent int
+		want    Money
+		wantErr string
+	}{
+		{inr("1000.00"), 10, inr("100.00"), ""},
+		{inr("0.19"), 10, inr("0.01"), ""},
+		{inr("-0.19"), 10, inr("-0.01"), ""},
+		{inr("1000.00"), 0, inr("0.00"), ""},
+		{Money{Amount: "15", Currency: "JPY"}, 10, Money{Amount: "1", Currency: "JPY"}, ""},
+		{inr("92233720368547758.07"), 200, Money{}, "200% of INR 92233720368547758.07: amount out of range"},
+		{inr("1.001"), 10, Money{}, `"1.001" has more than 2 decimals`},
+	}
+	for _, tt := range tests {
+		got, err := tt.m.Percent(tt.percent)
+		if errorString(err) != tt.wantErr || got != tt.want {
+			t.Errorf("%s.Percent(%d) = %+v, %v, want %+v, %q", tt.m, tt.percent, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestWithCurrency(t *testing.T) {
+	tests := []struct {
+		m       Money
+		want    Money
+		wantErr string
+	}{
+		{Money{Amount: "1000"}, inr("1000.00"), ""},
+		{inr("1000.5"), inr("1000.50"), ""},
+		{Money{Amount: "1", Currency: "USD"}, Money{}, "USD 1 is not an amount in INR"},
+		{Money{Amount: "1.001"}, Money{}, `"1.001" has more than 2 decimals`},
+	}
+	for _, tt := range tests {
+		got, err := tt.m.WithCurrency("INR")
+		if errorString(err) != tt.wantErr || got != tt.want {
+			t.Errorf("%+v.WithCurrency(INR) = %+v, %v, want %+v, %q", tt.m, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Money
+		wantErr bool
+	}{
+		{"object", `{"amount":"11436300.50","currency":"INR"}`, inr("11436300.50"), false},
+		{"object without currency", ` {"amount":"1"} `, Money{Amount: "1"}, false},
+		{"legacy number", `11436300`, Money{Amount: "11436300"}, false},
+		{"legacy decimal number", `11436300.5`, Money{Amount: "11436300.5"}, false},
+		{"legacy string", `"11436300.50"`, Money{Amount: "11436300.50"}, false},
+		{"null", `null`, Money{}, false},
+		{"bool", `true`, Money{}, true},
+		{"bad object", `{"amount":1}`, Money{}, true},
+	}
+	for _, tt := range tests {
+		var holder struct {
+			Amount Money `json:"amount"`
+		}
+		err := json.Unmarshal([]byte(`{"amount":`+tt.data+`}`), &holder)
+		if (err != nil) != tt.wantErr || holder.Amount != tt.want {
+			t.Errorf("%s: Unmarshal(%s) = %+v, %v, want %+v", tt.name, tt.data, holder.Amount, err, tt.want)
+		}
+	}
+	data, err := json.Marshal(inr("1.50"))
+	if err != nil || string(data) != `{"amount":"1.50","currency":"INR"}` {
+		t.Errorf("Marshal = %s, %v", data, err)
+	}
+}
+
+func TestLoadCurrencies(t *testing.T) {
+	rows := 0
+	for _, line := range strings.Split(strings.TrimSpace(iso4217CSV), "\n") {
+		if !strings.HasPrefix(line, "#") && line != "code,minor_units" {
+			rows++
+		}
+	}
+	if rows == 0 || len(currencies) != rows {
+		t.Errorf("loaded %d currencies of %d rows", len(currencies), rows)
+	}
+	for code, currency := range currencies {
+		if currency.Code != code || len(code) != 3 || strings.ToUpper(code) != code || currency.MinorUnits < 0 || currency.MinorUnits > 4 {
+			t.Errorf("currency %s = %+v", code, currency)
+		}
+	}
+	for code, minorUnits := range map[string]int{"INR": 2, "USD": 2, "EUR": 2, "JPY": 0, "KWD": 3} {
+		if currency, err := LookupCurrency(code); err != nil || currency.MinorUnits != minorUnits {
+			t.Errorf("LookupCurrency(%s) = %+v, %v, want %d minor units", code, currency, err, minorUnits)
+		}
+	}
+	if IsCurrency("RS") || !IsCurrency("INR") {
+		t.Errorf("IsCurrency of RS or INR is wrong")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("loadCurrencies of an invalid table did not panic")
+		}
+	}()
+	loadCurrencies("code,minor_units\nXXX,two\n")
+}
@@ -0,0 +1,154 @@
+package swift
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"sample.com/lc/chaincode"
+	"sample.com/lc/events"
+	"sample.com/lc/locclient"
+)
+
+// ErrNoMessage is returned by FormatEnvelope for the events no MT message is sent for
+var ErrNoMessage = errors.New("no MT message is sent for the event")
+
+// EventMessages are the MT messages the applicant bank sends for the events of the LoC chaincode. Waived
+// discrepancies are followed by DocumentsAccepted in the same batch, its MT752 is the one sent.
+var EventMessages = map[string]string{
+	"LoCIssued":            "700",
+	"LoCAmendmentProposed": "707",
+	"DiscrepanciesRaised":  "734",
+	"DocumentsRefused":     "734",
+	"DocumentsAccepted":    "752",
+}
+
+// FormatEvents renders the MT messages for a chaincode event of the LoC chaincode with name & payload, as set by SetEvent,
+// one for each of the events it carries that has one, eg. of an LoCEvents batch, see events.Unpack & FormatEnvelope
+func FormatEvents(name string, payload []byte, contract locclient.Evaluator) ([]string, error) {
+	envelopes, err := events.Unpack(name, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s event: %v", name, err)
+	}
+	messages := make([]string, 0)
+	for i := range envelopes {
+		text, err := FormatEnvelope(&envelopes[i], contract)
+		if errors.Is(err, ErrNoMessage) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, text)
+	}
+	return messages, nil
+}
+
+// FormatEnvelope renders the MT message for an event of the LoC chaincode, ErrNoMessage for events without one, see
+// EventMessages. Envelopes tell what changed, not the terms of the LoC, contract evaluates GetLoCById, GetLoCAmendments
+// & GetLoCPresentation to read them. It has to be a contract of the applicant bank for the private details, eg. the
+// amount claimed by a presentation, LoCs are read as they are when the event is formatted.
+func FormatEnvelope(envelope *events.Envelope, contract locclient.Evaluator) (string, error) {
+	name := envelope.Type
+	mt, ok := EventMessages[name]
+	if !ok {
+		return "", ErrNoMessage
+	}
+	if contract == nil {
+		return "", fmt.Errorf("%s event only tells what changed, a contract is needed to read the LoC with Id@%s", name, envelope.LoCID)
+	}
+	loc, err := readLoC(contract, envelope.LoCID)
+	if err != nil {
+		return "", err
+	}
+	switch mt {
+	case "700":
+		return FormatMT700(loc)
+	case "707":
+		var event chaincode.Amendment
+		ok, err := envelope.DecodeDetails(&event)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", fmt.Errorf("%s event has no amendment", name)
+		}
+		amendment, err := readAmendment(contract, loc.ID, &event)
+		if err != nil {
+			return "", err
+		}
+		return FormatMT707(loc, amendment)
+	}
+	var presentation chaincode.Presentation
+	ok, err = envelope.DecodeDetails(&presentation)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%s event has no presentation", name)
+	}
+	// events do not carry the private amount claimed, it is read as the contract sees it
+	claimed, err := readPresentation(contract, loc.ID, presentation.Seq)
+	if err != nil {
+		return "", err
+	}
+	presentation.Amount = claimed.Amount
+	if mt == "752" {
+		return FormatMT752(loc, &presentation)
+	}
+	return FormatMT734(loc, &presentation)
+}
+
+// readPresentation returns presentation {seq} of LoC with given {id} as read by contract, with the amount claimed if
+// contract shares the private details of the LoC
+func readPresentation(contract locclient.Evaluator, id string, seq int) (*chaincode.Presentation, error) {
+	presentationJSON, err := contract.EvaluateTransaction("GetLoCPresentation", id, strconv.Itoa(seq))
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate GetLoCPresentation: %v", err)
+	}
+	var presentation chaincode.Presentation
+	if err := json.Unmarshal(presentationJSON, &presentation); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal presentation from Json: %v", err)
+	}
+	return &presentation, nil
+}
+
+// readLoC returns the LoC with given {id} as read by contract, with its private details if contract shares them
+func readLoC(contract locclient.Evaluator, id string) (*chaincode.LoC, error) {
+	locJSON, err := contract.EvaluateTransaction("GetLoCById", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate GetLoCById: %v", err)
+	}
+	var loc chaincode.LoC
+	if err := json.Unmarshal(locJSON, &loc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal LoC from Json: %v", err)
+	}
+	return &loc, nil
+}
+
+// readAmendment returns amendment with the values of its private fields as read by contract, amendment itself
+// if it has none
+func readAmendment(contract locclient.Evaluator, id string, amendment *chaincode.Amendment) (*chaincode.Amendment, error) {
+	redacted := false
+	for _, change := range amendment.Changes {
+		redacted = redacted || isRedacted(change)
+	}
+	if !redacted {
+		return amendment, nil
+	}
+	amendmentsJSON, err := contract.EvaluateTransaction("GetLoCAmendments", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate GetLoCAmendments: %v", err)
+	}
+	var amendments []*chaincode.Amendment
+	if err := json.Unmarshal(amendmentsJSON, &amendments); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal amendments from Json: %v", err)
+	}
+	for _, full := range amendments {
+		if full.Seq == amendment.Seq {
+			return full, nil
+		}
+	}
+	return nil, fmt.Errorf("amendment #%d of LoC with Id@%s not found", amendment.Seq, id)
+}
@@ -0,0 +1,164 @@
+package swift
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"sample.com/lc/chaincode"
+	"sample.com/lc/events"
+	"sample.com/lc/locclient"
+	"sample.com/lc/money"
+)
+
+// ledger evaluates transactions of the applicant bank on a fixed LoC, its amendments & presentations
+type ledger struct {
+	loc           *chaincode.LoC
+	amendments    []*chaincode.Amendment
+	presentations []*chaincode.Presentation
+}
+
+func (l *ledger) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	switch name {
+	case "GetLoCById":
+		return json.Marshal(l.loc)
+	case "GetLoCAmendments":
+		return json.Marshal(l.amendments)
+	case "GetLoCPresentation":
+		for _, presentation := range l.presentations {
+			if fmt.Sprint(presentation.Seq) == args[1] {
+				return json.Marshal(presentation)
+			}
+		}
+		return nil, fmt.Errorf("presentation #%s of LoC with Id@%s does not exist", args[1], args[0])
+	}
+	return nil, fmt.Errorf("unexpected transaction %s", name)
+}
+
+// mustJSON marshals v
+func mustJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// eventOf returns the envelope of event name on LoC {id}, with details
+func eventOf(t *testing.T, name string, id string, details interface{}) *events.Envelope {
+	t.Helper()
+	envelope := &events.Envelope{SchemaVersion: events.SchemaVersion, Type: name, LoCID: id, Changes: make([]events.Change, 0)}
+	if details != nil {
+		envelope.Details = mustJSON(t, details)
+	}
+	return envelope
+}
+
+func TestFormatEnvelope(t *testing.T) {
+	envelope := amendedLoC()
+	full := amendedLoC()
+	full.Applicant = "AMBER ENTERPRISES INDIA LTD"
+	full.Beneficiary = "POSCO INDIA PROCESSING CENTER PVT"
+	full.Amount = money.Money{Amount: "11436300.00", Currency: "INR"}
+	amendment := &chaincode.Amendment{DocType: "Amendment", LoCID: full.ID, Seq: 1, ProposedAt: "2022-04-11T11:46:00+05:30",
+		Changes: []chaincode.FieldChange{{Field: "amount", OldValue: "11436300.00", NewValue: "12000000.00"}, {Field: "shipment_period", OldValue: "", NewValue: "MARCH 2022"}}}
+	redacted := *amendment
+	redacted.Changes = []chaincode.FieldChange{{Field: "amount"}, amendment.Changes[1]}
+	presentation := &chaincode.Presentation{LoCID: full.ID, Seq: 1, Status: chaincode.PresentationRefused, RefusalReason: "WAIVER DECLINED",
+		Discrepancies: []chaincode.Discrepancy{{Code: "LATE_SHIPMENT", Description: "SHIPPED 20220215"}},
+		History:       []chaincode.PresentationStatusEvent{{ToStatus: chaincode.PresentationPresented, Timestamp: "2022-02-01T10:00:00+05:30"}}}
+	// events carry presentations without the amount claimed
+	claimed := *presentation
+	claimed.Amount = money.Money{Amount: "5000000.00", Currency: "INR"}
+	contract := &ledger{loc: full, amendments: []*chaincode.Amendment{amendment}, presentations: []*chaincode.Presentation{&claimed}}
+	// a bank that does not share the private details reads the envelope, redacted amendments & presentations
+	public := &ledger{loc: envelope, amendments: []*chaincode.Amendment{&redacted}, presentations: []*chaincode.Presentation{presentation}}
+
+	tests := []struct {
+		name     string
+		event    string
+		details  interface{}
+		contract *ledger
+		want     string // a field of the message, or the error
+	}{
+		{"issued", "LoCIssued", nil, contract, ":50:AMBER ENTERPRISES INDIA LTD"},
+		{"issued, private details not read", "LoCIssued", nil, public, "applicant is required"},
+		{"issued, no contract", "LoCIssued", nil, nil, "a contract is needed to read the LoC with Id@" + full.ID},
+		{"amendment proposed, private values read", "LoCAmendmentProposed", &redacted, contract, ":32B:INR563700,00\r\n:44D:MARCH 2022"},
+		{"amendment proposed, private values not read", "LoCAmendmentProposed", &redacted, public, "amount was blanked as private"},
+		{"documents refused", "DocumentsRefused", presentation, contract, ":32A:220201INR5000000,00"},
+		{"documents refused, amount not read", "DocumentsRefused", presentation, public, "amount claimed by presentation #1 is not known"},
+		{"documents refused, no such presentation", "DocumentsRefused", &chaincode.Presentation{Seq: 2}, contract, "presentation #2 of LoC with Id@" + full.ID + " does not exist"},
+		{"event without presentation", "DocumentsAccepted", nil, contract, "DocumentsAccepted event has no presentation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var evaluator locclient.Evaluator
+			if tt.contract != nil {
+				evaluator = tt.contract
+			}
+			text, err := FormatEnvelope(eventOf(t, tt.event, full.ID, tt.details), evaluator)
+			got := text
+			if err != nil {
+				got = err.Error()
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("FormatEnvelope(%s) = %q, want it to contain %q", tt.event, got, tt.want)
+			}
+		})
+	}
+
+	_, err := FormatEnvelope(eventOf(t, "LoCClosed", full.ID, nil), contract)
+	if !errors.Is(err, ErrNoMessage) {
+		t.Errorf("FormatEnvelope(LoCClosed) error = %v, want ErrNoMessage", err)
+	}
+}
+
+func TestFormatEvents(t *testing.T) {
+	full := amendedLoC()
+	full.Applicant = "AMBER ENTERPRISES INDIA LTD"
+	full.Beneficiary = "POSCO INDIA PROCESSING CENTER PVT"
+	full.Amount = money.Money{Amount: "11436300.00", Currency: "INR"}
+	presentation := &chaincode.Presentation{LoCID: full.ID, Seq: 1, Status: chaincode.PresentationWaived,
+		History: []chaincode.PresentationStatusEvent{{ToStatus: chaincode.PresentationPresented, Timestamp: "2022-02-01T10:00:00+05:30"}}}
+	claimed := *presentation
+	claimed.Amount = money.Money{Amount: "5000000.00", Currency: "INR"}
+	contract := &ledger{loc: full, presentations: []*chaincode.Presentation{&claimed}}
+	batch := func(envelopes ...*events.Envelope) []byte {
+		b := events.Batch{SchemaVersion: events.SchemaVersion, Events: make([]events.Envelope, 0)}
+		for _, envelope := range envelopes {
+			b.Events = append(b.Events, *envelope)
+		}
+		return mustJSON(t, b)
+	}
+	tests := []struct {
+		name    string
+		payload []byte
+		want    []string // a field of each message
+	}{
+		{"waived, then accepted", batch(eventOf(t, "DiscrepanciesWaived", full.ID, presentation), eventOf(t, "DocumentsAccepted", full.ID, presentation)), []string{":32B:INR5000000,00"}},
+		{"no message", batch(eventOf(t, "LoCIssuanceAcknowledged", full.ID, nil)), []string{}},
+		{"two LoCs", batch(eventOf(t, "LoCIssued", full.ID, nil), eventOf(t, "LoCIssued", full.ID, nil)), []string{":50:AMBER", ":50:AMBER"}},
+	}
+	for _, tt := range tests {
+		messages, err := FormatEvents("LoCEvents", tt.payload, contract)
+		if err != nil || len(messages) != len(tt.want) {
+			t.Errorf("%s: FormatEvents = %q, %v, want %d messages", tt.name, messages, err, len(tt.want))
+			continue
+		}
+		for i, message := range messages {
+			if !strings.Contains(message, tt.want[i]) {
+				t.Errorf("%s: message %d = %q, want it to contain %q", tt.name, i+1, message, tt.want[i])
+			}
+		}
+	}
+	if _, err := FormatEvents("LoCEvents", []byte(`{"events":[]}`), contract); err == nil {
+		t.Errorf("FormatEvents of a batch without schema version")
+	}
+	if _, err := FormatEvents("LoCIssued", mustJSON(t, eventOf(t, "LoCIssued", full.ID, nil)), contract); err == nil {
+		t.Errorf("FormatEvents of a single envelope")
+	}
+}
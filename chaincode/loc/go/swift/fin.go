@@ -0,0 +1,182 @@
+// Package swift converts LoCs from & to the SWIFT MT messages of category 7, documentary credits, as exchanged
+// in the text block, block 4, of FIN messages. See mt700.go for how the fields of an LoC map onto MT700 tags, mt707.go,
+// mt734.go & mt752.go for the messages of amendments & presentations & events.go for the messages of chaincode events.
+package swift
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Field is a field of the text block of an MT message, eg. {Tag: "32B", Value: "INR11436300,00"}.
+// The lines of a field with several lines are separated by "\n".
+type Field struct {
+	Tag   string
+	Value string
+}
+
+// Message is an MT message, its type as read from the application header, block 2, & the fields of its text block in order
+type Message struct {
+	Type   string // eg. "700", empty if the message had no application header
+	Fields []Field
+}
+
+// FormatError lists why a value can not be rendered as an MT message, all violations are reported together
+type FormatError struct {
+	Violations []string
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("invalid MT message: %s", strings.Join(e.Violations, "; "))
+}
+
+func (e *FormatError) add(format string, args ...interface{}) {
+	e.Violations = append(e.Violations, fmt.Sprintf(format, args...))
+}
+
+// orNil returns e if it holds any violation, nil otherwise
+func (e *FormatError) orNil() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
+
+var (
+	// eg. {2:I700BANKINBBXXXXN} for a message sent, {2:O7001200220105BANKINBBAXXX...} for a message received
+	applicationHeader = regexp.MustCompile(`\{2:[IO](\d{3})`)
+	// eg. :32B:INR11436300,00
+	fieldLine = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+	// eg. BANKINBBXXX
+	bic = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+)
+
+// Parse reads an MT message, either a whole FIN message with its blocks, eg. "{1:...}{2:I700...}{4:\r\n:20:...\r\n-}",
+// or only the fields of its text block. Lines may end in "\r\n" or "\n".
+func Parse(text string) (*Message, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	message := &Message{}
+	if match := applicationHeader.FindStringSubmatch(text); match != nil {
+		message.Type = match[1]
+	}
+	body := text
+	if start := strings.Index(text, "{4:"); start >= 0 {
+		body = text[start+len("{4:"):]
+		end := strings.Index(body, "\n-}")
+		if end < 0 {
+			return nil, fmt.Errorf("text block {4: is not closed by -}")
+		}
+		body = body[:end]
+	} else {
+		body = strings.TrimSuffix(strings.TrimSpace(body), "-}")
+	}
+	for i, line := range strings.Split(body, "\n") {
+		if match := fieldLine.FindStringSubmatch(line); match != nil {
+			message.Fields = append(message.Fields, Field{Tag: match[1], Value: match[2]})
+			continue
+		}
+		if len(message.Fields) == 0 {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %q does not start with a field tag, eg. :20:", i+1, line)
+		}
+		// continuation of the field above
+		last := &message.Fields[len(message.Fields)-1]
+		last.Value += "\n" + line
+	}
+	if len(message.Fields) == 0 {
+		return nil, fmt.Errorf("text block has no fields")
+	}
+	return message, nil
+}
+
+// Field returns the first field with tag. A tag ending in a lower case letter, eg. "41a", matches any option of the
+// field, eg. 41A or 41D, as in the SWIFT standards.
+func (m *Message) Field(tag string) (Field, bool) {
+	for _, field := range m.Fields {
+		if field.Tag == tag || (strings.HasSuffix(tag, "a") && len(field.Tag) == 3 && field.Tag[:2] == tag[:2]) {
+			return field, true
+		}
+	}
+	return Field{}, false
+}
+
+// Format renders the text block of m, eg. "{4:\r\n:20:INLCU0100220001\r\n...\r\n-}", lines end in "\r\n" as on FIN.
+// The basic & application headers need the addresses of the banks, they are left to the SWIFT interface sending it.
+func (m *Message) Format() string {
+	var text strings.Builder
+	text.WriteString("{4:\r\n")
+	for _, field := range m.Fields {
+		text.WriteString(":" + field.Tag + ":" + strings.ReplaceAll(field.Value, "\n", "\r\n") + "\r\n")
+	}
+	text.WriteString("-}")
+	return text.String()
+}
+
+// xCharacters are the characters of the SWIFT x character set, besides letters & digits
+const xCharacters = "/-?:().,'+ "
+
+// zCharacters are the characters the SWIFT z character set adds to the x character set
+const zCharacters = "=!\"%&*<>;{@#_"
+
+// isSWIFTText reports whether value only has characters of the x character set, or of the z character set if z
+func isSWIFTText(value string, z bool) bool {
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '\n':
+		case strings.ContainsRune(xCharacters, r):
+		case z && strings.ContainsRune(zCharacters, r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// wrap splits value into lines of at most width characters, breaking at spaces where it can. Lines of value are kept.
+func wrap(value string, width int) []string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(value, "\n") {
+		for len(line) > width {
+			cut := strings.LastIndex(line[:width+1], " ")
+			if cut <= 0 {
+				lines = append(lines, line[:width])
+				line = line[width:]
+				continue
+			}
+			lines = append(lines, strings.TrimRight(line[:cut], " "))
+			line = strings.TrimLeft(line[cut:], " ")
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// narrative renders value as field tag of at most maxLines lines of width characters, in the x or z character set,
+// violations are added to verr
+func narrative(verr *FormatError, tag string, name string, value string, maxLines int, width int, z bool) Field {
+	if !isSWIFTText(value, z) {
+		verr.add("%s (%s) has characters SWIFT does not carry", name, tag)
+	}
+	lines := wrap(value, width)
+	if len(lines) > maxLines {
+		verr.add("%s (%s) needs %d lines of %d characters, at most %d are allowed", name, tag, len(lines), width, maxLines)
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, ":") || strings.HasPrefix(line, "-") {
+			verr.add("%s (%s) has a line starting with %q", name, tag, line[:1])
+			break
+		}
+	}
+	return Field{Tag: tag, Value: strings.Join(lines, "\n")}
+}
+
+// party renders bank as field tag, option A if it is a BIC, option D, name & address, otherwise
+func party(verr *FormatError, tag string, name string, bank string) Field {
+	if bic.MatchString(bank) {
+		return Field{Tag: tag + "A", Value: bank}
+	}
+	return narrative(verr, tag+"D", name, bank, 4, 35, false)
+}
@@ -0,0 +1,328 @@
+package swift
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"sample.com/lc/chaincode"
+	"sample.com/lc/money"
+)
+
+// Fields of an LoC on an MT700, Issue of a Documentary Credit
+//
+//	20  Documentary Credit Number       documentary_credit_number, also the ID of an LoC read
+//	40A Form of Documentary Credit      form_of_documentary_credit
+//	31C Date of Issue                   date_of_issue
+//	31D Date and Place of Expiry        date_of_expiry & place_of_expiry
+//	51a Applicant Bank                  applicant_bank
+//	50  Applicant                       applicant
+//	59  Beneficiary                     beneficiary
+//	32B Currency Code, Amount           currency_code & amount
+//	39A Percentage Credit Amount Tol.   tolerance_plus_percent & tolerance_minus_percent
+//	41a Available With ... By ...       available_with_by, eg. "ANY BANK IN INDIA BY NEGOTIATION"
+//	42C Drafts at ...                   drafts_at
+//	43P Partial Shipments               partial_shipments
+//	44A Dispatch/Taking in Charge from  loading_from, 44E Port of Loading when read
+//	44B For Transportation to ...       transportation_to, 44F Port of Discharge when read
+//	44C Latest Date of Shipment         latest_date_of_shipment
+//	44D Shipment Period                 shipment_period
+//	45A Description of Goods/Services   description_of_goods_and_services
+//	46A Documents Required              documents_required
+//	71D Charges                         charges
+//	48  Period for Presentation         period_for_presentation
+//	53a Reimbursing Bank                reimbursing_bank
+//	78  Instructions to the Paying/...  instructions_to_the_paying_or_accepting_or_negotiating_bank
+//	57a 'Advise Through' Bank           advise_through_bank
+//
+// The negotiating bank has no field of its own & the status of an LoC stays on the ledger.
+// 27 Sequence of Total, 40E Applicable Rules & 49 Confirmation Instructions are written as
+// "1/1", "UCP LATEST VERSION", "ISP LATEST VERSION" for a standby, & "WITHOUT". Only ISP rules are read, they make the LoC a standby.
+// Guarantees are not issued by MT700 but by MT760, FormatMT700 refuses them.
+
+// availableBy are the codes of the second subfield of field 41a
+var availableBy = []string{"BY ACCEPTANCE", "BY DEF PAYMENT", "BY MIXED PYMT", "BY NEGOTIATION", "BY PAYMENT"}
+
+// swiftDateLayout is the layout of SWIFT dates, YYMMDD
+const swiftDateLayout = "060102"
+
+// ParseMT700 reads an MT700 into an LoC ready to be issued, see IssueLoC. The LoC gets the documentary credit
+// number as ID & has no status yet. An MT700 sent in several messages, with MT701s, is not supported.
+func ParseMT700(text string) (*chaincode.LoC, error) {
+	message, err := Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if message.Type != "" && message.Type != "700" {
+		return nil, fmt.Errorf("message is an MT%s, not an MT700", message.Type)
+	}
+	for _, tag := range []string{"20", "40A", "31C", "31D", "50", "59", "32B"} {
+		if _, ok := message.Field(tag); !ok {
+			return nil, fmt.Errorf("MT700 has no field %s", tag)
+		}
+	}
+	loc := &chaincode.LoC{DocType: "LoC"}
+	for _, field := range message.Fields {
+		switch field.Tag {
+		case "20":
+			loc.ID = field.Value
+			loc.DocumentaryCreditNumber = field.Value
+		case "40A":
+			loc.FormOfDocumentaryCredit = field.Value
+		case "40E":
+			if strings.HasPrefix(field.Value, "ISP") {
+				loc.InstrumentType = chaincode.InstrumentStandby
+			}
+		case "31C":
+			loc.DateOfIssue, err = parseDate(field)
+		case "31D":
+			loc.DateOfExpiry, err = parseDate(field)
+			loc.PlaceOfExpiry = strings.TrimSpace(strings.TrimPrefix(field.Value, head(field.Value, len(swiftDateLayout))))
+		case "51A", "51D":
+			loc.ApplicantBank = field.Value
+		case "50":
+			loc.Applicant = field.Value
+		case "59":
+			loc.Beneficiary = field.Value
+		case "32B":
+			loc.CurrencyCode, loc.Amount, err = parseAmount(field)
+		case "39A":
+			loc.TolerancePlusPercent, loc.ToleranceMinusPercent, err = parseTolerance(field)
+		case "41A", "41D":
+			loc.AvailableWithBy = parseAvailableWithBy(field.Value)
+		case "42C":
+			loc.DraftsAt = field.Value
+		case "43P":
+			loc.PartialShipments = field.Value
+		case "44A", "44E":
+			if loc.LoadingFrom == "" {
+				loc.LoadingFrom = field.Value
+			}
+		case "44B", "44F":
+			if loc.TransportationTo == "" {
+				loc.TransportationTo = field.Value
+			}
+		case "44C":
+			loc.LatestDateOfShipment, err = parseDate(field)
+		case "44D":
+			loc.ShipmentPeriod = field.Value
+		case "45A":
+			loc.DescriptionOfGoodsAndServices = field.Value
+		case "46A":
+			loc.DocumentsRequired = field.Value
+		case "71D":
+			loc.Charges = field.Value
+		case "48":
+			loc.PeriodForPresentation = field.Value
+		case "53A", "53D":
+			loc.ReimbursingBank = field.Value
+		case "78":
+			loc.InstructionsToThePayingOrAcceptingOrNegotiatingBank = field.Value
+		case "57A", "57B", "57D":
+			loc.AdviseThroughBank = field.Value
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return loc, nil
+}
+
+// parseDate reads the YYMMDD date field starts with as an LoC date, YYYYMMDD
+func parseDate(field Field) (string, error) {
+	value := head(field.Value, len(swiftDateLayout))
+	date, err := time.Parse(swiftDateLayout, value)
+	if err != nil {
+		return "", fmt.Errorf("field %s: %q is not a date YYMMDD", field.Tag, value)
+	}
+	return date.Format(chaincode.LoCDateLayouts[0]), nil
+}
+
+// head returns the first n bytes of value, all of it if it is shorter
+func head(value string, n int) string {
+	if len(value) < n {
+		return value
+	}
+	return value[:n]
+}
+
+// parseAmount reads field 32B, eg. "INR11436300,00", SWIFT amounts have a decimal comma
+func parseAmount(field Field) (string, money.Money, error) {
+	if len(field.Value) < 4 {
+		return "", money.Money{}, fmt.Errorf("field %s: %q is not a currency & amount, eg. INR11436300,00", field.Tag, field.Value)
+	}
+	code := field.Value[:3]
+	amount := strings.TrimSuffix(strings.Replace(field.Value[3:], ",", ".", 1), ".")
+	parsed, err := money.Parse(amount, code)
+	if err != nil {
+		return "", money.Money{}, fmt.Errorf("field %s: %v", field.Tag, err)
+	}
+	return code, parsed, nil
+}
+
+// parseTolerance reads field 39A, eg. "10/05" for +10% & -5%
+func parseTolerance(field Field) (int, int, error) {
+	plus, minus, ok := strings.Cut(field.Value, "/")
+	plusPercent, err := strconv.Atoi(plus)
+	if ok && err == nil {
+		var minusPercent int
+		minusPercent, err = strconv.Atoi(minus)
+		if err == nil {
+			return plusPercent, minusPercent, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("field %s: %q is not a tolerance, eg. 10/10", field.Tag, field.Value)
+}
+
+// parseAvailableWithBy reads field 41a as a single text, eg. "ANY BANK IN INDIA\nBY NEGOTIATION" as "ANY BANK IN INDIA BY NEGOTIATION"
+func parseAvailableWithBy(value string) string {
+	cut := strings.LastIndex(value, "\n")
+	if cut >= 0 && isAvailableBy(value[cut+1:]) {
+		return value[:cut] + " " + value[cut+1:]
+	}
+	return value
+}
+
+// isAvailableBy reports whether value is a code of the second subfield of field 41a, eg. "BY NEGOTIATION"
+func isAvailableBy(value string) bool {
+	for _, code := range availableBy {
+		if value == code {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatMT700 renders loc as the text block of an MT700. Narrative fields are wrapped at spaces to the lines SWIFT
+// allows, values too long for their field or with characters SWIFT does not carry are reported together.
+func FormatMT700(loc *chaincode.LoC) (string, error) {
+	verr := &FormatError{}
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"documentary_credit_number", loc.DocumentaryCreditNumber},
+		{"form_of_documentary_credit", loc.FormOfDocumentaryCredit},
+		{"date_of_issue", loc.DateOfIssue},
+		{"date_of_expiry", loc.DateOfExpiry},
+		{"applicant", loc.Applicant},
+		{"beneficiary", loc.Beneficiary},
+		{"available_with_by", loc.AvailableWithBy},
+	}
+	for _, field := range required {
+		if strings.TrimSpace(field.value) == "" {
+			verr.add("%s is required", field.name)
+		}
+	}
+	if loc.InstrumentType == chaincode.InstrumentGuarantee {
+		verr.add("a guarantee is issued by MT760, not MT700")
+	}
+	message := &Message{Type: "700"}
+	add := func(field Field) {
+		message.Fields = append(message.Fields, field)
+	}
+	addText := func(tag string, name string, value string, maxLines int, width int, z bool) {
+		if value != "" {
+			add(narrative(verr, tag, name, value, maxLines, width, z))
+		}
+	}
+	add(Field{Tag: "27", Value: "1/1"})
+	addText("40A", "form_of_documentary_credit", loc.FormOfDocumentaryCredit, 1, 24, false)
+	number := loc.DocumentaryCreditNumber
+	if len(number) > 16 || !isSWIFTText(number, false) || strings.Contains(number, "\n") || strings.HasPrefix(number, "/") || strings.HasSuffix(number, "/") || strings.Contains(number, "//") {
+		verr.add("documentary_credit_number (20) must be at most 16 characters SWIFT carries, not start or end with / nor contain //")
+	}
+	add(Field{Tag: "20", Value: number})
+	add(Field{Tag: "31C", Value: formatDate(verr, "date_of_issue", loc.DateOfIssue)})
+	rules := "UCP LATEST VERSION"
+	if loc.InstrumentType == chaincode.InstrumentStandby {
+		rules = "ISP LATEST VERSION"
+	}
+	add(Field{Tag: "40E", Value: rules})
+	if len(loc.PlaceOfExpiry) > 29 || !isSWIFTText(loc.PlaceOfExpiry, false) || strings.Contains(loc.PlaceOfExpiry, "\n") {
+		verr.add("place_of_expiry (31D) must be one line of at most 29 characters SWIFT carries")
+	}
+	add(Field{Tag: "31D", Value: formatDate(verr, "date_of_expiry", loc.DateOfExpiry) + loc.PlaceOfExpiry})
+	if loc.ApplicantBank != "" {
+		add(party(verr, "51", "applicant_bank", loc.ApplicantBank))
+	}
+	addText("50", "applicant", loc.Applicant, 4, 35, false)
+	addText("59", "beneficiary", loc.Beneficiary, 4, 35, false)
+	add(Field{Tag: "32B", Value: formatAmount(verr, "32B", "amount", loc.Amount, loc.CurrencyCode)})
+	if loc.TolerancePlusPercent != 0 || loc.ToleranceMinusPercent != 0 {
+		add(Field{Tag: "39A", Value: fmt.Sprintf("%d/%d", loc.TolerancePlusPercent, loc.ToleranceMinusPercent)})
+	}
+	if loc.AvailableWithBy != "" {
+		add(formatAvailableWithBy(verr, loc.AvailableWithBy))
+	}
+	addText("42C", "drafts_at", loc.DraftsAt, 3, 35, false)
+	addText("43P", "partial_shipments", loc.PartialShipments, 1, 11, false)
+	addText("44A", "loading_from", loc.LoadingFrom, 1, 65, false)
+	addText("44B", "transportation_to", loc.TransportationTo, 1, 65, false)
+	if loc.LatestDateOfShipment != "" {
+		add(Field{Tag: "44C", Value: formatDate(verr, "latest_date_of_shipment", loc.LatestDateOfShipment)})
+	}
+	addText("44D", "shipment_period", loc.ShipmentPeriod, 6, 65, false)
+	addText("45A", "description_of_goods_and_services", loc.DescriptionOfGoodsAndServices, 100, 65, true)
+	addText("46A", "documents_required", loc.DocumentsRequired, 100, 65, true)
+	addText("71D", "charges", loc.Charges, 6, 35, true)
+	addText("48", "period_for_presentation", loc.PeriodForPresentation, 4, 35, false)
+	add(Field{Tag: "49", Value: "WITHOUT"})
+	if loc.ReimbursingBank != "" {
+		add(party(verr, "53", "reimbursing_bank", loc.ReimbursingBank))
+	}
+	addText("78", "instructions_to_the_paying_or_accepting_or_negotiating_bank", loc.InstructionsToThePayingOrAcceptingOrNegotiatingBank, 12, 65, false)
+	if loc.AdviseThroughBank != "" {
+		add(party(verr, "57", "advise_through_bank", loc.AdviseThroughBank))
+	}
+	if err := verr.orNil(); err != nil {
+		return "", err
+	}
+	return message.Format(), nil
+}
+
+// formatDate renders LoC date value as YYMMDD, violations are added to verr
+func formatDate(verr *FormatError, name string, value string) string {
+	for _, layout := range chaincode.LoCDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.Format(swiftDateLayout)
+		}
+	}
+	if value != "" {
+		verr.add("%s %q is not a date in format YYYYMMDD or YYYY-MM-DD", name, value)
+	}
+	return ""
+}
+
+// formatAmount renders amount of currency code as the currency & amount of field tag, eg. "INR11436300,00" for 32B,
+// SWIFT amounts have a decimal comma. Violations are added to verr.
+func formatAmount(verr *FormatError, tag string, name string, amount money.Money, code string) string {
+	amount, err := amount.WithCurrency(code)
+	if err != nil {
+		verr.add("%s (%s) %v", name, tag, err)
+		return ""
+	}
+	value := strings.Replace(amount.Amount, ".", ",", 1)
+	if !strings.Contains(value, ",") {
+		value += ","
+	}
+	if strings.HasPrefix(value, "-") || len(value) > 15 {
+		verr.add("%s (%s) %s must be positive with at most 15 characters", name, tag, amount)
+	}
+	return code + value
+}
+
+// formatAvailableWithBy renders available_with_by as field 41a, the bank then the code on a line of its own,
+// eg. "ANY BANK IN INDIA BY NEGOTIATION" as 41D "ANY BANK IN INDIA\nBY NEGOTIATION", violations are added to verr
+func formatAvailableWithBy(verr *FormatError, value string) Field {
+	cut := strings.LastIndex(value, " BY ")
+	if cut < 0 || !isAvailableBy(value[cut+1:]) {
+		verr.add("available_with_by (41a) must end in one of %s, got %q", strings.Join(availableBy, ", "), value)
+		return Field{Tag: "41D", Value: value}
+	}
+	bank, by := value[:cut], value[cut+1:]
+	field := party(verr, "41", "available_with_by", bank)
+	field.Value += "\n" + by
+	return field
+}
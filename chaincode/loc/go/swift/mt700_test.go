@@ -0,0 +1,201 @@
+package swift
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sample.com/lc/chaincode"
+	"sample.com/lc/money"
+)
+
+// readTestdata returns testdata file name with lines ending in "\r\n" as on FIN
+func readTestdata(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.ReplaceAll(strings.TrimSuffix(string(data), "\n"), "\n", "\r\n")
+}
+
+func TestMT700TextRoundTrip(t *testing.T) {
+	text := readTestdata(t, "mt700.txt")
+	loc, err := ParseMT700(text)
+	if err != nil {
+		t.Fatalf("ParseMT700: %v", err)
+	}
+	formatted, err := FormatMT700(loc)
+	if err != nil {
+		t.Fatalf("FormatMT700: %v", err)
+	}
+	if formatted != text {
+		t.Errorf("FormatMT700(ParseMT700(mt700.txt)) =\n%s\nwant\n%s", formatted, text)
+	}
+}
+
+func TestParseMT700Received(t *testing.T) {
+	loc, err := ParseMT700(readTestdata(t, "mt700_received.txt"))
+	if err != nil {
+		t.Fatalf("ParseMT700: %v", err)
+	}
+	want := &chaincode.LoC{
+		ID:                            "LC22US0042",
+		DocType:                       "LoC",
+		DocumentaryCreditNumber:       "LC22US0042",
+		FormOfDocumentaryCredit:       "IRREVOCABLE TRANSFERABLE",
+		DateOfIssue:                   "20220310",
+		DateOfExpiry:                  "20220630",
+		PlaceOfExpiry:                 "LONDON",
+		Applicant:                     "ACME IMPORTS INC\n1 MAIN STREET\nNEW YORK NY 10001",
+		Beneficiary:                   "/GB29NWBK60161331926819\nBRITISH MACHINE TOOLS LTD\n12 KING STREET, LEEDS",
+		CurrencyCode:                  "USD",
+		Amount:                        money.Money{Amount: "250000.00", Currency: "USD"},
+		TolerancePlusPercent:          5,
+		ToleranceMinusPercent:         5,
+		AvailableWithBy:               "BANKGB2LXXX BY PAYMENT",
+		DraftsAt:                      "SIGHT",
+		LoadingFrom:                   "SOUTHAMPTON",
+		TransportationTo:              "NEW YORK",
+		LatestDateOfShipment:          "20220531",
+		PartialShipments:              "NOT ALLOWED",
+		DescriptionOfGoodsAndServices: "+2 CNC LATHES MODEL KL-500\n+CIF NEW YORK, INCOTERMS 2020",
+		DocumentsRequired:             "+SIGNED COMMERCIAL INVOICE IN 3 ORIGINALS\n+FULL SET OF CLEAN ON BOARD BILLS OF LADING\n+INSURANCE CERTIFICATE FOR 110 PCT OF CIF VALUE",
+		Charges:                       "ALL CHARGES OUTSIDE THE US ARE FOR\nBENEFICIARY ACCOUNT",
+		PeriodForPresentation:         "21 DAYS AFTER DATE OF SHIPMENT",
+		ReimbursingBank:               "CITIUS33XXX",
+		InstructionsToThePayingOrAcceptingOrNegotiatingBank: "REIMBURSEMENT ON OUR ACCOUNT WITH\nCITIUS33 UPON RECEIPT OF COMPLIANT\nDOCUMENTS",
+		AdviseThroughBank: "BANKGB2LXXX",
+	}
+	if !reflect.DeepEqual(loc, want) {
+		t.Errorf("ParseMT700(mt700_received.txt) =\n%+v\nwant\n%+v", loc, want)
+	}
+}
+
+func TestMT700LoCRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		loc  chaincode.LoC
+	}{
+		{
+			name: "required fields only",
+			loc: chaincode.LoC{ID: "LC1", DocType: "LoC", DocumentaryCreditNumber: "LC1", FormOfDocumentaryCredit: "IRREVOCABLE", DateOfIssue: "20220105",
+				DateOfExpiry: "20220221", Applicant: "AMBER ENTERPRISES INDIA LTD", Beneficiary: "POSCO INDIA PROCESSING CENTER PVT",
+				CurrencyCode: "INR", Amount: money.Money{Amount: "11436300.50", Currency: "INR"}, AvailableWithBy: "ANY BANK IN INDIA BY NEGOTIATION"},
+		},
+		{
+			name: "currency without minor units & banks by BIC",
+			loc: chaincode.LoC{ID: "JP-22-7", DocType: "LoC", DocumentaryCreditNumber: "JP-22-7", FormOfDocumentaryCredit: "IRREVOCABLE", DateOfIssue: "20221201",
+				DateOfExpiry: "20230301", PlaceOfExpiry: "TOKYO", ApplicantBank: "BOTKJPJTXXX", Applicant: "NIPPON PARTS KK\nOSAKA", Beneficiary: "SHENZHEN MOTORS CO",
+				CurrencyCode: "JPY", Amount: money.Money{Amount: "98000000", Currency: "JPY"}, ToleranceMinusPercent: 5, AvailableWithBy: "BOTKJPJT BY ACCEPTANCE",
+				DraftsAt: "60 DAYS AFTER SIGHT", PartialShipments: "CONDITIONAL", LatestDateOfShipment: "20230131", ShipmentPeriod: "JANUARY 2023",
+				ReimbursingBank: "BOTKJPJT", AdviseThroughBank: "BKCHCNBJ45A"},
+		},
+		{
+			name: "currency with 3 minor units",
+			loc: chaincode.LoC{ID: "KW001", DocType: "LoC", DocumentaryCreditNumber: "KW001", FormOfDocumentaryCredit: "IRREVOCABLE STANDBY", DateOfIssue: "20220105",
+				DateOfExpiry: "20230105", Applicant: "GULF TRADING CO", Beneficiary: "DESERT BUILDERS", CurrencyCode: "KWD",
+				Amount: money.Money{Amount: "1250.125", Currency: "KWD"}, AvailableWithBy: "ISSUING BANK BY PAYMENT", Charges: "ALL CHARGES FOR APPLICANT"},
+		},
+		{
+			name: "standby under ISP rules",
+			loc: chaincode.LoC{ID: "SB-22-1", DocType: "LoC", DocumentaryCreditNumber: "SB-22-1", FormOfDocumentaryCredit: "IRREVOCABLE STANDBY",
+				InstrumentType: chaincode.InstrumentStandby, DateOfIssue: "20220105", DateOfExpiry: "20230105", Applicant: "GULF TRADING CO",
+				Beneficiary: "DESERT BUILDERS", CurrencyCode: "USD", Amount: money.Money{Amount: "500000.00", Currency: "USD"},
+				AvailableWithBy: "ISSUING BANK BY PAYMENT"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := FormatMT700(&tt.loc)
+			if err != nil {
+				t.Fatalf("FormatMT700: %v", err)
+			}
+			loc, err := ParseMT700(text)
+			if err != nil {
+				t.Fatalf("ParseMT700: %v\n%s", err, text)
+			}
+			if !reflect.DeepEqual(*loc, tt.loc) {
+				t.Errorf("ParseMT700(FormatMT700(loc)) =\n%+v\nwant\n%+v", *loc, tt.loc)
+			}
+		})
+	}
+}
+
+func TestFormatMT700Wraps(t *testing.T) {
+	loc, err := ParseMT700(readTestdata(t, "mt700.txt"))
+	if err != nil {
+		t.Fatalf("ParseMT700: %v", err)
+	}
+	loc.DocumentsRequired = strings.ReplaceAll(loc.DocumentsRequired, "\n", " ")
+	text, err := FormatMT700(loc)
+	if err != nil {
+		t.Fatalf("FormatMT700: %v", err)
+	}
+	for _, line := range strings.Split(text, "\r\n") {
+		if len(line) > len(":46A:")+65 {
+			t.Errorf("line longer than 65 characters: %q", line)
+		}
+	}
+	if text != readTestdata(t, "mt700.txt") {
+		t.Errorf("FormatMT700 did not wrap documents_required as in mt700.txt:\n%s", text)
+	}
+}
+
+func TestFormatMT700Errors(t *testing.T) {
+	valid := func() chaincode.LoC {
+		return chaincode.LoC{DocumentaryCreditNumber: "LC1", FormOfDocumentaryCredit: "IRREVOCABLE", DateOfIssue: "20220105", DateOfExpiry: "20220221",
+			Applicant: "AMBER", Beneficiary: "POSCO", CurrencyCode: "INR", Amount: money.Money{Amount: "100.00", Currency: "INR"}, AvailableWithBy: "ANY BANK BY PAYMENT"}
+	}
+	tests := []struct {
+		name   string
+		modify func(loc *chaincode.LoC)
+		want   string
+	}{
+		{"missing beneficiary", func(loc *chaincode.LoC) { loc.Beneficiary = "" }, "beneficiary is required"},
+		{"long credit number", func(loc *chaincode.LoC) { loc.DocumentaryCreditNumber = "INLCU0100220001XYZ" }, "documentary_credit_number (20)"},
+		{"bad date", func(loc *chaincode.LoC) { loc.DateOfExpiry = "21/02/2022" }, `date_of_expiry "21/02/2022"`},
+		{"amount in another currency", func(loc *chaincode.LoC) { loc.Amount.Currency = "USD" }, "amount (32B)"},
+		{"available with by without code", func(loc *chaincode.LoC) { loc.AvailableWithBy = "ANY BANK" }, "available_with_by (41a) must end in"},
+		{"applicant too long", func(loc *chaincode.LoC) { loc.Applicant = strings.Repeat("ACME ", 30) }, "applicant (50) needs 5 lines"},
+		{"characters SWIFT does not carry", func(loc *chaincode.LoC) { loc.Beneficiary = "POSCO & CO" }, "beneficiary (59) has characters"},
+		{"line starting with a colon", func(loc *chaincode.LoC) { loc.Applicant = "AMBER\n:20:FORGED" }, `applicant (50) has a line starting with ":"`},
+		{"guarantee", func(loc *chaincode.LoC) { loc.InstrumentType = chaincode.InstrumentGuarantee }, "a guarantee is issued by MT760"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := valid()
+			tt.modify(&loc)
+			_, err := FormatMT700(&loc)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("FormatMT700 error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMT700Errors(t *testing.T) {
+	valid := readTestdata(t, "mt700.txt")
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"another message type", strings.Replace(readTestdata(t, "mt700_received.txt"), "{2:O700", "{2:O707", 1), "is an MT707, not an MT700"},
+		{"text before the first field", "INLCU0100220001\r\n" + strings.TrimPrefix(valid, "{4:\r\n"), "does not start with a field tag"},
+		{"text block not closed", strings.TrimSuffix(valid, "-}"), "not closed"},
+		{"missing amount", strings.Replace(valid, ":32B:INR11436300,00\r\n", "", 1), "no field 32B"},
+		{"too many decimals", strings.Replace(valid, ":32B:INR11436300,00", ":32B:INR11436300,001", 1), "field 32B"},
+		{"bad date", strings.Replace(valid, ":31C:220105", ":31C:220135", 1), "field 31C"},
+		{"bad tolerance", strings.Replace(valid, ":39A:10/10", ":39A:10", 1), "field 39A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMT700(tt.text)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseMT700 error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package swift
+
+import (
+	"strconv"
+	"time"
+
+	"sample.com/lc/chaincode"
+	"sample.com/lc/money"
+)
+
+// Fields of an amendment on an MT707, Amendment to a Documentary Credit, besides the fields of the credit it amends
+//
+//	20  Sender's Reference              documentary_credit_number
+//	21  Receiver's Reference            NONREF, the advising bank has none on the ledger
+//	23  Issuing Bank's Reference        documentary_credit_number
+//	52a Issuing Bank                    applicant_bank
+//	31C Date of Issue                   date_of_issue
+//	26E Number of Amendment             seq of the amendment
+//	30  Date of Amendment               proposed_at of the amendment
+//	22A Purpose of Message              ISSU, the amendment is issued by the applicant bank
+//
+// The changes of the amendment map onto the fields of mt700.go, except the amount, an increase is 32B & a decrease
+// 33B, & the description of goods & documents required, replaced whole by 45B & 46B /REPALL/.
+
+// formatDateTime renders RFC 3339 timestamp value as a date YYMMDD, in the offset of the timestamp. Violations are added to verr.
+func formatDateTime(verr *FormatError, name string, value string) string {
+	timestamp, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		verr.add("%s %q is not an RFC 3339 timestamp", name, value)
+		return ""
+	}
+	return timestamp.Format(swiftDateLayout)
+}
+
+// FormatMT707 renders amendment of loc as the text block of an MT707, as issued by the applicant bank when it proposes it,
+// eg. from the details of the LoCAmendmentProposed event. Events blank the values of private fields, an amendment of the
+// amount or the description of goods has to be read with GetLoCAmendments by the applicant bank, see FormatEnvelope.
+func FormatMT707(loc *chaincode.LoC, amendment *chaincode.Amendment) (string, error) {
+	verr := &FormatError{}
+	if loc.DocumentaryCreditNumber == "" {
+		verr.add("documentary_credit_number is required")
+	}
+	if amendment.Seq < 1 {
+		verr.add("seq of the amendment must be 1 or more, got %d", amendment.Seq)
+	}
+	if len(amendment.Changes) == 0 {
+		verr.add("the amendment changes nothing")
+	}
+	// values after the amendment, the changes over the terms of loc
+	amended := map[string]string{}
+	changes := map[string]chaincode.FieldChange{}
+	for _, change := range amendment.Changes {
+		if isRedacted(change) {
+			verr.add("%s was blanked as private, read the amendment with GetLoCAmendments", change.Field)
+			continue
+		}
+		amended[change.Field] = change.NewValue
+		changes[change.Field] = change
+	}
+	value := func(field string, current string) string {
+		if newValue, ok := amended[field]; ok {
+			return newValue
+		}
+		return current
+	}
+	message := &Message{Type: "707"}
+	add := func(field Field) {
+		message.Fields = append(message.Fields, field)
+	}
+	addText := func(field string, tag string, maxLines int, width int, z bool, prefix string) {
+		if newValue, ok := amended[field]; ok {
+			add(narrative(verr, tag, field, prefix+newValue, maxLines, width, z))
+			delete(changes, field)
+		}
+	}
+	add(Field{Tag: "27", Value: "1/1"})
+	add(Field{Tag: "20", Value: loc.DocumentaryCreditNumber})
+	add(Field{Tag: "21", Value: "NONREF"})
+	add(Field{Tag: "23", Value: loc.DocumentaryCreditNumber})
+	if loc.ApplicantBank != "" {
+		add(party(verr, "52", "applicant_bank", loc.ApplicantBank))
+	}
+	add(Field{Tag: "31C", Value: formatDate(verr, "date_of_issue", loc.DateOfIssue)})
+	add(Field{Tag: "26E", Value: strconv.Itoa(amendment.Seq)})
+	add(Field{Tag: "30", Value: formatDateTime(verr, "proposed_at", amendment.ProposedAt)})
+	add(Field{Tag: "22A", Value: "ISSU"})
+	_, dateChanged := changes["date_of_expiry"]
+	_, placeChanged := changes["place_of_expiry"]
+	if dateChanged || placeChanged {
+		add(Field{Tag: "31D", Value: formatDate(verr, "date_of_expiry", value("date_of_expiry", loc.DateOfExpiry)) + value("place_of_expiry", loc.PlaceOfExpiry)})
+		delete(changes, "date_of_expiry")
+		delete(changes, "place_of_expiry")
+	}
+	if change, ok := changes["amount"]; ok {
+		add(formatAmountChange(verr, loc.CurrencyCode, change))
+		delete(changes, "amount")
+	}
+	_, plusChanged := changes["tolerance_plus_percent"]
+	_, minusChanged := changes["tolerance_minus_percent"]
+	if plusChanged || minusChanged {
+		add(Field{Tag: "39A", Value: value("tolerance_plus_percent", strconv.Itoa(loc.TolerancePlusPercent)) + "/" + value("tolerance_minus_percent", strconv.Itoa(loc.ToleranceMinusPercent))})
+		delete(changes, "tolerance_plus_percent")
+		delete(changes, "tolerance_minus_percent")
+	}
+	addText("partial_shipments", "43P", 1, 11, false, "")
+	addText("loading_from", "44A", 1, 65, false, "")
+	addText("transportation_to", "44B", 1, 65, false, "")
+	if newValue, ok := amended["latest_date_of_shipment"]; ok {
+		add(Field{Tag: "44C", Value: formatDate(verr, "latest_date_of_shipment", newValue)})
+		delete(changes, "latest_date_of_shipment")
+	}
+	addText("shipment_period", "44D", 6, 65, false, "")
+	addText("description_of_goods_and_services", "45B", 100, 65, true, "/REPALL/")
+	addText("documents_required", "46B", 100, 65, true, "/REPALL/")
+	addText("period_for_presentation", "48", 4, 35, false, "")
+	for field := range changes {
+		verr.add("%s can not be amended by an MT707", field)
+	}
+	if err := verr.orNil(); err != nil {
+		return "", err
+	}
+	return message.Format(), nil
+}
+
+// formatAmountChange renders a change of the amount of an LoC in currency code as field 32B, increase of
+// documentary credit amount, or 33B, decrease. Violations are added to verr.
+func formatAmountChange(verr *FormatError, code string, change chaincode.FieldChange) Field {
+	oldAmount, err := money.Parse(change.OldValue, code)
+	if err != nil {
+		verr.add("old amount %v", err)
+		return Field{Tag: "32B"}
+	}
+	newAmount, err := money.Parse(change.NewValue, code)
+	if err != nil {
+		verr.add("new amount %v", err)
+		return Field{Tag: "32B"}
+	}
+	increase, err := newAmount.Sub(oldAmount)
+	if err != nil {
+		verr.add("amount %v", err)
+		return Field{Tag: "32B"}
+	}
+	if sign, _ := increase.Sign(); sign >= 0 {
+		return Field{Tag: "32B", Value: formatAmount(verr, "32B", "amount increase", increase, code)}
+	}
+	decrease, _ := oldAmount.Sub(newAmount)
+	return Field{Tag: "33B", Value: formatAmount(verr, "33B", "amount decrease", decrease, code)}
+}
+
+// isRedacted reports whether change had its values blanked as a change of a private field, see FormatEnvelope
+func isRedacted(change chaincode.FieldChange) bool {
+	return change.OldValue == "" && change.NewValue == ""
+}
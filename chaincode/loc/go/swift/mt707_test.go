@@ -0,0 +1,138 @@
+package swift
+
+import (
+	"strings"
+	"testing"
+
+	"sample.com/lc/chaincode"
+	"sample.com/lc/money"
+)
+
+// amendedLoC is the public envelope of an LoC as carried by events
+func amendedLoC() *chaincode.LoC {
+	return &chaincode.LoC{ID: "INLCU0100220001", DocType: "LoC", DocumentaryCreditNumber: "INLCU0100220001", FormOfDocumentaryCredit: "IRREVOCABLE",
+		DateOfIssue: "20220105", DateOfExpiry: "20220221", PlaceOfExpiry: "NEGOTIATION BANK COUNTER", ApplicantBank: "Org1", CurrencyCode: "INR",
+		TolerancePlusPercent: 10, ToleranceMinusPercent: 10, AvailableWithBy: "ANY BANK IN INDIA BY NEGOTIATION", AdviseThroughBank: "Org2", NegotiatingBank: "Org2"}
+}
+
+func TestFormatMT707(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes []chaincode.FieldChange
+		want    []string // fields after 22A
+	}{
+		{
+			name:    "expiry & shipment",
+			changes: []chaincode.FieldChange{{Field: "date_of_expiry", OldValue: "20220221", NewValue: "20220331"}, {Field: "latest_date_of_shipment", OldValue: "", NewValue: "20220310"}},
+			want:    []string{":31D:220331NEGOTIATION BANK COUNTER", ":44C:220310"},
+		},
+		{
+			name:    "place of expiry keeps the date",
+			changes: []chaincode.FieldChange{{Field: "place_of_expiry", OldValue: "NEGOTIATION BANK COUNTER", NewValue: "MUMBAI"}},
+			want:    []string{":31D:220221MUMBAI"},
+		},
+		{
+			name:    "amount increase",
+			changes: []chaincode.FieldChange{{Field: "amount", OldValue: "11436300.00", NewValue: "12000000.50"}},
+			want:    []string{":32B:INR563700,50"},
+		},
+		{
+			name:    "amount decrease & tolerance",
+			changes: []chaincode.FieldChange{{Field: "amount", OldValue: "11436300.00", NewValue: "11000000.00"}, {Field: "tolerance_minus_percent", OldValue: "10", NewValue: "5"}},
+			want:    []string{":33B:INR436300,00", ":39A:10/5"},
+		},
+		{
+			name:    "documents replaced",
+			changes: []chaincode.FieldChange{{Field: "documents_required", OldValue: "1: BILL OF EXCHANGE", NewValue: "1: BILL OF EXCHANGE 2: TAX INVOICE"}, {Field: "period_for_presentation", OldValue: "", NewValue: "WITHIN 21 DAYS"}},
+			want:    []string{":46B:/REPALL/1: BILL OF EXCHANGE 2: TAX INVOICE", ":48:WITHIN 21 DAYS"},
+		},
+	}
+	header := []string{"{4:", ":27:1/1", ":20:INLCU0100220001", ":21:NONREF", ":23:INLCU0100220001", ":52D:Org1", ":31C:220105", ":26E:2", ":30:220411", ":22A:ISSU"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amendment := &chaincode.Amendment{DocType: "Amendment", LoCID: "INLCU0100220001", Seq: 2, Changes: tt.changes, Status: "PROPOSED", ProposedBy: "Org1", ProposedAt: "2022-04-11T11:46:00+05:30"}
+			text, err := FormatMT707(amendedLoC(), amendment)
+			if err != nil {
+				t.Fatalf("FormatMT707: %v", err)
+			}
+			want := strings.Join(append(append(header, tt.want...), "-}"), "\r\n")
+			if text != want {
+				t.Errorf("FormatMT707 =\n%s\nwant\n%s", text, want)
+			}
+			if _, err := Parse(text); err != nil {
+				t.Errorf("Parse(FormatMT707): %v", err)
+			}
+		})
+	}
+}
+
+func TestFormatMT707Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes []chaincode.FieldChange
+		want    string
+	}{
+		{"no changes", []chaincode.FieldChange{}, "the amendment changes nothing"},
+		{"private values blanked by the event", []chaincode.FieldChange{{Field: "amount"}}, "amount was blanked as private"},
+		{"field without MT707 field", []chaincode.FieldChange{{Field: "beneficiary", OldValue: "POSCO", NewValue: "POSCO INDIA"}}, "beneficiary can not be amended by an MT707"},
+		{"amount with too many decimals", []chaincode.FieldChange{{Field: "amount", OldValue: "100.00", NewValue: "100.001"}}, "new amount"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amendment := &chaincode.Amendment{Seq: 1, Changes: tt.changes, ProposedAt: "2022-04-11T11:46:00+05:30"}
+			_, err := FormatMT707(amendedLoC(), amendment)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("FormatMT707 error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatMT734AndMT752(t *testing.T) {
+	loc := amendedLoC()
+	loc.Amount = money.Money{Amount: "11436300.00", Currency: "INR"}
+	history := []chaincode.PresentationStatusEvent{
+		{FromStatus: "", ToStatus: chaincode.PresentationPresented, Timestamp: "2022-02-01T10:00:00+05:30"},
+		{FromStatus: chaincode.PresentationPresented, ToStatus: chaincode.PresentationDiscrepant, Timestamp: "2022-02-03T10:00:00+05:30"},
+	}
+	discrepancies := []chaincode.Discrepancy{{Code: "LATE_SHIPMENT", Description: "BILL OF LADING DATED 20220215"}, {Code: "DOCUMENT_MISSING", Description: "NO INSURANCE CERTIFICATE"}}
+	presentation := &chaincode.Presentation{DocType: "Presentation", LoCID: loc.ID, Seq: 1, Round: 1, Status: chaincode.PresentationDiscrepant, Discrepancies: discrepancies, History: history}
+	presentation.Amount = money.Money{Amount: "5000000", Currency: "INR"}
+
+	text, err := FormatMT734(loc, presentation)
+	if err != nil {
+		t.Fatalf("FormatMT734: %v", err)
+	}
+	want := "{4:\r\n:20:INLCU0100220001\r\n:21:NONREF\r\n:32A:220201INR5000000,00\r\n" +
+		":77J:+LATE_SHIPMENT BILL OF LADING DATED 20220215\r\n+DOCUMENT_MISSING NO INSURANCE CERTIFICATE\r\n:77B:/NOTIFY/\r\n-}"
+	if text != want {
+		t.Errorf("FormatMT734 =\n%s\nwant\n%s", text, want)
+	}
+	if _, err := FormatMT752(loc, presentation); err == nil {
+		t.Errorf("FormatMT752 of discrepant documents did not fail")
+	}
+
+	presentation.Status = chaincode.PresentationWaived
+	text, err = FormatMT752(loc, presentation)
+	if err != nil {
+		t.Fatalf("FormatMT752: %v", err)
+	}
+	want = "{4:\r\n:20:INLCU0100220001\r\n:21:NONREF\r\n:23:NEGOTIATE\r\n:30:220203\r\n:32B:INR5000000,00\r\n" +
+		":72Z:DISCREPANCIES WAIVED BY APPLICANT:\r\nLATE_SHIPMENT, DOCUMENT_MISSING\r\n-}"
+	if text != want {
+		t.Errorf("FormatMT752 =\n%s\nwant\n%s", text, want)
+	}
+	if _, err := FormatMT734(loc, presentation); err == nil {
+		t.Errorf("FormatMT734 of waived documents did not fail")
+	}
+
+	// the credit amount never stands in for the amount claimed
+	presentation.Amount = money.Money{}
+	if _, err := FormatMT752(loc, presentation); err == nil || !strings.Contains(err.Error(), "amount claimed by presentation #1 is not known") {
+		t.Errorf("FormatMT752 without amount claimed error = %v", err)
+	}
+	presentation.Status = chaincode.PresentationRefused
+	if _, err := FormatMT734(loc, presentation); err == nil || !strings.Contains(err.Error(), "amount claimed by presentation #1 is not known") {
+		t.Errorf("FormatMT734 without amount claimed error = %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"sample.com/lc/chaincode"
+	"sample.com/lc/money"
+)
+
+// Fields of a presentation on an MT734, Advice of Refusal
+//
+//	20  Sender's TRN                    documentary_credit_number
+//	21  Presenting Bank's Reference     NONREF, the negotiating bank has none on the ledger
+//	32A Date and Amount of Utilisation  date the documents were last presented & the amount claimed
+//	77J Discrepancies                   discrepancies of the presentation & the refusal reason
+//	77B Disposal of Documents           /NOTIFY/ while the applicant may still waive, /HOLD/ once refused
+
+// statusTime returns the timestamp of the latest change of presentation to status, empty if it never was
+func statusTime(presentation *chaincode.Presentation, status chaincode.PresentationStatus) string {
+	for i := len(presentation.History) - 1; i >= 0; i-- {
+		if presentation.History[i].ToStatus == status {
+			return presentation.History[i].Timestamp
+		}
+	}
+	return ""
+}
+
+// claimedAmount returns the amount claimed by presentation, an error if it is not known
+func claimedAmount(verr *FormatError, presentation *chaincode.Presentation) money.Money {
+	if presentation.Amount.IsEmpty() {
+		verr.add("amount claimed by presentation #%d is not known, it is recorded in transient field loc_presentation & only read by the applicant & advising banks", presentation.Seq)
+	}
+	return presentation.Amount
+}
+
+// FormatMT734 renders the refusal of presentation of loc as the text block of an MT734, as sent by the applicant bank
+// to the negotiating bank once it raised discrepancies or refused the documents, eg. from the payload of the
+// DiscrepanciesRaised & DocumentsRefused events. The amount claimed by presentation is required, events do not carry
+// it, see FormatEnvelope.
+func FormatMT734(loc *chaincode.LoC, presentation *chaincode.Presentation) (string, error) {
+	verr := &FormatError{}
+	var disposal string
+	switch presentation.Status {
+	case chaincode.PresentationDiscrepant, chaincode.PresentationWaiverRequested:
+		disposal = "/NOTIFY/"
+	case chaincode.PresentationRefused:
+		disposal = "/HOLD/"
+	default:
+		verr.add("presentation #%d is %s, only discrepant or refused documents are refused by an MT734", presentation.Seq, presentation.Status)
+	}
+	if loc.DocumentaryCreditNumber == "" {
+		verr.add("documentary_credit_number is required")
+	}
+	message := &Message{Type: "734"}
+	add := func(field Field) {
+		message.Fields = append(message.Fields, field)
+	}
+	add(Field{Tag: "20", Value: loc.DocumentaryCreditNumber})
+	add(Field{Tag: "21", Value: "NONREF"})
+	presented := formatDateTime(verr, "presentation date", statusTime(presentation, chaincode.PresentationPresented))
+	add(Field{Tag: "32A", Value: presented + formatAmount(verr, "32A", "amount claimed", claimedAmount(verr, presentation), loc.CurrencyCode)})
+	discrepancies := make([]string, 0, len(presentation.Discrepancies)+1)
+	for _, discrepancy := range presentation.Discrepancies {
+		discrepancies = append(discrepancies, fmt.Sprintf("+%s %s", discrepancy.Code, discrepancy.Description))
+	}
+	if presentation.RefusalReason != "" {
+		discrepancies = append(discrepancies, "+REFUSED "+presentation.RefusalReason)
+	}
+	if len(discrepancies) == 0 {
+		verr.add("presentation #%d has no discrepancies", presentation.Seq)
+	}
+	add(narrative(verr, "77J", "discrepancies", strings.Join(discrepancies, "\n"), 70, 50, true))
+	add(Field{Tag: "77B", Value: disposal})
+	if err := verr.orNil(); err != nil {
+		return "", err
+	}
+	return message.Format(), nil
+}
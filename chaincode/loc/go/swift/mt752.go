@@ -0,0 +1,60 @@
+package swift
+
+import (
+	"strings"
+
+	"sample.com/lc/chaincode"
+)
+
+// Fields of a presentation on an MT752, Authorisation to Pay, Accept or Negotiate
+//
+//	20  Documentary Credit Number       documentary_credit_number
+//	21  Presenting Bank's Reference     NONREF, the negotiating bank has none on the ledger
+//	23  Further Identification          ACCEPT or NEGOTIATE as available_with_by says, REIMBURSE otherwise
+//	30  Date of Advice of Discrepancy   date discrepancies were raised, of the presentation if there were none
+//	32B Total Amount Advised            the amount claimed
+//	72Z Sender to Receiver Information  the discrepancies waived
+
+// FormatMT752 renders the acceptance of presentation of loc as the text block of an MT752, as sent by the applicant bank
+// to the negotiating bank once it accepted the documents or waived their discrepancies, eg. from the payload of the
+// DocumentsAccepted & DiscrepanciesWaived events. The amount claimed by presentation is required, see FormatMT734.
+func FormatMT752(loc *chaincode.LoC, presentation *chaincode.Presentation) (string, error) {
+	verr := &FormatError{}
+	if presentation.Status != chaincode.PresentationAccepted && presentation.Status != chaincode.PresentationWaived {
+		verr.add("presentation #%d is %s, only accepted or waived documents are authorised by an MT752", presentation.Seq, presentation.Status)
+	}
+	if loc.DocumentaryCreditNumber == "" {
+		verr.add("documentary_credit_number is required")
+	}
+	message := &Message{Type: "752"}
+	add := func(field Field) {
+		message.Fields = append(message.Fields, field)
+	}
+	add(Field{Tag: "20", Value: loc.DocumentaryCreditNumber})
+	add(Field{Tag: "21", Value: "NONREF"})
+	identification := "REIMBURSE"
+	switch {
+	case strings.HasSuffix(loc.AvailableWithBy, "BY ACCEPTANCE"):
+		identification = "ACCEPT"
+	case strings.HasSuffix(loc.AvailableWithBy, "BY NEGOTIATION"):
+		identification = "NEGOTIATE"
+	}
+	add(Field{Tag: "23", Value: identification})
+	advised := statusTime(presentation, chaincode.PresentationDiscrepant)
+	if advised == "" {
+		advised = statusTime(presentation, chaincode.PresentationPresented)
+	}
+	add(Field{Tag: "30", Value: formatDateTime(verr, "date of advice", advised)})
+	add(Field{Tag: "32B", Value: formatAmount(verr, "32B", "amount claimed", claimedAmount(verr, presentation), loc.CurrencyCode)})
+	if presentation.Status == chaincode.PresentationWaived && len(presentation.Discrepancies) > 0 {
+		codes := make([]string, 0, len(presentation.Discrepancies))
+		for _, discrepancy := range presentation.Discrepancies {
+			codes = append(codes, discrepancy.Code)
+		}
+		add(narrative(verr, "72Z", "discrepancies waived", "DISCREPANCIES WAIVED BY APPLICANT: "+strings.Join(codes, ", "), 6, 35, true))
+	}
+	if err := verr.orNil(); err != nil {
+		return "", err
+	}
+	return message.Format(), nil
+}
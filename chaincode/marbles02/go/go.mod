@@ -6,3 +6,7 @@ require (
 	github.com/hyperledger/fabric-chaincode-go v0.0.0-20190823162523-04390e015b85
 	github.com/hyperledger/fabric-protos-go v0.0.0-20190821214336-621b908d5022
 )
+
+require sample.com/lc/couchdb v0.0.0
+
+replace sample.com/lc/couchdb => ../../loc/go/couchdb
@@ -89,6 +89,7 @@ import (
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"sample.com/lc/couchdb"
 )
 
 // SimpleChaincode example simple Chaincode implementation
@@ -522,7 +523,10 @@ func (t *SimpleChaincode) queryMarblesByOwner(stub shim.ChaincodeStubInterface,
 
 	owner := strings.ToLower(args[0])
 
-	queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"marble\",\"owner\":\"%s\"}}", owner)
+	queryString, err := ownerQuery(owner)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
 
 	queryResults, err := getQueryResultForQueryString(stub, queryString)
 	if err != nil {
@@ -531,6 +535,12 @@ func (t *SimpleChaincode) queryMarblesByOwner(stub shim.ChaincodeStubInterface,
 	return shim.Success(queryResults)
 }
 
+// ownerQuery returns the rich query for the marbles of owner. The selector is built by the couchdb package,
+// so quotes & braces in owner stay inside its value & can not change the query.
+func ownerQuery(owner string) (string, error) {
+	return couchdb.NewQuery(couchdb.Eq("docType", "marble"), couchdb.Eq("owner", owner)).UseIndex("_design/indexOwnerDoc", "indexOwner").Build()
+}
+
 // ===== Example: Ad hoc rich query ========================================================
 // queryMarbles uses a query string to perform a query for marbles.
 // Query string matching state database syntax is passed in and executed as is.
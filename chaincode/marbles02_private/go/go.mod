@@ -3,3 +3,7 @@ module github.com/hyperledger/fabric-samples/chaincode/marbles02_private/go
 go 1.13
 
 require github.com/hyperledger/fabric-contract-api-go v1.1.0
+
+require sample.com/lc/couchdb v0.0.0
+
+replace sample.com/lc/couchdb => ../../loc/go/couchdb
@@ -99,6 +99,7 @@ import (
 	"strings"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sample.com/lc/couchdb"
 )
 
 type Marble struct {
@@ -467,7 +468,10 @@ func (s *SmartContract) QueryMarblesByOwner(ctx contractapi.TransactionContextIn
 
 	ownerString  := strings.ToLower(owner)
 
-	queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"marble\",\"owner\":\"%s\"}}", ownerString)
+	queryString, err := ownerQuery(ownerString)
+	if err != nil {
+		return nil, err
+	}
 
 	queryResults, err := s.getQueryResultForQueryString(ctx, queryString)
 	if err != nil {
@@ -476,6 +480,12 @@ func (s *SmartContract) QueryMarblesByOwner(ctx contractapi.TransactionContextIn
 	return queryResults, nil
 }
 
+// ownerQuery returns the rich query for the marbles of owner. The selector is built by the couchdb package,
+// so quotes & braces in owner stay inside its value & can not change the query.
+func ownerQuery(owner string) (string, error) {
+	return couchdb.NewQuery(couchdb.Eq("docType", "marble"), couchdb.Eq("owner", owner)).UseIndex("_design/indexOwnerDoc", "indexOwner").Build()
+}
+
 // ===== Example: Ad hoc rich query ========================================================
 // queryMarbles uses a query string to perform a query for marbles.
 // Query string matching state database syntax is passed in and executed as is.
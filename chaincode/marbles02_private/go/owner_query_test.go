@@ -0,0 +1,34 @@
+/*
+ SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOwnerQuery(t *testing.T) {
+	// an owner closing the selector & adding an $or that matches every marble
+	owner := `tom"},"$or":[{"docType":{"$gt":null}}],"x":{"y":"`
+	queryString, err := ownerQuery(owner)
+	if err != nil {
+		t.Fatalf("ownerQuery: %v", err)
+	}
+	var query struct {
+		Selector map[string]interface{} `json:"selector"`
+		UseIndex []string               `json:"use_index"`
+	}
+	if err := json.Unmarshal([]byte(queryString), &query); err != nil {
+		t.Fatalf("query %s is not JSON: %v", queryString, err)
+	}
+	want := map[string]interface{}{"docType": map[string]interface{}{"$eq": "marble"}, "owner": map[string]interface{}{"$eq": owner}}
+	if !reflect.DeepEqual(query.Selector, want) {
+		t.Errorf("selector = %v, want %v", query.Selector, want)
+	}
+	if !reflect.DeepEqual(query.UseIndex, []string{"_design/indexOwnerDoc", "indexOwner"}) {
+		t.Errorf("use_index = %v", query.UseIndex)
+	}
+}
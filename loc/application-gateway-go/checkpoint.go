@@ -0,0 +1,93 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint is how far the listener got, persisted to a file after each transaction handled, to resume where it
+// left off. Fabric delivers events from a block on, the transactions already handled of that block are skipped.
+type Checkpoint struct {
+	path           string
+	BlockNumber    uint64   `json:"block_number"`    // block of the last transaction handled
+	TransactionIDs []string `json:"transaction_ids"` // transactions handled of the block, in the order they were
+}
+
+// LoadCheckpoint reads the checkpoint of file path, an empty one if there is no such file yet
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	checkpoint := &Checkpoint{path: path}
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint file: %w", err)
+	}
+	err = json.Unmarshal(data, checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint %s from Json: %w", path, err)
+	}
+	return checkpoint, nil
+}
+
+// StartBlock returns the block to resume from, false if no transaction was handled yet
+func (c *Checkpoint) StartBlock() (uint64, bool) {
+	return c.BlockNumber, len(c.TransactionIDs) > 0
+}
+
+// Handled tells if the transaction txID of block blockNumber was handled already
+func (c *Checkpoint) Handled(blockNumber uint64, txID string) bool {
+	if len(c.TransactionIDs) == 0 || blockNumber > c.BlockNumber {
+		return false
+	}
+	if blockNumber < c.BlockNumber {
+		return true
+	}
+	for _, handled := range c.TransactionIDs {
+		if handled == txID {
+			return true
+		}
+	}
+	return false
+}
+
+// Save records the transaction txID of block blockNumber as handled & writes the checkpoint file. The file is
+// replaced in one go, a crash leaves the checkpoint before or after the transaction, never half written.
+func (c *Checkpoint) Save(blockNumber uint64, txID string) error {
+	if blockNumber != c.BlockNumber {
+		c.BlockNumber, c.TransactionIDs = blockNumber, nil
+	}
+	c.TransactionIDs = append(c.TransactionIDs, txID)
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint into Json: %w", err)
+	}
+	file, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint file: %w", err)
+	}
+	err = os.Rename(file.Name(), c.path)
+	if err != nil {
+		return fmt.Errorf("failed to replace checkpoint file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+module locEvents
+
+go 1.18
+
+require (
+	github.com/hyperledger/fabric-gateway v1.0.0
+	github.com/hyperledger/fabric-protos-go v0.0.0-20211118165945-23d738fc3553
+	google.golang.org/grpc v1.42.0
+	sample.com/lc v0.0.0
+)
+
+require (
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/hyperledger/fabric v2.1.1+incompatible // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	golang.org/x/net v0.0.0-20211015210444-4f30a5c0130f // indirect
+	golang.org/x/sys v0.0.0-20211110154304-99a53858aa08 // indirect
+	golang.org/x/text v0.3.7 // indirect
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013 // indirect
+	google.golang.org/protobuf v1.27.1 // indirect
+)
+
+replace sample.com/lc => ../../chaincode/loc/go
@@ -0,0 +1,122 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"sample.com/lc/events"
+)
+
+// Event is an LoC event as handed to handlers, the envelope of package events & the block it was committed in, eg.
+//
+//	{"block_number":12,"schema_version":1,"type":"LoCIssuanceAcknowledged","loc_id":"LC1",...,"changes":[]}
+type Event struct {
+	BlockNumber uint64 `json:"block_number"`
+	events.Envelope
+}
+
+// Handler does something with LoC events, eg. posts them to a webhook. An error stops the listener before the
+// transaction of the event is checkpointed, the events of the transaction are handed again once it resumes.
+type Handler interface {
+	Handle(event *Event) error
+}
+
+// JSONLineHandler writes events as Json, one per line
+type JSONLineHandler struct {
+	w io.Writer
+}
+
+// NewStdoutHandler returns a handler writing events to standard output as Json lines
+func NewStdoutHandler() *JSONLineHandler {
+	return &JSONLineHandler{w: os.Stdout}
+}
+
+// Handle writes event as a Json line
+func (h *JSONLineHandler) Handle(event *Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event into Json: %w", event.Type, err)
+	}
+	_, err = h.w.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write %s event: %w", event.Type, err)
+	}
+	return nil
+}
+
+// FileHandler appends events to a file as Json lines, the file is synced after each event
+type FileHandler struct {
+	file *os.File
+}
+
+// NewFileHandler returns a handler appending events to file path, created if there is no such file
+func NewFileHandler(path string) (*FileHandler, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open events file: %w", err)
+	}
+	return &FileHandler{file: file}, nil
+}
+
+// Handle appends event to the file as a Json line
+func (h *FileHandler) Handle(event *Event) error {
+	err := (&JSONLineHandler{w: h.file}).Handle(event)
+	if err != nil {
+		return err
+	}
+	err = h.file.Sync()
+	if err != nil {
+		return fmt.Errorf("failed to sync events file: %w", err)
+	}
+	return nil
+}
+
+// Close closes the file
+func (h *FileHandler) Close() error {
+	return h.file.Close()
+}
+
+// WebhookHandler posts events as Json to a URL, any status but 2xx is an error
+type WebhookHandler struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookHandler returns a handler posting events to url, requests time out after timeout
+func NewWebhookHandler(url string, timeout time.Duration) *WebhookHandler {
+	return &WebhookHandler{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Handle posts event to the webhook, the type of the event is in the X-LoC-Event-Type header too
+func (h *WebhookHandler) Handle(event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event into Json: %w", event.Type, err)
+	}
+	request, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-LoC-Event-Type", event.Type)
+	response, err := h.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to post %s event to webhook: %w", event.Type, err)
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s to %s event of LoC %s", response.Status, event.Type, event.LoCID)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sample.com/lc/events"
+)
+
+func TestHandlers(t *testing.T) {
+	event := &Event{BlockNumber: 12, Envelope: events.Envelope{SchemaVersion: 1, Type: "LoCIssued", LoCID: "LC1", Changes: []events.Change{}}}
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	for i := 0; i < 2; i++ {
+		handler, err := NewFileHandler(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := handler.Handle(event); err != nil {
+			t.Fatalf("FileHandler.Handle: %v", err)
+		}
+		handler.Close()
+	}
+	data, err := ioutil.ReadFile(path)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if err != nil || len(lines) != 2 || !strings.HasPrefix(lines[1], `{"block_number":12,"schema_version":1,"type":"LoCIssued","loc_id":"LC1"`) {
+		t.Errorf("events file = %s, %v", data, err)
+	}
+
+	var posted Event
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("X-LoC-Event-Type") != "LoCIssued" {
+			t.Errorf("webhook request %s %v", r.Method, r.Header)
+		}
+		json.NewDecoder(r.Body).Decode(&posted)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	webhook := NewWebhookHandler(server.URL, time.Second)
+	if err := webhook.Handle(event); err != nil || posted.BlockNumber != 12 || posted.LoCID != "LC1" {
+		t.Errorf("WebhookHandler.Handle posted %+v, %v", posted, err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := webhook.Handle(event); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("WebhookHandler.Handle on 503 = %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"log"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"sample.com/lc/events"
+)
+
+// Listener hands the LoC events of chaincode events to handlers, in the order they were committed, & checkpoints
+// each transaction once all its events are handled
+type Listener struct {
+	Checkpoint *Checkpoint
+	Handlers   []Handler
+	Types      map[string]bool // types of the events handed to handlers, eg. LoCIssued, all if empty
+}
+
+// Run handles the chaincode events read from chaincodeEvents till it is closed, or till a handler or the
+// checkpoint fails. Events of transactions the checkpoint has are skipped.
+func (l *Listener) Run(chaincodeEvents <-chan *client.ChaincodeEvent) error {
+	for event := range chaincodeEvents {
+		err := l.handle(event)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handle hands the LoC events of a chaincode event to every handler, then checkpoints its transaction. Chaincode
+// events that are not LoCEvents batches, eg. of chaincode before versioned events, are skipped.
+func (l *Listener) handle(event *client.ChaincodeEvent) error {
+	if l.Checkpoint.Handled(event.BlockNumber, event.TransactionID) {
+		return nil
+	}
+	envelopes, err := events.Unpack(event.EventName, event.Payload)
+	if err != nil {
+		log.Printf("skipping %s event of transaction %s in block %d: %v\n", event.EventName, event.TransactionID, event.BlockNumber, err)
+	}
+	for _, envelope := range envelopes {
+		if len(l.Types) > 0 && !l.Types[envelope.Type] {
+			continue
+		}
+		locEvent := &Event{BlockNumber: event.BlockNumber, Envelope: envelope}
+		for _, handler := range l.Handlers {
+			err = handler.Handle(locEvent)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return l.Checkpoint.Save(event.BlockNumber, event.TransactionID)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// recorder is a handler recording the types of the events it is handed, failing from the fail-th on if fail > 0
+type recorder struct {
+	types []string
+	fail  int
+}
+
+func (r *recorder) Handle(event *Event) error {
+	if r.fail > 0 && len(r.types)+1 >= r.fail {
+		return errors.New("handler failed")
+	}
+	r.types = append(r.types, event.Type)
+	return nil
+}
+
+// stream returns a closed channel of chaincodeEvents
+func stream(chaincodeEvents ...*client.ChaincodeEvent) <-chan *client.ChaincodeEvent {
+	events := make(chan *client.ChaincodeEvent, len(chaincodeEvents))
+	for _, event := range chaincodeEvents {
+		events <- event
+	}
+	close(events)
+	return events
+}
+
+func TestListener(t *testing.T) {
+	blocks := []*client.ChaincodeEvent{
+		{BlockNumber: 5, TransactionID: "tx1", EventName: "LoCEvents", Payload: []byte(`{"schema_version":1,"tx_id":"tx1","events":[{"type":"LoCIssued"}]}`)},
+		{BlockNumber: 6, TransactionID: "tx2", EventName: "LoCEvents", Payload: []byte(`{"schema_version":1,"tx_id":"tx2","events":[{"type":"LoCAmendmentAcknowledged"},{"type":"DocumentsAwaited"}]}`)},
+		{BlockNumber: 6, TransactionID: "tx3", EventName: "LoCClosed", Payload: []byte(`{"schema_version":1,"type":"LoCClosed","loc_id":"LC1"}`)},
+		{BlockNumber: 6, TransactionID: "tx4", EventName: "LoCEvents", Payload: []byte(`{"schema_version":1,"tx_id":"tx4","events":[{"type":"LoCExpired"}]}`)},
+	}
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	checkpoint, err := LoadCheckpoint(path)
+	if _, ok := checkpoint.StartBlock(); err != nil || ok {
+		t.Fatalf("LoadCheckpoint without file = %+v, %v", checkpoint, err)
+	}
+	handler := &recorder{fail: 3}
+	listener := &Listener{Checkpoint: checkpoint, Handlers: []Handler{handler}}
+	if err := listener.Run(stream(blocks...)); err == nil {
+		t.Fatalf("Run with a failing handler")
+	}
+	if block, ok := checkpoint.StartBlock(); !ok || block != 5 {
+		t.Errorf("checkpoint of tx1 starts from block %d, %t", block, ok)
+	}
+	// resumed from the checkpoint file, Fabric delivers events from its block on
+	checkpoint, err = LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler = &recorder{}
+	listener = &Listener{Checkpoint: checkpoint, Handlers: []Handler{handler}}
+	if err := listener.Run(stream(blocks[0], blocks[1], blocks[2])); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// reconnected after the stream broke in the middle of block 6
+	if err := listener.Run(stream(blocks[1:]...)); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := []string{"LoCAmendmentAcknowledged", "DocumentsAwaited", "LoCExpired"}
+	if !reflect.DeepEqual(handler.types, want) {
+		t.Errorf("handled %v, want %v", handler.types, want)
+	}
+	checkpoint, err = LoadCheckpoint(path)
+	if block, _ := checkpoint.StartBlock(); err != nil || block != 6 || !reflect.DeepEqual(checkpoint.TransactionIDs, []string{"tx2", "tx3", "tx4"}) {
+		t.Errorf("checkpoint = %+v, %v", checkpoint, err)
+	}
+	// only the types asked for
+	handler = &recorder{}
+	checkpoint, _ = LoadCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"))
+	listener = &Listener{Checkpoint: checkpoint, Handlers: []Handler{handler}, Types: map[string]bool{"DocumentsAwaited": true}}
+	if err := listener.Run(stream(blocks...)); err != nil || !reflect.DeepEqual(handler.types, []string{"DocumentsAwaited"}) {
+		t.Errorf("Run with types handled %v, %v", handler.types, err)
+	}
+}
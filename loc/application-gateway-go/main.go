@@ -0,0 +1,212 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Command locEvents listens to the events of the LoC chaincode through the Fabric Gateway & hands them to handlers
+//
+//	locEvents [-checkpoint checkpoint.json] [-start-block N] [-types LoCIssued,LoCClosed]
+//	          [-stdout] [-file events.jsonl] [-webhook http://localhost:8080/loc-events]
+//
+// Every LoC event of a chaincode event, see events.Unpack, is handed to each handler set, as Json with the block it
+// was committed in, see Event: -stdout writes Json lines to standard output, -file appends them to a file & -webhook
+// posts them to a URL. Standard output is the handler without any set. The transactions handled are checkpointed to
+// a file, the listener resumes after them once restarted or reconnected, without gaps nor duplicates. A handler that
+// fails stops the listener before its transaction is checkpointed, the transaction is handed again to all handlers
+// once it resumes, handlers that did not fail see its events twice.
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	mspID        = "Org1MSP"
+	cryptoPath   = "../../test-network/organizations/peerOrganizations/org1.example.com"
+	certPath     = cryptoPath + "/users/User1@org1.example.com/msp/signcerts/cert.pem"
+	keyPath      = cryptoPath + "/users/User1@org1.example.com/msp/keystore/"
+	tlsCertPath  = cryptoPath + "/peers/peer0.org1.example.com/tls/ca.crt"
+	peerEndpoint = "localhost:7051"
+	gatewayPeer  = "peer0.org1.example.com"
+	retryDelay   = 5 * time.Second // before reconnecting, after the events stream broke or a handler failed
+)
+
+func main() {
+	log.SetPrefix("locEvents: ")
+	checkpointPath := flag.String("checkpoint", "checkpoint.json", "file the transactions handled are checkpointed to")
+	startBlock := flag.Int64("start-block", -1, "block to start from without checkpoint, the next block committed if negative")
+	channelName := flag.String("channel", "mychannel", "channel of the chaincode")
+	chaincodeName := flag.String("chaincode", "managelc", "name the LoC chaincode is deployed with")
+	types := flag.String("types", "", "comma separated types of the events to handle, eg. LoCIssued,LoCClosed, all if empty")
+	stdout := flag.Bool("stdout", false, "write events to standard output as Json lines")
+	file := flag.String("file", "", "append events to file as Json lines")
+	webhook := flag.String("webhook", "", "post events as Json to URL")
+	webhookTimeout := flag.Duration("webhook-timeout", 10*time.Second, "timeout of webhook requests")
+	flag.Parse()
+
+	checkpoint, err := LoadCheckpoint(*checkpointPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	listener := &Listener{Checkpoint: checkpoint, Types: make(map[string]bool)}
+	for _, eventType := range strings.Split(*types, ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			listener.Types[eventType] = true
+		}
+	}
+	if *file != "" {
+		fileHandler, err := NewFileHandler(*file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer fileHandler.Close()
+		listener.Handlers = append(listener.Handlers, fileHandler)
+	}
+	if *webhook != "" {
+		listener.Handlers = append(listener.Handlers, NewWebhookHandler(*webhook, *webhookTimeout))
+	}
+	if *stdout || len(listener.Handlers) == 0 {
+		listener.Handlers = append(listener.Handlers, NewStdoutHandler())
+	}
+
+	// The gRPC client connection should be shared by all Gateway connections to this endpoint
+	clientConnection := newGrpcConnection()
+	defer clientConnection.Close()
+
+	gateway, err := client.Connect(newIdentity(), client.WithSign(newSign()), client.WithClientConnection(clientConnection))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer gateway.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	listen(ctx, gateway.GetNetwork(*channelName), *chaincodeName, listener, *startBlock)
+}
+
+// listen runs listener on the events of chaincode till ctx is done, reconnecting after retryDelay whenever the events
+// stream breaks or listener fails. It starts from the block of the checkpoint, from startBlock without checkpoint.
+func listen(ctx context.Context, network *client.Network, chaincodeName string, listener *Listener, startBlock int64) {
+	for ctx.Err() == nil {
+		var options []client.ChaincodeEventsOption
+		if block, ok := listener.Checkpoint.StartBlock(); ok {
+			options = append(options, client.WithStartBlock(block))
+		} else if startBlock >= 0 {
+			options = append(options, client.WithStartBlock(uint64(startBlock)))
+		}
+		err := listenOnce(ctx, network, chaincodeName, listener, options...)
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			log.Println("error -> listenOnce -> listen\n", err)
+		} else {
+			log.Println("events stream of", chaincodeName, "closed")
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryDelay):
+		}
+	}
+	log.Println("stopped, checkpoint at block", listener.Checkpoint.BlockNumber)
+}
+
+// listenOnce runs listener on one events stream of chaincode, till it breaks or listener fails
+func listenOnce(ctx context.Context, network *client.Network, chaincodeName string, listener *Listener, options ...client.ChaincodeEventsOption) error {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	chaincodeEvents, err := network.ChaincodeEvents(streamCtx, chaincodeName, options...)
+	if err != nil {
+		return fmt.Errorf("failed to read events of %s: %w", chaincodeName, err)
+	}
+	err = listener.Run(chaincodeEvents)
+	if err != nil {
+		// the stream only stops once cancelled & read till closed
+		cancel()
+		for range chaincodeEvents {
+		}
+	}
+	return err
+}
+
+// newGrpcConnection creates a gRPC connection to the Gateway server.
+func newGrpcConnection() *grpc.ClientConn {
+	certificate, err := loadCertificate(tlsCertPath)
+	if err != nil {
+		panic(err)
+	}
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(certificate)
+	transportCredentials := credentials.NewClientTLSFromCert(certPool, gatewayPeer)
+
+	connection, err := grpc.Dial(peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
+	if err != nil {
+		panic(fmt.Errorf("failed to create gRPC connection: %w", err))
+	}
+
+	return connection
+}
+
+// newIdentity creates a client identity for this Gateway connection using an X.509 certificate.
+func newIdentity() *identity.X509Identity {
+	certificate, err := loadCertificate(certPath)
+	if err != nil {
+		panic(err)
+	}
+
+	id, err := identity.NewX509Identity(mspID, certificate)
+	if err != nil {
+		panic(err)
+	}
+
+	return id
+}
+
+func loadCertificate(filename string) (*x509.Certificate, error) {
+	certificatePEM, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	return identity.CertificateFromPEM(certificatePEM)
+}
+
+// newSign creates a function that generates a digital signature from a message digest using a private key.
+func newSign() identity.Sign {
+	files, err := ioutil.ReadDir(keyPath)
+	if err != nil {
+		panic(fmt.Errorf("failed to read private key directory: %w", err))
+	}
+	privateKeyPEM, err := ioutil.ReadFile(path.Join(keyPath, files[0].Name()))
+
+	if err != nil {
+		panic(fmt.Errorf("failed to read private key file: %w", err))
+	}
+
+	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		panic(err)
+	}
+
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	return sign
+}
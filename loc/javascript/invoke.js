@@ -55,8 +55,14 @@ async function main() {
         const contract = network.getContract("managelc");
 
         // Submit the specified transaction.
+        // amount, applicant, description_of_goods_and_services & charges are private to the
+        // applicant & advising banks, they go in the transient field loc_private, not in jsonLoC.
         let jsonLoC;
-        await contract.submitTransaction("IssueLoC", jsonLoC);
+        let privateDetails;
+        await contract
+            .createTransaction("IssueLoC")
+            .setTransient({ loc_private: Buffer.from(JSON.stringify(privateDetails)) })
+            .submit(jsonLoC);
         console.log("Transaction has been submitted");
 
         // Disconnect from the gateway.